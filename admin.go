@@ -0,0 +1,833 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+)
+
+func init() {
+	caddy.RegisterModule(adminAPI{})
+}
+
+// adminEndpointBase is the path prefix for all dns_register admin routes.
+const adminEndpointBase = "/dns_register/"
+
+// adminAPI is a module that serves dns_register endpoints on the
+// Caddy admin API.
+type adminAPI struct {
+	ctx    caddy.Context
+	log    *zap.Logger
+	dnsApp *App
+}
+
+// CaddyModule returns the Caddy module information.
+func (adminAPI) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "admin.api.dns_register",
+		New: func() caddy.Module { return new(adminAPI) },
+	}
+}
+
+// Provision sets up the adminAPI module.
+func (a *adminAPI) Provision(ctx caddy.Context) error {
+	a.ctx = ctx
+	a.log = ctx.Logger(a)
+
+	// The dns_register app is optional; endpoints report an
+	// error if it isn't configured.
+	app, err := ctx.AppIfConfigured("dns_register")
+	if err == nil {
+		a.dnsApp = app.(*App)
+	}
+
+	return nil
+}
+
+// Routes returns the admin routes for the dns_register app.
+func (a *adminAPI) Routes() []caddy.AdminRoute {
+	return []caddy.AdminRoute{
+		{
+			Pattern: adminEndpointBase,
+			Handler: caddy.AdminHandlerFunc(a.handleAPIEndpoints),
+		},
+	}
+}
+
+// handleAPIEndpoints routes API requests within adminEndpointBase.
+func (a *adminAPI) handleAPIEndpoints(w http.ResponseWriter, r *http.Request) error {
+	if a.dnsApp == nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusNotFound,
+			Err:        fmt.Errorf("dns_register app is not configured"),
+		}
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, adminEndpointBase) {
+	case "history":
+		return a.handleHistory(w, r)
+	case "status":
+		return a.handleStatus(w, r)
+	case "ready":
+		return a.handleReady(w, r)
+	case "summary":
+		return a.handleSummary(w, r)
+	case "conditions":
+		return a.handleConditions(w, r)
+	case "freeze":
+		return a.handleFreeze(w, r)
+	case "reconcile":
+		return a.handleReconcile(w, r)
+	case "explain":
+		return a.handleExplain(w, r)
+	case "diff":
+		return a.handleDiff(w, r)
+	case "export":
+		return a.handleExport(w, r)
+	case "backups":
+		return a.handleBackups(w, r)
+	case "restore":
+		return a.handleRestore(w, r)
+	case "config":
+		return a.handleConfig(w, r)
+	case "patch":
+		return a.handlePatch(w, r)
+	case "cancel":
+		return a.handleCancel(w, r)
+	case "apply-plan":
+		return a.handleApplyPlan(w, r)
+	case "owned":
+		return a.handleDeleteOwned(w, r)
+	case "release":
+		return a.handleRelease(w, r)
+	case "pause":
+		return a.handlePause(w, r, true)
+	case "resume":
+		return a.handlePause(w, r, false)
+	}
+	return caddy.APIError{
+		HTTPStatus: http.StatusNotFound,
+		Err:        fmt.Errorf("resource not found: %v", r.URL.Path),
+	}
+}
+
+// handleHistory returns the recent reconcile results, optionally
+// filtered to a single zone with the zone query parameter.
+func (a *adminAPI) handleHistory(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	var zone string
+	if query := r.URL.Query().Get("zone"); query != "" {
+		domain, err := a.domainByZone(query)
+		if err != nil {
+			return err
+		}
+		zone = domain.Zone
+	}
+	return writeJSON(w, a.dnsApp.history.get(zone))
+}
+
+// zoneStatus is the status of a managed zone, as returned by the
+// status endpoint.
+type zoneStatus struct {
+	Zone          string           `json:"zone"`
+	Provider      string           `json:"provider"`
+	ProviderZone  string           `json:"provider_zone,omitempty"`
+	ZoneRecords   int              `json:"zone_records"`
+	Ready         bool             `json:"ready"`
+	LastReconcile *ReconcileResult `json:"last_reconcile,omitempty"`
+}
+
+// handleStatus returns the status of each managed zone: the provider
+// module that services it and the provider's name for the zone if it
+// differs, its record count as of the last reconcile and the result of
+// that reconcile.
+func (a *adminAPI) handleStatus(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	statuses := make([]zoneStatus, 0, len(a.dnsApp.Domains))
+	for _, domain := range a.dnsApp.Domains {
+		status := zoneStatus{
+			Zone:          domain.Zone,
+			Provider:      providerName(domain.provider),
+			ProviderZone:  domain.providerZone,
+			Ready:         a.dnsApp.ready.ready(domain.Zone),
+			LastReconcile: a.dnsApp.history.last(domain.Zone),
+		}
+		if domain.provider == nil {
+			// Not loaded yet with lazy_providers
+			status.Provider = configuredProviderName(domain)
+		}
+		if status.LastReconcile != nil {
+			status.ZoneRecords = status.LastReconcile.ZoneRecords
+		}
+		statuses = append(statuses, status)
+	}
+	return writeJSON(w, statuses)
+}
+
+// readyResponse is the response of the ready endpoint.
+type readyResponse struct {
+	Ready   bool     `json:"ready"`
+	Waiting []string `json:"waiting"`
+}
+
+// handleReady reports whether every managed zone has had a successful
+// reconcile since the app started, and lists the zones still waiting
+// for one. It responds with 503 Service Unavailable until they all
+// have, for use as a readiness probe.
+func (a *adminAPI) handleReady(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	ready, waiting := a.dnsApp.readiness()
+	if !ready {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	return writeJSON(w, readyResponse{Ready: ready, Waiting: waiting})
+}
+
+// handleSummary returns the outcome of the last reconcile of every
+// zone, with an overall success flag, for scripts deciding an exit
+// code after a one-shot run.
+func (a *adminAPI) handleSummary(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	return writeJSON(w, a.dnsApp.summarize(func(domain *Domain) *ReconcileResult {
+		return a.dnsApp.history.last(domain.Zone)
+	}))
+}
+
+// handleConditions returns the status conditions of the zone given in
+// the zone query parameter, or of all zones if it is omitted.
+func (a *adminAPI) handleConditions(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	domains := a.dnsApp.Domains
+	if zone := r.URL.Query().Get("zone"); zone != "" {
+		domain, err := a.domainByZone(zone)
+		if err != nil {
+			return err
+		}
+		domains = []*Domain{domain}
+	}
+	all := make([]ZoneConditions, 0, len(domains))
+	for _, domain := range domains {
+		all = append(all, ZoneConditions{Zone: domain.Zone, Conditions: a.dnsApp.conds.get(domain.Zone)})
+	}
+	return writeJSON(w, all)
+}
+
+// handleReconcile triggers a reconcile of the zone given in the zone
+// query parameter, or of all zones if it is omitted. The reconcile is
+// subject to the reconcile debounce window.
+func (a *adminAPI) handleReconcile(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	zone := r.URL.Query().Get("zone")
+	if zone == "" {
+		return writeJSON(w, map[string][]string{"triggered": a.dnsApp.runCycle(a.dnsApp.Domains, "admin")})
+	}
+	domain, err := a.domainByZone(zone)
+	if err != nil {
+		return err
+	}
+	a.dnsApp.triggerReconcile(a.dnsApp.ctx, domain, "admin")
+	return writeJSON(w, map[string][]string{"triggered": {domain.Zone}})
+}
+
+// handleExplain explains the state of the record set given by the
+// zone, name and type query parameters, and what the next reconcile
+// would do with it.
+func (a *adminAPI) handleExplain(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	query := r.URL.Query()
+	zone, name, typ := query.Get("zone"), query.Get("name"), query.Get("type")
+	if name == "" || typ == "" {
+		return caddy.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        fmt.Errorf("name and type are required"),
+		}
+	}
+	domain, err := a.domainByZone(zone)
+	if err != nil {
+		return err
+	}
+
+	explanation, err := a.dnsApp.explainRecord(domain, name, typ)
+	if err != nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+	return writeJSON(w, explanation)
+}
+
+// handleConfig returns the effective records of the zone given by the
+// zone query parameter, after all config sources are merged and
+// expanded.
+func (a *adminAPI) handleConfig(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	domain, err := a.domainByZone(r.URL.Query().Get("zone"))
+	if err != nil {
+		return err
+	}
+
+	records, err := a.dnsApp.effectiveRecords(domain)
+	if err != nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+	return writeJSON(w, records)
+}
+
+// handleExport returns the records this instance owns in the zone
+// given by the zone query parameter, in the format given by the format
+// query parameter: "json" (the default) or "bind", a zone file.
+func (a *adminAPI) handleExport(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	query := r.URL.Query()
+	zone, format := query.Get("zone"), query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if !slices.Contains(exportFormats, format) {
+		return caddy.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        fmt.Errorf("unsupported format %q, must be one of %v", format, exportFormats),
+		}
+	}
+	domain, err := a.domainByZone(zone)
+	if err != nil {
+		return err
+	}
+
+	records, err := a.dnsApp.ownedRecords(domain)
+	if err != nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+	if format == "json" {
+		return writeJSON(w, records)
+	}
+
+	ttl := a.dnsApp.zoneDefaultTTL(domain)
+	if ttl == 0 {
+		ttl = 300
+	}
+	w.Header().Set("Content-Type", "text/dns")
+	_, _ = w.Write([]byte(renderZoneFile(domain.Zone, ttl, records)))
+	return nil
+}
+
+// handleDiff returns, for each zone of the dns_register app config in
+// the request body that is managed here, what applying that config
+// would change in the zone, without changing anything. The zone query
+// parameter limits it to one zone.
+func (a *adminAPI) handleDiff(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	var proposal App
+	if err := json.NewDecoder(r.Body).Decode(&proposal); err != nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        fmt.Errorf("decoding config: %v", err),
+		}
+	}
+	diffs, err := a.dnsApp.diffProposed(&proposal, r.URL.Query().Get("zone"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errInvalidProposal) {
+			status = http.StatusBadRequest
+		}
+		return caddy.APIError{
+			HTTPStatus: status,
+			Err:        err,
+		}
+	}
+	return writeJSON(w, diffs)
+}
+
+// handleBackups returns the backed-up prior values of the record sets
+// of the zone given in the zone query parameter, oldest first, limited
+// to those matching the optional name and type query parameters.
+func (a *adminAPI) handleBackups(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	query := r.URL.Query()
+	domain, err := a.domainByZone(query.Get("zone"))
+	if err != nil {
+		return err
+	}
+
+	backups, err := a.dnsApp.findBackups(domain.Zone, query.Get("name"), query.Get("type"))
+	if err != nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+	return writeJSON(w, backups)
+}
+
+// handleRestore restores a backed-up prior value of the record set
+// given by the name and type query parameters in the zone given in the
+// zone query parameter, and returns the result of the reconcile that
+// publishes it, or null if reconciliation is paused. The newest backup
+// of the set is restored unless the time query parameter gives the time
+// of another.
+func (a *adminAPI) handleRestore(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	query := r.URL.Query()
+	zone, name, typ := query.Get("zone"), query.Get("name"), query.Get("type")
+	domain, err := a.domainByZone(zone)
+	if err != nil {
+		return err
+	}
+	if name == "" || typ == "" {
+		return caddy.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        fmt.Errorf("name and type are required"),
+		}
+	}
+	var at time.Time
+	if raw := query.Get("time"); raw != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+			return caddy.APIError{
+				HTTPStatus: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid time: %v", err),
+			}
+		}
+	}
+
+	backups, err := a.dnsApp.findBackups(domain.Zone, name, typ)
+	if err != nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+	var backup *recordBackup
+	for i := len(backups) - 1; i >= 0; i-- {
+		if at.IsZero() || backups[i].Time.Equal(at) {
+			backup = &backups[i]
+			break
+		}
+	}
+	if backup == nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusNotFound,
+			Err:        fmt.Errorf("no backup of %s %s", name, strings.ToUpper(typ)),
+		}
+	}
+
+	if err := a.dnsApp.restoreBackup(domain, *backup); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errConfigOverridesRestore) {
+			status = http.StatusConflict
+		}
+		return caddy.APIError{
+			HTTPStatus: status,
+			Err:        err,
+		}
+	}
+	a.log.Info("record set restored from backup",
+		zap.String("zone", domain.Zone),
+		zap.String("record", backup.key()),
+		zap.Time("backup_time", backup.Time))
+
+	// Errors are recorded in the reconcile result, which is nil while
+	// reconciliation is paused
+	return writeJSON(w, a.dnsApp.runReconcile(a.dnsApp.ctx, domain))
+}
+
+// patchRequest is the request body of the patch endpoint.
+type patchRequest struct {
+	Add    []*Record      `json:"add,omitempty"`
+	Remove []patchRemoval `json:"remove,omitempty"`
+}
+
+// handlePatch adds records to and removes record sets from the zone
+// given in the zone query parameter, on top of its configured records.
+// The patch is persisted and applied immediately with a reconcile of the
+// zone, whose result is returned; while reconciliation is paused it is
+// only persisted, and the result is null.
+func (a *adminAPI) handlePatch(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	domain, err := a.domainByZone(r.URL.Query().Get("zone"))
+	if err != nil {
+		return err
+	}
+
+	var req patchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        fmt.Errorf("decoding patch: %v", err),
+		}
+	}
+	if err := a.dnsApp.addPatch(domain.Zone, req.Add, req.Remove); err != nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+	a.log.Info("zone patched",
+		zap.String("zone", domain.Zone),
+		zap.Int("added", len(req.Add)),
+		zap.Int("removed", len(req.Remove)))
+
+	// Errors are recorded in the reconcile result, which is nil while
+	// reconciliation is paused
+	return writeJSON(w, a.dnsApp.runReconcile(a.dnsApp.ctx, domain))
+}
+
+// handleCancel cancels the reconcile in progress for the zone given in
+// the zone query parameter, and reports whether one was running.
+func (a *adminAPI) handleCancel(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	domain, err := a.domainByZone(r.URL.Query().Get("zone"))
+	if err != nil {
+		return err
+	}
+
+	cancelled := a.dnsApp.running.cancel(domain.Zone)
+	if cancelled {
+		a.log.Info("reconcile cancelled", zap.String("zone", domain.Zone))
+	}
+	return writeJSON(w, map[string]bool{"cancelled": cancelled})
+}
+
+// handleApplyPlan applies the plan written in plan mode for the zone
+// given in the zone query parameter, and returns the reconcile result.
+func (a *adminAPI) handleApplyPlan(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	domain, err := a.domainByZone(r.URL.Query().Get("zone"))
+	if err != nil {
+		return err
+	}
+	if until, frozen := a.dnsApp.frozen(); frozen {
+		return caddy.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        fmt.Errorf("change freeze in effect until %s", until.Format(time.RFC3339)),
+		}
+	}
+	if a.dnsApp.paused != nil && a.dnsApp.paused.Load() {
+		return caddy.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        fmt.Errorf("reconciliation is paused"),
+		}
+	}
+
+	result, err := a.dnsApp.applyPlanFile(domain)
+	if errors.Is(err, fs.ErrNotExist) {
+		return caddy.APIError{
+			HTTPStatus: http.StatusNotFound,
+			Err:        fmt.Errorf("no plan for zone: %s", domain.Zone),
+		}
+	}
+	if err != nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+	return writeJSON(w, result)
+}
+
+// deleteOwnedResponse is the response body of the owned endpoint.
+type deleteOwnedResponse struct {
+	Deleted int             `json:"deleted"`
+	Result  ReconcileResult `json:"result"`
+}
+
+// handleDeleteOwned deletes every record this instance owns in the zone
+// given in the zone query parameter, with their markers, and returns the
+// number of record sets deleted. The confirm query parameter must repeat
+// the zone name.
+func (a *adminAPI) handleDeleteOwned(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodDelete {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	domain, err := a.domainByZone(r.URL.Query().Get("zone"))
+	if err != nil {
+		return err
+	}
+	if confirm, _ := normalizeZone(r.URL.Query().Get("confirm")); confirm != domain.Zone {
+		return caddy.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        fmt.Errorf("deleting all owned records requires confirm=%s", domain.Zone),
+		}
+	}
+	if until, frozen := a.dnsApp.frozen(); frozen {
+		return caddy.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        fmt.Errorf("change freeze in effect until %s", until.Format(time.RFC3339)),
+		}
+	}
+	if a.dnsApp.paused != nil && a.dnsApp.paused.Load() {
+		return caddy.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        fmt.Errorf("reconciliation is paused"),
+		}
+	}
+
+	result, err := a.dnsApp.deleteOwned(domain)
+	if err != nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+	a.log.Warn("deleted all owned records",
+		zap.String("zone", domain.Zone),
+		zap.Int("deleted", len(result.Deleted)))
+	return writeJSON(w, deleteOwnedResponse{Deleted: len(result.Deleted), Result: result})
+}
+
+// releaseResponse is the response body of the release endpoint.
+type releaseResponse struct {
+	Released int             `json:"released"`
+	Result   ReconcileResult `json:"result"`
+}
+
+// handleRelease releases every record this instance owns in the zone
+// given in the zone query parameter: their ownership markers are
+// deleted, leaving the records in place. It returns the number of
+// record sets released.
+func (a *adminAPI) handleRelease(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	domain, err := a.domainByZone(r.URL.Query().Get("zone"))
+	if err != nil {
+		return err
+	}
+	if until, frozen := a.dnsApp.frozen(); frozen {
+		return caddy.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        fmt.Errorf("change freeze in effect until %s", until.Format(time.RFC3339)),
+		}
+	}
+	if a.dnsApp.paused != nil && a.dnsApp.paused.Load() {
+		return caddy.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        fmt.Errorf("reconciliation is paused"),
+		}
+	}
+
+	result, err := a.dnsApp.releaseOwned(domain)
+	if err != nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+	a.log.Info("released owned records",
+		zap.String("zone", domain.Zone),
+		zap.Int("released", len(result.Released)))
+	return writeJSON(w, releaseResponse{Released: len(result.Released), Result: result})
+}
+
+// handlePause pauses or resumes all reconciliation. While paused,
+// reconciles are skipped rather than queued.
+func (a *adminAPI) handlePause(w http.ResponseWriter, r *http.Request, pause bool) error {
+	if r.Method != http.MethodPost {
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	a.dnsApp.paused.Store(pause)
+	if pause {
+		a.log.Info("reconciliation paused")
+	} else {
+		a.log.Info("reconciliation resumed")
+	}
+	return writeJSON(w, map[string]bool{"paused": pause})
+}
+
+// freezeStatus is the response body of the freeze endpoint.
+type freezeStatus struct {
+	Frozen bool       `json:"frozen"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// handleFreeze reports (GET), sets (POST) or clears (DELETE) the change
+// freeze. POST takes the end of the freeze as an RFC 3339 timestamp in
+// the until query parameter.
+func (a *adminAPI) handleFreeze(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		until, err := time.Parse(time.RFC3339, r.URL.Query().Get("until"))
+		if err != nil {
+			return caddy.APIError{
+				HTTPStatus: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid until: %v", err),
+			}
+		}
+		a.dnsApp.setFreeze(until)
+		a.log.Info("change freeze set", zap.Time("until", until))
+	case http.MethodDelete:
+		a.dnsApp.setFreeze(time.Time{})
+		a.log.Info("change freeze cleared")
+	default:
+		return caddy.APIError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("method not allowed: %v", r.Method),
+		}
+	}
+
+	status := freezeStatus{}
+	if until, frozen := a.dnsApp.frozen(); frozen {
+		status.Frozen = true
+		status.Until = &until
+	}
+	return writeJSON(w, status)
+}
+
+// domainByZone returns the managed domain of the zone given in a zone
+// query parameter, normalized like configured zones so that e.g.
+// "Example.com." or an IDN zone in Unicode finds it, or a not found
+// error.
+func (a *adminAPI) domainByZone(zone string) (*Domain, error) {
+	if name, err := normalizeZone(zone); err == nil {
+		for _, domain := range a.dnsApp.Domains {
+			if domain.Zone == name {
+				return domain, nil
+			}
+		}
+	}
+	return nil, caddy.APIError{
+		HTTPStatus: http.StatusNotFound,
+		Err:        fmt.Errorf("unknown zone: %s", zone),
+	}
+}
+
+// writeJSON encodes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, v any) error {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return caddy.APIError{
+			HTTPStatus: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(encoded)
+
+	return nil
+}
+
+// Interface guards
+var (
+	_ caddy.AdminRouter = (*adminAPI)(nil)
+	_ caddy.Provisioner = (*adminAPI)(nil)
+)
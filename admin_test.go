@@ -0,0 +1,211 @@
+package dnsregister
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+func TestAdminPauseResume(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.paused = new(atomic.Bool)
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	post := func(path string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, adminEndpointBase+path, nil)
+		if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); err != nil {
+			t.Fatalf("POST %s failed: %v", path, err)
+		}
+	}
+
+	post("pause")
+	app.triggerReconcile(app.ctx, app.Domains[0], "test")
+	if provider.gets != 0 {
+		t.Errorf("expected no reconcile while paused, got %d", provider.gets)
+	}
+
+	post("resume")
+	app.triggerReconcile(app.ctx, app.Domains[0], "test")
+	if provider.gets != 1 {
+		t.Errorf("expected a reconcile after resuming, got %d", provider.gets)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"pause", nil)
+	if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); err == nil {
+		t.Error("expected GET on pause to be rejected")
+	}
+}
+
+// fakeModuleProvider is a fakeProvider that is a Caddy module.
+type fakeModuleProvider struct {
+	fakeProvider
+}
+
+func (*fakeModuleProvider) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{ID: "dns.providers.fake"}
+}
+
+func TestAdminStatus(t *testing.T) {
+	app := newTestApp(t, &fakeModuleProvider{}, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	// The record count is taken before changes are applied
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"status", nil)
+	if err := api.handleAPIEndpoints(rec, req); err != nil {
+		t.Fatalf("GET status failed: %v", err)
+	}
+
+	var statuses []zoneStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("decoding status: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].Provider != "dns.providers.fake" {
+		t.Fatalf("expected provider module ID in status, got %+v", statuses)
+	}
+	if last := statuses[0].LastReconcile; last == nil || last.Zone != "example.com" {
+		t.Errorf("expected last reconcile in status, got %+v", last)
+	}
+	if statuses[0].ZoneRecords != 2 {
+		t.Errorf("expected 2 zone records (record and marker), got %d", statuses[0].ZoneRecords)
+	}
+
+	if got := providerName(&fakeProvider{}); got != "*dnsregister.fakeProvider" {
+		t.Errorf("expected Go type for non-module provider, got %q", got)
+	}
+}
+
+// fakeHangingProvider is a fakeProvider whose GetRecords blocks until
+// its context is done.
+type fakeHangingProvider struct {
+	fakeProvider
+	entered chan struct{}
+}
+
+func (p *fakeHangingProvider) GetRecords(ctx context.Context, _ string) ([]libdns.Record, error) {
+	close(p.entered)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestAdminCancel(t *testing.T) {
+	provider := &fakeHangingProvider{entered: make(chan struct{})}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.running = newRunningReconciles()
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	cancel := func(zone string) (bool, error) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, adminEndpointBase+"cancel?zone="+zone, nil)
+		if err := api.handleAPIEndpoints(rec, req); err != nil {
+			return false, err
+		}
+		var resp map[string]bool
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		return resp["cancelled"], nil
+	}
+
+	done := make(chan error)
+	go func() { done <- app.reconcileDomain(app.Domains[0]) }()
+	<-provider.entered
+
+	if cancelled, err := cancel("example.com"); err != nil || !cancelled {
+		t.Fatalf("expected running reconcile to be cancelled, got %v, %v", cancelled, err)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected reconcile to fail with context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reconcile did not return after cancel")
+	}
+
+	if cancelled, err := cancel("example.com"); err != nil || cancelled {
+		t.Errorf("expected nothing to cancel once the reconcile ended, got %v, %v", cancelled, err)
+	}
+	if _, err := cancel("unknown.example"); err == nil {
+		t.Error("expected unknown zone to be rejected")
+	}
+}
+
+func TestAdminZoneLookup(t *testing.T) {
+	app := newTestApp(t, &fakeProvider{}, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.Domains = append(app.Domains, &Domain{Zone: "xn--bcher-kva.example", provider: &fakeProvider{}})
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	for query, want := range map[string]string{
+		"example.com":           "example.com",
+		"example.com.":          "example.com",
+		"EXAMPLE.Com":           "example.com",
+		"xn--bcher-kva.example": "xn--bcher-kva.example",
+		"bücher.example":        "xn--bcher-kva.example",
+		"Bücher.Example.":       "xn--bcher-kva.example",
+		"unknown.example":       "",
+		"":                      "",
+	} {
+		domain, err := api.domainByZone(query)
+		if want == "" {
+			var apiErr caddy.APIError
+			if !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusNotFound {
+				t.Errorf("%q: expected a not found error, got %v, %v", query, domain, err)
+			}
+			continue
+		}
+		if err != nil || domain.Zone != want {
+			t.Errorf("%q: expected zone %s, got %v, %v", query, want, domain, err)
+		}
+	}
+
+	// Endpoints look up zones through it
+	req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"config?zone="+url.QueryEscape("Example.COM."), nil)
+	if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); err != nil {
+		t.Errorf("GET config with a trailing dot and uppercase failed: %v", err)
+	}
+	// The history endpoint filters by the normalized zone
+	app.history = newReconcileHistory(0)
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	for _, zone := range []string{"example.com.", "Example.COM"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"history?zone="+url.QueryEscape(zone), nil)
+		if err := api.handleAPIEndpoints(rec, req); err != nil {
+			t.Fatalf("GET history for %q failed: %v", zone, err)
+		}
+		var results []ReconcileResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if len(results) != 1 || results[0].Zone != "example.com" {
+			t.Errorf("%q: expected the zone's history, got %+v", zone, results)
+		}
+	}
+	req = httptest.NewRequest(http.MethodGet, adminEndpointBase+"history?zone=unknown.example", nil)
+	var apiErr caddy.APIError
+	if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusNotFound {
+		t.Errorf("expected history of an unknown zone to be not found, got %v", err)
+	}
+}
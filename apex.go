@@ -0,0 +1,51 @@
+package dnsregister
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// apexTypes are the record types the zone apex holds for the zone's
+// delegation, which the provider creates along with the zone.
+var apexTypes = []string{"NS", "SOA"}
+
+// isApexKey reports whether key (name:type) is that of an apex set of
+// one of apexTypes. Such sets are updated in place, never created
+// alongside the provider's own records, and never deleted, which would
+// break the zone's delegation.
+func isApexKey(key string) bool {
+	name, typ, _ := strings.Cut(key, ":")
+	return name == "@" && slices.Contains(apexTypes, typ)
+}
+
+// adoptApexRecords adds the apex sets of apexTypes in existing that are
+// desired but not owned to owned, so that they are matched against the
+// desired records and updated in place if they differ, rather than
+// created as new sets.
+func (a *App) adoptApexRecords(existing []libdns.Record, desired, owned map[string][]*Record) {
+	apex := make(map[string][]*Record)
+	for _, rec := range existing {
+		rr := rec.RR()
+		key := rr.Name + ":" + rr.Type
+		if !isApexKey(key) {
+			continue
+		}
+		if _, ok := desired[key]; !ok {
+			continue
+		}
+		if _, ok := owned[key]; ok {
+			continue
+		}
+		apex[key] = append(apex[key], &Record{
+			Name:  rr.Name,
+			Type:  rr.Type,
+			Value: a.extractValue(rec),
+			TTL:   int(rr.TTL.Seconds()),
+		})
+	}
+	for key, recs := range apex {
+		owned[key] = recs
+	}
+}
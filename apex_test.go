@@ -0,0 +1,53 @@
+package dnsregister
+
+import (
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestReconcileApexNS(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "@", Type: "NS", Data: "ns1.provider.net."},
+		libdns.RR{Name: "@", Type: "NS", Data: "ns2.provider.net."},
+	}}
+	app := newTestApp(t, provider,
+		&Record{Name: "@", Type: "NS", Value: "ns1.provider.net."},
+		&Record{Name: "@", Type: "NS", Value: "ns2.provider.net."})
+	app.history = newReconcileHistory(0)
+	domain := app.Domains[0]
+
+	// Matching the provider's records, nothing is created alongside them
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.last("example.com")
+	if len(result.Created) != 0 || len(result.Updated) != 0 || len(provider.records) != 2 {
+		t.Errorf("expected no changes, got %+v and %v", result, provider.records)
+	}
+
+	// Changed values are updated in place
+	domain.Records[1].Value = "ns3.provider.net."
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result = app.history.last("example.com")
+	if len(result.Created) != 0 || len(result.Updated) != 1 || result.Updated[0] != "@:NS" {
+		t.Errorf("expected @:NS to be updated, got %+v", result)
+	}
+	if len(provider.records) != 2 ||
+		!hasRecord(provider.records, "@", "NS", "ns1.provider.net.") ||
+		!hasRecord(provider.records, "@", "NS", "ns3.provider.net.") {
+		t.Errorf("expected the apex NS set to be replaced, got %v", provider.records)
+	}
+
+	// Removed from the config, the apex NS set is kept
+	domain.Records = nil
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result = app.history.last("example.com")
+	if len(result.Deleted) != 0 || len(provider.records) != 2 {
+		t.Errorf("expected the apex NS set to be kept, got %+v and %v", result, provider.records)
+	}
+}
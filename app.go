@@ -5,12 +5,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
 	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyevents"
 	"github.com/libdns/libdns"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -27,10 +39,233 @@ type App struct {
 	// Domains contains the DNS zones and records to manage.
 	Domains []*Domain `json:"domains,omitempty"`
 
+	// HistorySize is the number of recent reconcile results kept in
+	// memory per zone, exposed via the admin API. Defaults to 20.
+	HistorySize int `json:"history_size,omitempty"`
+
+	// MarkerlessTypes lists record types that are managed without a
+	// "_cdr." ownership marker. Ownership of these records is tracked in
+	// a state file in Caddy's data directory instead.
+	MarkerlessTypes []string `json:"markerless_types,omitempty"`
+
+	// ResumeOnCrash persists each reconcile plan to Caddy's data
+	// directory before applying it. If Caddy stops mid-apply, the plan
+	// is verified on the next start: records created without their
+	// ownership marker are claimed and orphaned markers are removed.
+	ResumeOnCrash bool `json:"resume_on_crash,omitempty"`
+
+	// FreezeUntil is an RFC 3339 timestamp before which no changes are
+	// applied. Reconciles still compute and report the pending diff.
+	// The freeze can also be set or cleared via the admin API.
+	FreezeUntil string `json:"freeze_until,omitempty"`
+
+	// ReconcileDebounce coalesces reconcile triggers for a zone (config
+	// loads, admin requests) that arrive within this window into a
+	// single reconcile, run when the window closes. Zero reconciles on
+	// every trigger immediately.
+	ReconcileDebounce caddy.Duration `json:"reconcile_debounce,omitempty"`
+
+	// ReconcileInterval reconciles all domains again this long after
+	// the previous reconcile cycle ended, so that records changed
+	// outside of Caddy are corrected. Zero (the default) reconciles
+	// only on start and when triggered via the admin API.
+	ReconcileInterval caddy.Duration `json:"reconcile_interval,omitempty"`
+
+	// RecordsCacheTTL is how long GetRecords results are reused for
+	// domains sharing a provider and zone. Zero (the default) reuses
+	// them only within a single reconcile of all domains.
+	RecordsCacheTTL caddy.Duration `json:"records_cache_ttl,omitempty"`
+
+	// VerifyInterval lets a reconcile skip reading the zone if its
+	// desired records, values resolved from SRV lookups included, are
+	// the same as when a reconcile last found the zone in sync, less
+	// than this long ago. Drift made outside of reconciles is then
+	// caught within this interval. Zero (the default) reads the zone on
+	// every reconcile.
+	VerifyInterval caddy.Duration `json:"verify_interval,omitempty"`
+
+	// CycleDeadline bounds how long a reconcile cycle of all domains,
+	// on start or triggered via the admin API, may take, retries
+	// included. Reconciles still running or waiting for a worker at the
+	// deadline are cancelled. Zero means no deadline.
+	CycleDeadline caddy.Duration `json:"cycle_deadline,omitempty"`
+
+	// MaxConcurrency bounds the number of reconciles that run at once,
+	// across all domains, including those of a reconcile cycle, which
+	// are started together. Further reconciles wait for one to finish.
+	// Zero (the default) runs reconciles without a bound.
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+
+	// DisableRecordMetrics turns off the per-record
+	// dns_register_record_in_sync gauge, e.g. for large zones where
+	// its cardinality is a concern.
+	DisableRecordMetrics bool `json:"disable_record_metrics,omitempty"`
+
+	// MarkerType is the record type of ownership markers. Defaults to
+	// TXT; another type the provider accepts can be used where TXT
+	// records are restricted. Marker data is the same for all types.
+	MarkerType string `json:"marker_type,omitempty"`
+
+	// RegistryFormat is the format of ownership marker data:
+	// "caddy-dns-register" (the default) or "external-dns", for
+	// migrating from Kubernetes external-dns. With "external-dns",
+	// markers are written as external-dns TXT registry entries
+	// ("heritage=external-dns,external-dns/owner=<owner_id>"), and
+	// existing entries of this owner ID are recognized as markers,
+	// including those external-dns writes at "<type>-<name>" or at the
+	// record's own name. It can't be combined with MarkerFormat.
+	RegistryFormat string `json:"registry_format,omitempty"`
+
+	// RegistryLayout is where markers are written with the external-dns
+	// registry format: "marker-prefix" (the default) writes one marker
+	// per name at "_cdr.<name>", "type-prefix" writes one per record
+	// set at "<type>-<name>" (e.g. "a-www"), like newer external-dns
+	// versions. Markers of the other layout are still recognized. It
+	// requires RegistryFormat "external-dns" and TXT markers.
+	RegistryLayout string `json:"registry_layout,omitempty"`
+
+	// MarkerFormat is the format of ownership marker data, with the
+	// placeholders {owner}, {heritage} and {priority}, e.g.
+	// "owner={owner};heritage={heritage}". It must include {owner} and
+	// {heritage}, and {priority} if InstancePriority is set. Defaults to
+	// the comma-separated "owner=...,heritage=...[,priority=...]" form,
+	// which is also still recognized after changing the format.
+	MarkerFormat string `json:"marker_format,omitempty"`
+
+	// PlanDir enables plan mode: instead of applying changes, each
+	// reconcile writes its plan to "<zone>.json" in this directory. A
+	// written plan is applied with the admin API's apply-plan endpoint,
+	// e.g. after external approval.
+	PlanDir string `json:"plan_dir,omitempty"`
+
+	// InstancePriority resolves contention between instances with
+	// different owner IDs that want the same records. It is recorded in
+	// this instance's markers, and records marked by an instance with a
+	// higher priority are left to that instance.
+	InstancePriority int `json:"instance_priority,omitempty"`
+
+	// RedactValues omits record values from info-level logs, for
+	// environments where values such as internal addresses must not
+	// reach shipped logs. Values are still logged at debug level.
+	RedactValues bool `json:"redact_values,omitempty"`
+
+	// PatchPrecedence decides which wins when a record set is both
+	// configured and changed via the admin API's patch endpoint:
+	// "config" (the default) or "patch".
+	PatchPrecedence string `json:"patch_precedence,omitempty"`
+
+	// ZoneBoundary sets how records whose names lie at or below a zone
+	// cut (a delegation in the zone, or another configured zone within
+	// it) are handled: "warn" (the default) logs a warning and manages
+	// them anyway, "skip" leaves them unmanaged, and "off" disables the
+	// check.
+	ZoneBoundary string `json:"zone_boundary,omitempty"`
+
+	// PreserveCase writes record names and hostnames in record values
+	// (CNAME, MX and SRV targets, etc.) in their configured case. By
+	// default they are written lowercased. Either way they are compared
+	// case-insensitively, while other data such as TXT content is
+	// always written verbatim and compared exactly.
+	PreserveCase bool `json:"preserve_case,omitempty"`
+
+	// TwoPhaseMarkers writes new records first and their ownership
+	// markers in a separate call once the records were written, so that
+	// a marker only exists for records that were actually created. If
+	// writing a marker fails, the records stay unmarked until the next
+	// reconcile. By default each set is written with its marker in one
+	// call. Transactional providers always apply both at once.
+	TwoPhaseMarkers bool `json:"two_phase_markers,omitempty"`
+
+	// ConfirmDeletes re-reads the zone after deleting record sets to
+	// confirm that their records and markers are gone, deleting them
+	// once more if not, for providers that apply deletes lazily or
+	// eventually. A set still present after that is reported as failed.
+	ConfirmDeletes bool `json:"confirm_deletes,omitempty"`
+
+	// ResolveTypeConflicts deletes existing record sets that a record
+	// set to create can't coexist with (a CNAME and records of any
+	// other type at the same name), owned or not, before creating it.
+	// By default such creates are skipped and reported as errors.
+	ResolveTypeConflicts bool `json:"resolve_type_conflicts,omitempty"`
+
+	// RemoveDuplicates deletes the extra copies of records the provider
+	// returns more than once with the same name, type and data, for
+	// owned record sets that stay configured, and rewrites the sets.
+	// Duplicates are always ignored when comparing records.
+	RemoveDuplicates bool `json:"remove_duplicates,omitempty"`
+
+	// MaxRecordsPerZone is the most records, ownership markers
+	// included, that a reconcile may leave in a zone, for providers
+	// that cap the size of a zone. If a reconcile's plan would exceed
+	// it, its creates and the updates that add records are not applied
+	// and reported as an error instead. Zero (the default) means no
+	// limit.
+	MaxRecordsPerZone int `json:"max_records_per_zone,omitempty"`
+
+	// KeepBackups is the number of prior values kept per record set in
+	// Caddy's data directory: before a reconcile updates or deletes an
+	// owned set, its records are backed up, so that a clobbered value
+	// can be listed and restored via the admin API. Zero (the default)
+	// disables backups.
+	KeepBackups int `json:"keep_backups,omitempty"`
+
+	// LazyProviders defers loading each domain's DNS provider module to
+	// its first reconcile, so Caddy starts faster when providers do
+	// network setup, and domains that are never reconciled never load
+	// theirs. Loading errors are then reported by the reconcile rather
+	// than failing the config load. By default providers are loaded
+	// when the config is loaded.
+	LazyProviders bool `json:"lazy_providers,omitempty"`
+
+	// HTTPTimeout bounds outbound HTTP requests made by the app.
+	// Defaults to 30s.
+	HTTPTimeout caddy.Duration `json:"http_timeout,omitempty"`
+
+	// CACert is the path to a PEM file of CA certificates trusted for
+	// outbound HTTPS requests in addition to the system roots, e.g. for
+	// internal endpoints.
+	CACert string `json:"ca_cert,omitempty"`
+
+	// StatusWebhook is a URL to which each zone's status conditions
+	// (Ready and Degraded, shaped like Kubernetes status conditions)
+	// are posted as JSON whenever the status or reason of one of them
+	// changes. The conditions are also available via the admin API.
+	StatusWebhook string `json:"status_webhook,omitempty"`
+
+	// PrintChanges prints each record change applied, or failed to
+	// apply, by a reconcile to standard output as a single-line JSON
+	// object, separately from Caddy's logs, e.g. for piping into jq.
+	PrintChanges bool `json:"print_changes,omitempty"`
+
+	// Ownership, if set, decides which existing records this instance
+	// owns in place of the ownership markers. It can't be configured
+	// and is meant for embedders setting it before Provision. See
+	// OwnershipFunc.
+	Ownership OwnershipFunc `json:"-"`
+
 	// Runtime state
-	logger *zap.Logger
-	ctx    context.Context
-	cancel context.CancelFunc
+	logger    *zap.Logger
+	ctx       context.Context
+	cancel    context.CancelFunc
+	history   *reconcileHistory
+	resolver  srvResolver
+	dataDir   string
+	freeze    *freezeWindow
+	cache     *recordsCache
+	paused    *atomic.Bool
+	failures  *failedRecords
+	running   *runningReconciles
+	workers   *reconcileWorkers
+	patches   *zonePatches
+	conds     *zoneConditionStore
+	printer   *changePrinter
+	zoneLocks *zoneLocks
+	ready     *readyZones
+	unmarked  *unmarkedRecords
+	markerRE  *regexp.Regexp
+	client    *http.Client
+	events    *caddyevents.App
+	caddyCtx  caddy.Context
 }
 
 // Domain represents a DNS zone with its provider and records.
@@ -44,8 +279,71 @@ type Domain struct {
 	// Records are the DNS records to manage in this zone.
 	Records []*Record `json:"records,omitempty"`
 
+	// RecordTemplates are expanded into Records at provision time, once
+	// per entry in their host list.
+	RecordTemplates []*RecordTemplate `json:"record_templates,omitempty"`
+
+	// AuthoritativePrefixes are names under which the zone is managed
+	// authoritatively: records at or below these names that carry no
+	// ownership marker and are not in config are deleted. The rest of
+	// the zone is left alone.
+	AuthoritativePrefixes []string `json:"authoritative_prefixes,omitempty"`
+
+	// MinTTL is the minimum TTL in seconds the provider accepts for the
+	// zone. It overrides a minimum reported by the provider. Records
+	// below it are reported at provision time.
+	MinTTL int `json:"min_ttl,omitempty"`
+
+	// ClampTTL raises record TTLs below the minimum to the minimum
+	// instead of only reporting them.
+	ClampTTL bool `json:"clamp_ttl,omitempty"`
+
+	// ReadTimeout bounds each call that reads from the provider, such
+	// as fetching the zone's records. No timeout by default.
+	ReadTimeout caddy.Duration `json:"read_timeout,omitempty"`
+
+	// WriteTimeout bounds each call that changes records at the
+	// provider. No timeout by default.
+	WriteTimeout caddy.Duration `json:"write_timeout,omitempty"`
+
+	// InterCallDelay is the least time between the end of one call that
+	// changes records at the provider and the start of the next, for
+	// APIs that reject bursts of calls. No delay by default.
+	InterCallDelay caddy.Duration `json:"inter_call_delay,omitempty"`
+
+	// ProviderConcurrency is the number of record sets whose changes
+	// are applied at once when they are applied one set at a time,
+	// i.e. when the provider can't apply them in a single batch. Sets
+	// sharing a name, and sets depending on others, still wait for
+	// each other. Defaults to 1, applying sets one after another.
+	ProviderConcurrency int `json:"provider_concurrency,omitempty"`
+
+	// BumpSOASerial raises the serial of the zone's SOA record after
+	// every reconcile that changed records of the zone, so that
+	// secondaries pick up the change. "counter" adds one to the serial,
+	// "date" keeps it in the YYYYMMDDnn form. The serial never
+	// decreases, even if a configured SOA has a lower one. Disabled
+	// by default.
+	BumpSOASerial string `json:"bump_soa_serial,omitempty"`
+
+	// ManageWhen, if set, is checked at the start of every reconcile
+	// of the domain. While it is false the domain is skipped: nothing
+	// is created, updated or deleted. This lets only the active node of
+	// an active/passive pair write records.
+	ManageWhen *Condition `json:"manage_when,omitempty"`
+
 	// Runtime: loaded provider (implements libdns interfaces)
 	provider any
+	lazy     *lazyProvider
+	pacer    *writePacer
+	setSlots chan struct{}
+
+	// Runtime: the provider's name for the zone, if it differs
+	providerZone string
+
+	// Runtime: hash of the provider config, taken before loading the
+	// provider clears DNSProviderRaw
+	providerHash string
 }
 
 // Record represents a DNS record to manage.
@@ -53,14 +351,94 @@ type Record struct {
 	// Name is the record name relative to the zone (e.g., "www" or "@" for apex).
 	Name string `json:"name"`
 
-	// Type is the record type (A, AAAA, CNAME, TXT, MX, NS).
+	// Type is the record type (A, AAAA, CNAME, TXT, MX, SRV, NS).
 	Type string `json:"type"`
 
 	// Value is the record value (IP address, target domain, text, etc.).
+	// A value of the form "file:<path>" is replaced with the contents of
+	// the file at provision time, without trailing newlines.
 	Value string `json:"value"`
 
-	// TTL is the time-to-live in seconds. Defaults to 300 if not specified.
+	// TTL is the time-to-live in seconds. Defaults to the zone's default
+	// TTL if the provider reports one, otherwise 300.
 	TTL int `json:"ttl,omitempty"`
+
+	// FromSRV, if set, is a service name (e.g. "_http._tcp.internal")
+	// whose SRV targets are resolved on each reconcile and published as
+	// the record's values instead of Value. Only valid for A and AAAA.
+	FromSRV string `json:"from_srv,omitempty"`
+
+	// Ordered makes the order of values significant when comparing a
+	// multi-value set (records sharing a name and type) with the
+	// provider's records. By default sets are compared regardless of
+	// order. Only enable this if the provider preserves record order.
+	Ordered bool `json:"ordered,omitempty"`
+
+	// SPF marks a TXT record as part of the name's SPF policy. The
+	// values of all SPF records at a name are merged into a single
+	// "v=spf1" record, so each can list its own includes.
+	SPF bool `json:"spf,omitempty"`
+
+	// ValidFrom and ValidUntil are RFC 3339 timestamps bounding when
+	// the record is published. Outside the window the record is treated
+	// as not configured, so an owned record is deleted. ValidFrom is
+	// inclusive and ValidUntil exclusive; either may be omitted.
+	ValidFrom  string `json:"valid_from,omitempty"`
+	ValidUntil string `json:"valid_until,omitempty"`
+
+	// DependsOn references records of the same domain, as "<name>" or
+	// "<name>:<type>", that must exist before this record is created,
+	// e.g. the target of a CNAME. When both are created by the same
+	// reconcile, the dependencies are created first, and this record is
+	// not created if creating one of them failed.
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	// Release stops managing the record without deleting it: if this
+	// instance owns its set, only the ownership marker is removed (or,
+	// for markerless types, the state file entry), handing the records
+	// over to whoever manages them next. Released records are not
+	// created. A set can only be released once no other managed set
+	// shares its marker.
+	Release bool `json:"release,omitempty"`
+
+	// CreateOnly creates the record if its set is absent from the zone
+	// but never updates or deletes it afterwards, leaving its values to
+	// be curated by hand. A set that exists unowned is left alone too.
+	CreateOnly bool `json:"create_only,omitempty"`
+
+	// ProviderComment is written to the comment the provider stores
+	// with the record, for providers that implement
+	// RecordCommentProvider, and a changed comment updates the record.
+	// It is unrelated to the ownership marker. Other providers ignore
+	// it.
+	ProviderComment string `json:"provider_comment,omitempty"`
+}
+
+// RecordTemplate is a record whose name and value contain placeholders
+// (e.g. "{host}" and "{ip}") that are filled in from each entry of Hosts
+// to produce one record per entry.
+type RecordTemplate struct {
+	Record
+
+	// Hosts holds the placeholder values for each record to produce.
+	Hosts []map[string]string `json:"hosts,omitempty"`
+}
+
+// expand returns the records produced by the template.
+func (t *RecordTemplate) expand() []*Record {
+	recs := make([]*Record, 0, len(t.Hosts))
+	for _, vars := range t.Hosts {
+		repl := caddy.NewEmptyReplacer()
+		for k, v := range vars {
+			repl.Set(k, v)
+		}
+		rec := t.Record
+		rec.Name = repl.ReplaceKnown(rec.Name, "")
+		rec.Value = repl.ReplaceKnown(rec.Value, "")
+		rec.FromSRV = repl.ReplaceKnown(rec.FromSRV, "")
+		recs = append(recs, &rec)
+	}
+	return recs
 }
 
 // CaddyModule returns the Caddy module information.
@@ -74,43 +452,283 @@ func (App) CaddyModule() caddy.ModuleInfo {
 // Provision sets up the app.
 func (a *App) Provision(ctx caddy.Context) error {
 	a.logger = ctx.Logger()
+	a.caddyCtx = ctx
 	a.ctx, a.cancel = context.WithCancel(ctx)
+	a.history = newReconcileHistory(a.HistorySize)
+	a.resolver = net.DefaultResolver
+	a.dataDir = filepath.Join(caddy.AppDataDir(), "dns_register")
+	a.freeze = new(freezeWindow)
+	a.paused = new(atomic.Bool)
+	a.failures = &failedRecords{zones: make(map[string]*failedZone)}
+	a.running = newRunningReconciles()
+	a.workers = newReconcileWorkers(a.MaxConcurrency)
+	a.patches = newZonePatches()
+	a.conds = newZoneConditionStore()
+	a.zoneLocks = newZoneLocks()
+	a.ready = newReadyZones()
+	a.unmarked = newUnmarkedRecords()
+	if a.PrintChanges {
+		a.printer = &changePrinter{out: os.Stdout}
+	}
+	a.cache = newRecordsCache(time.Duration(a.RecordsCacheTTL))
+	initMetrics(ctx.GetMetricsRegistry())
+	if err := a.loadEventsApp(ctx); err != nil {
+		return fmt.Errorf("loading events app: %v", err)
+	}
+
+	client, err := newHTTPClient(time.Duration(a.HTTPTimeout), a.CACert)
+	if err != nil {
+		return err
+	}
+	a.client = client
+
+	if a.StatusWebhook != "" {
+		u, err := url.Parse(a.StatusWebhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid status_webhook %q: must be an http or https URL", a.StatusWebhook)
+		}
+	}
 
 	// Default owner ID
 	if a.OwnerID == "" {
 		a.OwnerID = "caddy"
 	}
 
+	if a.FreezeUntil != "" {
+		until, err := time.Parse(time.RFC3339, a.FreezeUntil)
+		if err != nil {
+			return fmt.Errorf("invalid freeze_until: %v", err)
+		}
+		a.setFreeze(until)
+	}
+
+	switch a.PatchPrecedence {
+	case "", patchPrecedenceConfig, patchPrecedencePatch:
+	default:
+		return fmt.Errorf("invalid patch_precedence %q: must be %q or %q",
+			a.PatchPrecedence, patchPrecedenceConfig, patchPrecedencePatch)
+	}
+
+	switch a.ZoneBoundary {
+	case "", zoneBoundaryWarn, zoneBoundarySkip, zoneBoundaryOff:
+	default:
+		return fmt.Errorf("invalid zone_boundary %q: must be %q, %q or %q",
+			a.ZoneBoundary, zoneBoundaryWarn, zoneBoundarySkip, zoneBoundaryOff)
+	}
+
+	switch a.RegistryFormat {
+	case "", registryFormatDefault:
+	case registryFormatExternalDNS:
+		if a.MarkerFormat != "" {
+			return fmt.Errorf("marker_format can't be combined with registry_format %s", a.RegistryFormat)
+		}
+	default:
+		return fmt.Errorf("invalid registry_format %q: must be %q or %q",
+			a.RegistryFormat, registryFormatDefault, registryFormatExternalDNS)
+	}
+	switch a.RegistryLayout {
+	case "", registryLayoutMarkerPrefix:
+	case registryLayoutTypePrefix:
+		if !a.externalDNS() {
+			return fmt.Errorf("registry_layout %s requires registry_format %s", a.RegistryLayout, registryFormatExternalDNS)
+		}
+		if a.markerType() != "TXT" {
+			return fmt.Errorf("registry_layout %s requires TXT markers", a.RegistryLayout)
+		}
+	default:
+		return fmt.Errorf("invalid registry_layout %q: must be %q or %q",
+			a.RegistryLayout, registryLayoutMarkerPrefix, registryLayoutTypePrefix)
+	}
+
+	if a.MarkerFormat != "" {
+		pattern, err := compileMarkerFormat(a.MarkerFormat)
+		if err != nil {
+			return fmt.Errorf("invalid marker_format: %v", err)
+		}
+		if a.InstancePriority != 0 && !strings.Contains(a.MarkerFormat, "{priority}") {
+			return fmt.Errorf("invalid marker_format: must include {priority} when instance_priority is set")
+		}
+		a.markerRE = pattern
+	}
+
 	// Load DNS providers for each domain
 	for _, domain := range a.Domains {
 		if len(domain.DNSProviderRaw) == 0 {
 			return fmt.Errorf("domain %s: dns_provider is required", domain.Zone)
 		}
+		domain.providerHash = providerConfigHash(domain.DNSProviderRaw)
 
-		val, err := ctx.LoadModule(domain, "DNSProviderRaw")
+		zone, err := normalizeZone(domain.Zone)
 		if err != nil {
-			return fmt.Errorf("domain %s: loading DNS provider: %v", domain.Zone, err)
+			return fmt.Errorf("domain %s: %v", domain.Zone, err)
+		}
+		domain.Zone = zone
+		if domain.InterCallDelay > 0 {
+			domain.pacer = &writePacer{delay: time.Duration(domain.InterCallDelay)}
+		}
+		if domain.ProviderConcurrency < 0 {
+			return fmt.Errorf("domain %s: provider_concurrency must not be negative", domain.Zone)
+		}
+		switch domain.BumpSOASerial {
+		case "", soaSerialCounter, soaSerialDate:
+		default:
+			return fmt.Errorf("domain %s: invalid bump_soa_serial %q: must be %q or %q",
+				domain.Zone, domain.BumpSOASerial, soaSerialCounter, soaSerialDate)
+		}
+		if domain.ProviderConcurrency > 1 {
+			domain.setSlots = make(chan struct{}, domain.ProviderConcurrency)
 		}
-		domain.provider = val
 
-		a.logger.Debug("loaded DNS provider",
-			zap.String("zone", domain.Zone),
-			zap.String("provider", fmt.Sprintf("%T", val)))
+		if domain.ManageWhen != nil {
+			if err := domain.ManageWhen.provision(); err != nil {
+				return fmt.Errorf("domain %s: manage_when: %v", domain.Zone, err)
+			}
+		}
+
+		for _, tmpl := range domain.RecordTemplates {
+			domain.Records = append(domain.Records, tmpl.expand()...)
+		}
+
+		for _, rec := range domain.Records {
+			if err := a.prepareRecord(domain.Zone, rec); err != nil {
+				return fmt.Errorf("domain %s: record %s: %v", domain.Zone, rec.Name, err)
+			}
+		}
+
+		if err := checkDependencies(domain); err != nil {
+			return fmt.Errorf("domain %s: %v", domain.Zone, err)
+		}
+		a.warnMissingGlue(domain)
+
+		if err := a.loadPatch(domain.Zone); err != nil {
+			return fmt.Errorf("domain %s: %v", domain.Zone, err)
+		}
+
+		load := func() (any, error) {
+			return ctx.LoadModule(domain, "DNSProviderRaw")
+		}
+		if a.LazyProviders {
+			domain.lazy = &lazyProvider{load: load}
+			continue
+		}
+		if err := a.setProvider(domain, load); err != nil {
+			return fmt.Errorf("domain %s: %v", domain.Zone, err)
+		}
+	}
+
+	return a.checkSharedZones()
+}
+
+// providerName identifies a DNS provider by its Caddy module ID, or by
+// its Go type if it is not a Caddy module.
+func providerName(provider any) string {
+	if mod, ok := provider.(caddy.Module); ok {
+		return string(mod.CaddyModule().ID)
+	}
+	return fmt.Sprintf("%T", provider)
+}
+
+// activeAt reports whether t is within the record's validity window.
+// Unparseable times are rejected at provision time.
+func (rec *Record) activeAt(t time.Time) bool {
+	if from, err := time.Parse(time.RFC3339, rec.ValidFrom); err == nil && t.Before(from) {
+		return false
+	}
+	if until, err := time.Parse(time.RFC3339, rec.ValidUntil); err == nil && !t.Before(until) {
+		return false
+	}
+	return true
+}
+
+// valueFilePrefix marks a record value that is read from a file.
+const valueFilePrefix = "file:"
+
+// loadValueFile replaces a "file:<path>" value with the file's contents,
+// trimming trailing newlines.
+func (rec *Record) loadValueFile() error {
+	path, ok := strings.CutPrefix(rec.Value, valueFilePrefix)
+	if !ok {
+		return nil
+	}
+	if path == "" {
+		return fmt.Errorf("missing path in value %q", rec.Value)
 	}
 
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading value file: %v", err)
+	}
+	rec.Value = strings.TrimRight(string(data), "\r\n")
+	return nil
+}
+
+// prepareRecord loads the value file of a configured record of zone
+// and puts its name in the form it is managed in, then validates it.
+func (a *App) prepareRecord(zone string, rec *Record) error {
+	if err := rec.loadValueFile(); err != nil {
+		return err
+	}
+	name, err := recordName(rec.Name, zone)
+	if err != nil {
+		return err
+	}
+	if name == "@" && rec.Name != "@" && !strings.HasSuffix(rec.Name, ".") {
+		a.logger.Debug("record named after its zone, managing it at the apex",
+			zap.String("zone", zone),
+			zap.String("name", rec.Name),
+			zap.String("type", rec.Type))
+	}
+	rec.Name = name
+	a.foldRecordCase(rec)
+	return validateRecord(rec)
+}
+
+// validateRecord checks that a record is complete and consistent.
+func validateRecord(rec *Record) error {
+	if rec.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.ContainsAny(rec.Name, "{}") {
+		return fmt.Errorf("name has unexpanded placeholder")
+	}
+	if err := validateName(rec.Name, rec.Type); err != nil {
+		return err
+	}
+	if rec.FromSRV != "" && rec.Type != "A" && rec.Type != "AAAA" {
+		return fmt.Errorf("from_srv requires type A or AAAA, got %s", rec.Type)
+	}
+	if rec.SPF && rec.Type != "TXT" {
+		return fmt.Errorf("spf requires type TXT, got %s", rec.Type)
+	}
+	if err := validateRecordValue(rec); err != nil {
+		return err
+	}
+	for _, ts := range []string{rec.ValidFrom, rec.ValidUntil} {
+		if ts == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, ts); err != nil {
+			return fmt.Errorf("invalid validity time: %v", err)
+		}
+	}
 	return nil
 }
 
 // Start begins managing DNS records.
 func (a *App) Start() error {
-	for _, domain := range a.Domains {
-		if err := a.reconcileDomain(domain); err != nil {
-			a.logger.Error("failed to reconcile domain",
-				zap.String("zone", domain.Zone),
-				zap.Error(err))
-			// Continue with other domains
+	if a.ResumeOnCrash {
+		for _, domain := range a.Domains {
+			if err := a.resumePendingPlan(domain); err != nil {
+				a.logger.Error("failed to resume interrupted reconcile",
+					zap.String("zone", domain.Zone),
+					zap.Error(err))
+			}
 		}
 	}
+	a.runCycle(a.Domains, "start")
+	if a.ReconcileInterval > 0 {
+		go a.reconcileLoop()
+	}
 	return nil
 }
 
@@ -120,209 +738,1161 @@ func (a *App) Stop() error {
 	return nil
 }
 
-// reconcileDomain syncs DNS records for a domain.
+// reconcilePlan is the set of changes needed to bring the records owned
+// in a zone in line with the desired records. Changes are identified by
+// record set key (name:type).
+type reconcilePlan struct {
+	zone      string
+	owned     map[string][]*Record
+	desired   map[string][]*Record
+	toCreate  []string
+	toUpdate  []string
+	toDelete  []string
+	toRelease []string
+
+	// duplicates are exact duplicates of owned sets' records to delete
+	// before the sets are rewritten, keyed by set key.
+	duplicates map[string][]libdns.Record
+
+	// unmarked are the keys of sets written without their ownership
+	// marker by an earlier reconcile, whose marker is to be written.
+	unmarked []string
+
+	// comments are the comments the provider stores with the zone's
+	// records, keyed by commentKey, or nil if it stores none.
+	comments map[string]string
+}
+
+// empty reports whether the plan has no changes.
+func (p *reconcilePlan) empty() bool {
+	return len(p.toCreate) == 0 && len(p.toUpdate) == 0 && len(p.toDelete) == 0 && len(p.toRelease) == 0
+}
+
+// pending describes the plan's changes as "<action> <key>" entries.
+func (p *reconcilePlan) pending() []string {
+	var pending []string
+	for _, key := range p.toCreate {
+		pending = append(pending, "create "+key)
+	}
+	for _, key := range p.toUpdate {
+		pending = append(pending, "update "+key)
+	}
+	for _, key := range p.toDelete {
+		pending = append(pending, "delete "+key)
+	}
+	for _, key := range p.toRelease {
+		pending = append(pending, "release "+key)
+	}
+	return pending
+}
+
+// changesByName describes the plan's changes grouped by record name,
+// one entry per name in name order, e.g. "www: A updated, AAAA created".
+func (p *reconcilePlan) changesByName() []string {
+	changes := make(map[string][]string)
+	add := func(keys []string, sets map[string][]*Record, action string) {
+		for _, key := range keys {
+			rec := sets[key][0]
+			changes[rec.Name] = append(changes[rec.Name], rec.Type+" "+action)
+		}
+	}
+	add(p.toDelete, p.owned, "deleted")
+	add(p.toRelease, p.owned, "released")
+	add(p.toCreate, p.desired, "created")
+	add(p.toUpdate, p.desired, "updated")
+
+	names := make([]string, 0, len(changes))
+	for name := range changes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	grouped := make([]string, len(names))
+	for i, name := range names {
+		sort.Strings(changes[name])
+		grouped[i] = name + ": " + strings.Join(changes[name], ", ")
+	}
+	return grouped
+}
+
+// reconcileDomain syncs DNS records for a domain. The outcome is
+// recorded in the reconcile history.
 func (a *App) reconcileDomain(domain *Domain) error {
+	return a.reconcileRecords(a.ctx, domain, nil)
+}
+
+// reconcileRecords syncs the DNS records of a domain whose keys are in
+// only, or all records if only is nil, within ctx. Record sets that
+// fail to sync are retried shortly after.
+func (a *App) reconcileRecords(ctx context.Context, domain *Domain, only map[string]bool) error {
+	_, err := a.reconcile(ctx, domain, only)
+	return err
+}
+
+// reconcile is reconcileRecords, also returning the result of the
+// reconcile as recorded in the history, or nil if the domain isn't
+// managed.
+func (a *App) reconcile(ctx context.Context, domain *Domain, only map[string]bool) (res *ReconcileResult, err error) {
+	if !domain.managed() {
+		a.logger.Info("manage_when condition is false, skipping reconcile",
+			zap.String("zone", domain.Zone),
+			zap.Stringer("condition", domain.ManageWhen))
+		return nil, nil
+	}
+
+	ctx, span := startSpan(ctx, "dns_register.reconcile",
+		attribute.String("dns_register.zone", domain.Zone),
+		attribute.Bool("dns_register.partial", only != nil))
+	defer a.running.start(ctx, domain.Zone)()
+
+	result := ReconcileResult{Zone: domain.Zone, Time: time.Now()}
+	var plan *reconcilePlan
+	var failed map[string]error
+	var fingerprint string
+	defer func() {
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		if only == nil {
+			a.recordVerified(domain.Zone, fingerprint, result, err)
+			a.ready.record(result, err)
+		}
+		result.Duration = time.Since(result.Time).String()
+		res = &result
+		a.history.add(result)
+		a.emitRecordsChanged(result)
+		a.updateConditions(result)
+		if plan != nil {
+			a.auditChanges(plan, result)
+			a.printChanges(plan, result)
+		}
+		if plan != nil && only == nil {
+			a.updateRecordMetrics(domain, plan, failed, result)
+		}
+		if err == nil {
+			a.retryFailed(domain, result.failed, only != nil)
+		}
+		span.SetAttributes(reconcileSpanAttributes(result)...)
+		endSpan(span, err)
+	}()
+
+	unlock, err := a.zoneLocks.lock(a.running.context(ctx, domain.Zone), domain.Zone)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for another reconcile of the zone: %w", err)
+	}
+	defer unlock()
+
+	release, err := a.workers.acquire(a.running.context(ctx, domain.Zone), a.logger)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for a reconcile worker: %w", err)
+	}
+	defer release()
+
+	if err := a.ensureProvider(domain); err != nil {
+		return nil, err
+	}
+
 	// Get provider interfaces
 	getter, hasGetter := domain.provider.(libdns.RecordGetter)
-	setter, hasSetter := domain.provider.(libdns.RecordSetter)
-	appender, hasAppender := domain.provider.(libdns.RecordAppender)
-	deleter, hasDeleter := domain.provider.(libdns.RecordDeleter)
+	_, hasSetter := domain.provider.(libdns.RecordSetter)
+	_, hasAppender := domain.provider.(libdns.RecordAppender)
 
 	if !hasGetter {
-		return fmt.Errorf("provider does not implement RecordGetter")
+		return nil, fmt.Errorf("provider does not implement RecordGetter")
 	}
 	if !hasSetter && !hasAppender {
-		return fmt.Errorf("provider does not implement RecordSetter or RecordAppender")
+		return nil, fmt.Errorf("provider does not implement RecordSetter or RecordAppender")
 	}
 
-	// Get existing records
-	existing, err := getter.GetRecords(a.ctx, domain.Zone)
+	// Build desired state from config
+	desired, failed := a.desiredRecords(domain)
+
+	// Nothing to verify if the desired records are those the zone was
+	// recently verified to hold
+	if only == nil && len(failed) == 0 {
+		fingerprint = a.desiredFingerprint(domain, desired)
+		if unchanged, records := a.unchangedSinceVerified(domain.Zone, fingerprint); unchanged {
+			a.logger.Debug("desired records unchanged since the zone was verified, not reading it",
+				zap.String("zone", domain.Zone))
+			result.Unchanged = true
+			result.ZoneRecords = records
+			return nil, nil
+		}
+	}
+
+	diff, err := a.diffZone(domain, getter, desired, failed, only, &result)
 	if err != nil {
-		return fmt.Errorf("getting existing records: %w", err)
+		return nil, err
+	}
+	plan = diff.plan
+	existing, tracked, stateChanged := diff.existing, diff.tracked, diff.stateChanged
+	updateZoneRecordsMetric(domain, result.ZoneRecords)
+
+	a.logger.Info("reconciling DNS records", append(domain.zoneFields(),
+		zap.String("provider", providerName(domain.provider)),
+		zap.Int("create", len(plan.toCreate)),
+		zap.Int("update", len(plan.toUpdate)),
+		zap.Int("delete", len(plan.toDelete)),
+		zap.Int("release", len(plan.toRelease)),
+		zap.Strings("create_records", plan.toCreate),
+		zap.Strings("update_records", plan.toUpdate),
+		zap.Strings("delete_records", plan.toDelete),
+		zap.Strings("changes", plan.changesByName()))...)
+
+	// Report but don't apply changes during a freeze
+	if until, frozen := a.frozen(); frozen && !plan.empty() {
+		a.logger.Info("change freeze in effect, not applying changes",
+			zap.String("zone", domain.Zone),
+			zap.Time("freeze_until", until))
+		result.Frozen = true
+		result.Pending = plan.pending()
+		return nil, nil
+	}
+
+	// In plan mode, write the plan for approval instead of applying it
+	if a.PlanDir != "" {
+		result.Pending = plan.pending()
+		return nil, a.writePlanFile(domain.Zone, plan)
+	}
+
+	if err := a.applyPlan(domain, plan, &result); err != nil {
+		return nil, err
+	}
+	a.markUnmarked(domain, plan, &result)
+	if only == nil {
+		a.refreshMarkers(domain, existing, plan, &result)
+	}
+	a.bumpSOASerial(domain, &result)
+
+	// Track ownership of markerless records that were created or deleted
+	if a.trackChanges(tracked, plan, result) {
+		stateChanged = true
+	}
+
+	if stateChanged {
+		if err := a.saveState(domain.Zone, tracked); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// zoneDiff is the plan that brings the records owned in a zone in line
+// with the desired records, with the zone state it was computed from.
+type zoneDiff struct {
+	plan         *reconcilePlan
+	existing     []libdns.Record
+	tracked      map[string]bool
+	stateChanged bool
+}
+
+// diffZone reads the domain's zone and plans the changes that bring the
+// records owned in it in line with desired, limited to the record sets
+// whose keys are in only unless only is nil. Sets in failed are left as
+// they are. Problems that don't prevent planning are recorded in
+// result. Nothing is changed in the zone.
+func (a *App) diffZone(domain *Domain, getter libdns.RecordGetter, desired map[string][]*Record, failed map[string]error, only map[string]bool, result *ReconcileResult) (*zoneDiff, error) {
+	// Get existing records
+	ctx, cancel := a.readContext(domain)
+	existing, err := a.cache.getRecords(ctx, domain, getter)
+	cancel()
+	partial := isPartialResult(err)
+	if partial {
+		a.logger.Warn("provider returned partial zone records, diff may be incomplete and deletions are suppressed",
+			zap.String("zone", domain.Zone),
+			zap.Int("records", len(existing)),
+			zap.Error(err))
+		result.Errors = append(result.Errors, fmt.Sprintf("get records: partial result: %v", err))
+	} else if err != nil {
+		return nil, fmt.Errorf("getting existing records: %w", err)
+	}
+	existing = encodeRecordNames(domain.Zone, existing)
+	result.ZoneRecords = len(existing)
+	existing = a.normalizeRecordTypes(domain, existing)
+	existing = adoptConfiguredCase(existing, a.patchedRecords(domain))
+
+	// Exact duplicates would make sets look changed on every reconcile
+	existing, duplicates := dedupeRecords(existing)
+	if len(duplicates) > 0 {
+		dupKeys := make([]string, 0, len(duplicates))
+		for key := range duplicates {
+			dupKeys = append(dupKeys, key)
+		}
+		sort.Strings(dupKeys)
+		a.logger.Warn("provider returned duplicate records",
+			zap.String("zone", domain.Zone),
+			zap.Strings("records", dupKeys),
+			zap.Bool("remove", a.RemoveDuplicates))
 	}
 
 	// Parse ownership markers from existing TXT records
-	owned := a.parseOwnedRecords(existing)
+	owned := a.parseOwnedRecords(domain.Zone, existing)
 
-	// Build desired state from config
-	desired := make(map[string]*Record)
-	for _, rec := range domain.Records {
-		key := rec.Name + ":" + rec.Type
-		desired[key] = rec
+	// Records of markerless types are owned according to the state file
+	var tracked map[string]bool
+	if len(a.MarkerlessTypes) > 0 {
+		tracked, err = a.loadState(domain.Zone)
+		if err != nil {
+			return nil, err
+		}
+		for key, recs := range a.trackedRecords(existing, tracked) {
+			owned[key] = recs
+		}
+	}
+	stateChanged := false
+
+	// Forget tracked records that were removed out of band, unless they
+	// may just be missing from a partial result
+	if !partial {
+		for key := range tracked {
+			if _, exists := owned[key]; !exists {
+				delete(tracked, key)
+				stateChanged = true
+			}
+		}
+	}
+
+	// Records whose values could not be resolved are left as they are
+	for key, ferr := range failed {
+		a.logger.Warn("failed to resolve record values",
+			zap.String("zone", domain.Zone),
+			zap.String("record", key),
+			zap.Error(ferr))
+		result.Errors = append(result.Errors, fmt.Sprintf("resolve %s: %v", key, ferr))
+		result.failed = append(result.failed, key)
+		delete(owned, key)
+	}
+
+	// Stand by for records another instance owns with higher priority
+	standby := a.higherPriorityNames(existing)
+	for key, recs := range desired {
+		owner, ok := standby[recs[0].Name]
+		if _, exists := owned[key]; exists || !ok {
+			continue
+		}
+		a.logger.Debug("deferring to higher-priority instance",
+			zap.String("zone", domain.Zone),
+			zap.String("record", key),
+			zap.String("owner", owner))
+		delete(desired, key)
+	}
+
+	// Records beyond a zone cut would be published in the wrong zone
+	for key, cut := range a.crossZoneRecords(domain, desired, existing) {
+		a.logger.Warn("record appears to belong to a subzone",
+			zap.String("zone", domain.Zone),
+			zap.String("record", key),
+			zap.String("subzone", cut+"."+domain.Zone),
+			zap.Bool("skipped", a.ZoneBoundary == zoneBoundarySkip))
+		if a.ZoneBoundary == zoneBoundarySkip {
+			delete(desired, key)
+			delete(owned, key)
+		}
 	}
 
+	// Unmarked records under authoritative prefixes are removed unless
+	// configured
+	for key, recs := range a.authoritativeRecords(domain, existing) {
+		if _, exists := desired[key]; exists {
+			continue
+		}
+		if _, exists := failed[key]; exists {
+			continue
+		}
+		if _, exists := owned[key]; !exists {
+			owned[key] = recs
+		}
+	}
+
+	// The apex NS and SOA sets the provider keeps are updated in place
+	a.adoptApexRecords(existing, desired, owned)
+	a.keepSOASerial(domain, desired, owned)
+
+	// Sets an earlier reconcile wrote without their marker only need it
+	unmarked := a.claimUnmarked(domain, existing, desired, owned, failed)
+
 	// Compute diff
-	var toCreate, toUpdate []*Record
-	var toDelete []string
+	plan := &reconcilePlan{zone: domain.Zone, owned: owned, desired: desired, unmarked: unmarked}
 
-	// Find records to delete (owned but not in desired)
+	// Find records to delete (owned but not in desired), or to release
+	// if configured so. Apex NS and SOA sets are never deleted
+	released := a.releasedKeys(domain)
+	createOnly := a.createOnlyKeys(domain)
 	for key := range owned {
-		if _, exists := desired[key]; !exists {
-			toDelete = append(toDelete, key)
+		if _, exists := desired[key]; exists {
+			continue
+		}
+		if createOnly[key] {
+			continue
 		}
+		if released[key] {
+			plan.toRelease = append(plan.toRelease, key)
+		} else if !isApexKey(key) {
+			plan.toDelete = append(plan.toDelete, key)
+		}
+	}
+
+	// A partial result may be missing records that are still wanted, so
+	// nothing is deleted on its basis
+	if partial && len(plan.toDelete) > 0 {
+		sort.Strings(plan.toDelete)
+		a.logger.Warn("suppressing deletions after partial zone records",
+			zap.String("zone", domain.Zone),
+			zap.Strings("records", plan.toDelete))
+		plan.toDelete = nil
 	}
 
-	// Find records to create or update
-	for key, rec := range desired {
-		if existingRec, exists := owned[key]; exists {
+	// Find records to create or update. Create-only sets are created
+	// if absent from the zone and not touched once they exist
+	present := presentKeys(existing)
+	plan.comments = a.recordComments(domain)
+	for key, recs := range desired {
+		if existingRecs, exists := owned[key]; exists {
 			// Check if update needed
-			if existingRec.Value != rec.Value || (rec.TTL > 0 && existingRec.TTL != rec.TTL) {
-				toUpdate = append(toUpdate, rec)
+			changed := recordSetChanged(existingRecs, recs) || a.commentsChanged(plan.comments, existingRecs, recs)
+			if changed && !createOnly[key] {
+				plan.toUpdate = append(plan.toUpdate, key)
 			}
-		} else {
-			toCreate = append(toCreate, rec)
+		} else if !createOnly[key] || !present[key] {
+			plan.toCreate = append(plan.toCreate, key)
 		}
 	}
 
-	// Log what we're about to do with actual record names
-	createNames := make([]string, len(toCreate))
-	for i, r := range toCreate {
-		createNames[i] = r.Name + ":" + r.Type
+	if only != nil {
+		plan.toCreate = filterKeys(plan.toCreate, only)
+		plan.toUpdate = filterKeys(plan.toUpdate, only)
+		plan.toDelete = filterKeys(plan.toDelete, only)
+		plan.toRelease = filterKeys(plan.toRelease, only)
+		plan.unmarked = filterKeys(plan.unmarked, only)
 	}
-	updateNames := make([]string, len(toUpdate))
-	for i, r := range toUpdate {
-		updateNames[i] = r.Name + ":" + r.Type
+
+	// A CNAME can't share its name with records of other types
+	a.resolveTypeConflicts(domain, plan, existing, result)
+
+	if a.RemoveDuplicates {
+		a.planDuplicateRemoval(domain, plan, duplicates, only)
 	}
+	a.enforceRecordLimit(domain, plan, existing, result)
 
-	a.logger.Info("reconciling DNS records",
-		zap.String("zone", domain.Zone),
-		zap.Int("create", len(toCreate)),
-		zap.Int("update", len(toUpdate)),
-		zap.Int("delete", len(toDelete)),
-		zap.Strings("create_records", createNames),
-		zap.Strings("update_records", updateNames),
-		zap.Strings("delete_records", toDelete))
+	sort.Strings(plan.toCreate)
+	sort.Strings(plan.toUpdate)
+	sort.Strings(plan.toDelete)
+	sort.Strings(plan.toRelease)
+	sort.Strings(plan.unmarked)
+	plan.orderCreates()
 
-	// Apply deletions
-	if hasDeleter && len(toDelete) > 0 {
-		for _, key := range toDelete {
-			parts := strings.SplitN(key, ":", 2)
-			if len(parts) != 2 {
-				continue
-			}
+	return &zoneDiff{plan: plan, existing: existing, tracked: tracked, stateChanged: stateChanged}, nil
+}
 
-			rec := owned[key]
-			libRec := a.toLibdnsRecord(rec)
-			marker := a.makeTXTMarker(rec.Name)
+// applyPlan applies the changes in plan to the domain's zone, recording
+// the outcome in result.
+func (a *App) applyPlan(domain *Domain, plan *reconcilePlan, result *ReconcileResult) error {
+	if plan.empty() {
+		return nil
+	}
 
-			// Delete the record and its marker
-			_, err := deleter.DeleteRecords(a.ctx, domain.Zone, []libdns.Record{libRec, marker})
-			if err != nil {
-				a.logger.Warn("failed to delete record",
-					zap.String("name", rec.Name),
-					zap.String("type", rec.Type),
-					zap.Error(err))
-			} else {
-				a.logger.Info("deleted record",
-					zap.String("name", rec.Name),
-					zap.String("type", rec.Type))
-			}
+	// Persist the plan so an interrupted apply can be recovered
+	if a.ResumeOnCrash {
+		if err := a.savePendingPlan(domain.Zone, plan); err != nil {
+			return err
 		}
 	}
 
-	// Apply creates
-	if len(toCreate) > 0 {
-		for _, rec := range toCreate {
-			libRec := a.toLibdnsRecord(rec)
-			marker := a.makeTXTMarker(rec.Name)
+	// Keep the values that updates and deletes are about to overwrite
+	if err := a.backupPriorValues(domain.Zone, plan); err != nil {
+		return err
+	}
 
-			var err error
-			if hasSetter {
-				_, err = setter.SetRecords(a.ctx, domain.Zone, []libdns.Record{libRec, marker})
-			} else {
-				_, err = appender.AppendRecords(a.ctx, domain.Zone, []libdns.Record{libRec, marker})
+	// Cached records of the zone are stale once anything is written
+	defer a.cache.invalidate(domain)
+	a.deleteDuplicates(domain, plan, result)
+
+	// Apply all changes at once if the provider supports it, otherwise
+	// record by record
+	if tx, ok := domain.provider.(TransactionalProvider); ok {
+		if err := a.applyTransaction(tx, domain, plan, result); err != nil {
+			return err
+		}
+	} else {
+		a.applyRecordChanges(domain, plan, result)
+	}
+	a.writeComments(domain, plan, result)
+	a.releaseSets(domain, plan, result)
+
+	if a.ResumeOnCrash {
+		if err := a.clearPendingPlan(domain.Zone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackChanges updates tracked with the markerless record sets that
+// were created or deleted, and reports whether it changed.
+func (a *App) trackChanges(tracked map[string]bool, plan *reconcilePlan, result ReconcileResult) bool {
+	changed := false
+	for _, key := range slices.Concat(result.Deleted, result.Released) {
+		if tracked[key] {
+			delete(tracked, key)
+			changed = true
+		}
+	}
+	for _, key := range result.Created {
+		if a.isMarkerless(plan.desired[key][0].Type) {
+			tracked[key] = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+// applyRecordChanges applies a plan with as few provider calls as
+// possible: one for all deletes and one for all creates and updates.
+// If a batched call fails, the changes it covered are applied one
+// record set at a time instead, so that failures are reported per set.
+func (a *App) applyRecordChanges(domain *Domain, plan *reconcilePlan, result *ReconcileResult) {
+	if a.applyBatched(domain, plan, result) {
+		return
+	}
+	a.applyEachRecordSet(domain, plan, result)
+}
+
+// applyEachRecordSet applies a plan one record set at a time, skipping
+// sets already applied. Failures are logged and recorded in result
+// without stopping the remaining changes.
+//
+// Deletes are applied first and updates last. Without a setter, updates
+// replace each set with a delete and an append. When a name changes type
+// (e.g. from CNAME to A), the old set is deleted right before the new
+// one is created, and the new one is only created if the delete
+// succeeded, so the two never conflict.
+func (a *App) applyEachRecordSet(domain *Domain, plan *reconcilePlan, result *ReconcileResult) {
+	setter, hasSetter := domain.provider.(libdns.RecordSetter)
+	_, hasDeleter := domain.provider.(libdns.RecordDeleter)
+
+	createNames := make(map[string]bool)
+	for _, key := range plan.toCreate {
+		createNames[plan.desired[key][0].Name] = true
+	}
+
+	// Apply deletions of names that aren't replaced by a new type
+	if hasDeleter {
+		var deletes []string
+		for _, key := range plan.toDelete {
+			if !createNames[plan.owned[key][0].Name] && !slices.Contains(result.Deleted, key) {
+				deletes = append(deletes, key)
 			}
+		}
+		a.applyConcurrently(domain, deletes, result, func(key string, result *ReconcileResult) {
+			a.deleteSet(domain, plan, key, result)
+		})
+	}
 
-			if err != nil {
-				a.logger.Warn("failed to create record",
-					zap.String("name", rec.Name),
-					zap.String("type", rec.Type),
-					zap.Error(err))
-			} else {
-				a.logger.Info("created record",
-					zap.String("name", rec.Name),
-					zap.String("type", rec.Type),
-					zap.String("value", rec.Value))
+	// Apply creates, each preceded by the deletion of the sets it
+	// replaces, and dependencies before the sets that depend on them
+	deps := plan.createDependencies()
+	create := func(key string, result *ReconcileResult) {
+		name := plan.desired[key][0].Name
+		if slices.Contains(result.Created, key) {
+			return
+		}
+		if i := slices.IndexFunc(deps[key], func(dep string) bool {
+			return slices.Contains(result.failed, dep)
+		}); i >= 0 {
+			result.Errors = append(result.Errors, fmt.Sprintf("create %s: not created, creating %s it depends on failed", key, deps[key][i]))
+			result.failed = append(result.failed, key)
+			return
+		}
+
+		replaced := true
+		for _, delKey := range plan.toDelete {
+			if !hasDeleter || plan.owned[delKey][0].Name != name || slices.Contains(result.Deleted, delKey) {
+				continue
 			}
+			if !a.deleteSet(domain, plan, delKey, result) {
+				replaced = false
+			}
+		}
+		if !replaced {
+			result.Errors = append(result.Errors, fmt.Sprintf("create %s: not created, deleting the record it replaces failed", key))
+			result.failed = append(result.failed, key)
+			return
 		}
+
+		a.createSet(domain, plan, key, result)
+	}
+	levels := [][]string{plan.toCreate}
+	if domain.setSlots != nil {
+		levels = plan.createLevels()
+	}
+	for _, level := range levels {
+		a.applyConcurrently(domain, level, result, create)
 	}
 
 	// Apply updates
-	if hasSetter && len(toUpdate) > 0 {
-		for _, rec := range toUpdate {
-			libRec := a.toLibdnsRecord(rec)
+	if hasSetter {
+		a.applyConcurrently(domain, plan.toUpdate, result, func(key string, result *ReconcileResult) {
+			recs := plan.desired[key]
+			name, typ := recs[0].Name, recs[0].Type
+			if slices.Contains(result.Updated, key) {
+				return
+			}
 
-			_, err := setter.SetRecords(a.ctx, domain.Zone, []libdns.Record{libRec})
+			ctx, cancel := a.writeContext(domain)
+			_, err := providerSetRecords(ctx, domain, setter, a.toLibdnsRecords(recs))
+			cancel()
 			if err != nil {
 				a.logger.Warn("failed to update record",
-					zap.String("name", rec.Name),
-					zap.String("type", rec.Type),
+					zap.String("name", name),
+					zap.String("type", typ),
 					zap.Error(err))
+				result.Errors = append(result.Errors, fmt.Sprintf("update %s: %v", key, err))
+				result.failed = append(result.failed, key)
 			} else {
-				a.logger.Info("updated record",
-					zap.String("name", rec.Name),
-					zap.String("type", rec.Type),
-					zap.String("value", rec.Value))
+				a.logRecordChange("updated record", recs)
+				result.Updated = append(result.Updated, key)
+			}
+		})
+	} else if hasDeleter {
+		a.applyConcurrently(domain, plan.toUpdate, result, func(key string, result *ReconcileResult) {
+			if !slices.Contains(result.Updated, key) {
+				a.replaceSet(domain, plan, key, result)
 			}
+		})
+	}
+}
+
+// deleteSet deletes an owned record set, recording the outcome in
+// result. It reports whether the delete succeeded.
+func (a *App) deleteSet(domain *Domain, plan *reconcilePlan, key string, result *ReconcileResult) bool {
+	deleter := domain.provider.(libdns.RecordDeleter)
+	recs := plan.owned[key]
+	name, typ := recs[0].Name, recs[0].Type
+
+	ctx, cancel := a.writeContext(domain)
+	_, err := providerDeleteRecords(ctx, domain, deleter, a.deletionRecords(plan, key))
+	cancel()
+	if err != nil {
+		a.logger.Warn("failed to delete record",
+			zap.String("name", name),
+			zap.String("type", typ),
+			zap.Error(err))
+		result.Errors = append(result.Errors, fmt.Sprintf("delete %s: %v", key, err))
+		result.failed = append(result.failed, key)
+		return false
+	}
+
+	if a.ConfirmDeletes {
+		if err := a.confirmDelete(domain, plan, key); err != nil {
+			a.logger.Warn("delete did not take effect",
+				zap.String("name", name),
+				zap.String("type", typ),
+				zap.Error(err))
+			result.Errors = append(result.Errors, fmt.Sprintf("delete %s: %v", key, err))
+			result.failed = append(result.failed, key)
+			return false
 		}
 	}
 
-	return nil
+	a.logger.Info("deleted record",
+		zap.String("name", name),
+		zap.String("type", typ))
+	result.Deleted = append(result.Deleted, key)
+	return true
+}
+
+// createSet creates a desired record set with its ownership marker,
+// recording the outcome in result.
+func (a *App) createSet(domain *Domain, plan *reconcilePlan, key string, result *ReconcileResult) {
+	recs := plan.desired[key]
+	name, typ := recs[0].Name, recs[0].Type
+
+	if err := a.writeRecords(domain, a.createRecords(recs)); err != nil {
+		a.logger.Warn("failed to create record",
+			zap.String("name", name),
+			zap.String("type", typ),
+			zap.Error(err))
+		result.Errors = append(result.Errors, fmt.Sprintf("create %s: %v", key, err))
+		result.failed = append(result.failed, key)
+		return
+	}
+	a.markCreated(domain, plan, []string{key}, result)
+}
+
+// createRecords returns the records to write to create a set: the set
+// and its ownership marker, or with two-phase markers only the set, as
+// its marker is written once the set has been.
+func (a *App) createRecords(recs []*Record) []libdns.Record {
+	if a.TwoPhaseMarkers {
+		return a.toLibdnsRecords(recs)
+	}
+	return a.withMarker(recs)
+}
+
+// writeRecords writes recs to the domain's zone, replacing the sets
+// they belong to if the provider can, and appending them otherwise.
+func (a *App) writeRecords(domain *Domain, recs []libdns.Record) error {
+	if setter, ok := domain.provider.(libdns.RecordSetter); ok {
+		ctx, cancel := a.writeContext(domain)
+		defer cancel()
+		_, err := providerSetRecords(ctx, domain, setter, recs)
+		return err
+	}
+	return a.appendWithRetry(domain, domain.provider.(libdns.RecordAppender), recs)
+}
+
+// deletionRecords returns the records to delete for an owned set. The
+// set's ownership marker is included unless other sets sharing it (all
+// sets at the name, unless markers are type-prefixed) remain owned or
+// are about to be created.
+func (a *App) deletionRecords(plan *reconcilePlan, key string) []libdns.Record {
+	marker := a.markerName(plan.owned[key][0].Name, plan.owned[key][0].Type)
+	for other, recs := range plan.owned {
+		if other != key && a.markerName(recs[0].Name, recs[0].Type) == marker && !slices.Contains(plan.toDelete, other) {
+			return a.toLibdnsRecords(plan.owned[key])
+		}
+	}
+	for _, recs := range plan.desired {
+		if a.markerName(recs[0].Name, recs[0].Type) == marker {
+			return a.toLibdnsRecords(plan.owned[key])
+		}
+	}
+	return a.withMarker(plan.owned[key])
+}
+
+// logRecordChange logs an applied change to a record set at info level,
+// including the set's values unless RedactValues is set, in which case
+// they are logged at debug level only.
+func (a *App) logRecordChange(msg string, recs []*Record) {
+	fields := []zap.Field{
+		zap.String("name", recs[0].Name),
+		zap.String("type", recs[0].Type),
+	}
+	value := zap.String("value", joinValues(recs))
+
+	if !a.RedactValues {
+		a.logger.Info(msg, append(fields, value)...)
+		return
+	}
+	a.logger.Info(msg, fields...)
+	a.logger.Debug(msg, append(fields, value)...)
+}
+
+// withMarker converts a record set to libdns.Records followed by its
+// ownership marker, unless the set's type is markerless.
+func (a *App) withMarker(recs []*Record) []libdns.Record {
+	libRecs := a.toLibdnsRecords(recs)
+	if !a.isMarkerless(recs[0].Type) {
+		libRecs = append(libRecs, a.makeMarker(recs[0].Name, recs[0].Type))
+	}
+	return libRecs
+}
+
+// recordKey returns the diff key for a record.
+func recordKey(rec *Record) string {
+	return rec.Name + ":" + rec.Type
+}
+
+// desiredRecords builds the desired record sets for a domain, keyed by
+// name and type. Records sharing a name and type form a single set.
+// Records whose values are resolved dynamically and fail to resolve
+// are reported in failed rather than desired.
+func (a *App) desiredRecords(domain *Domain) (desired map[string][]*Record, failed map[string]error) {
+	desired = make(map[string][]*Record)
+	failed = make(map[string]error)
+
+	now := time.Now()
+	lookups := make(srvLookups)
+	for _, rec := range a.patchedRecords(domain) {
+		key := recordKey(rec)
+
+		if rec.Release || !rec.activeAt(now) {
+			continue
+		}
+
+		if rec.FromSRV == "" {
+			desired[key] = append(desired[key], rec)
+			continue
+		}
+
+		resolved, err := a.resolveFromSRV(rec, lookups)
+		if err != nil {
+			failed[key] = err
+			continue
+		}
+		desired[key] = append(desired[key], resolved...)
+	}
+
+	// A set is only as good as all of its members
+	for key := range failed {
+		delete(desired, key)
+	}
+
+	// SPF records at a name are assembled into one
+	for key, recs := range desired {
+		if recs[0].Type == "TXT" {
+			desired[key] = mergeSPF(recs)
+		}
+	}
+
+	// A value listed more than once is published once
+	for key, recs := range desired {
+		unique, removed := dedupeValues(recs)
+		if len(removed) == 0 {
+			continue
+		}
+		a.logger.Debug("collapsed duplicate values of record set",
+			zap.String("zone", domain.Zone),
+			zap.String("record", key),
+			zap.Strings("values", removed))
+		desired[key] = unique
+	}
+
+	// Records without a TTL get the zone's default, if the provider
+	// reports one
+	if ttl := a.zoneDefaultTTL(domain); ttl > 0 {
+		for key, recs := range desired {
+			for i, rec := range recs {
+				if rec.TTL == 0 {
+					withTTL := *rec
+					withTTL.TTL = ttl
+					desired[key][i] = &withTTL
+				}
+			}
+		}
+	}
+
+	return desired, failed
+}
+
+// ZoneDefaultTTLProvider is implemented by DNS providers that can report
+// the default TTL configured for a zone. It is used for records that
+// don't set a TTL, instead of the built-in default of 300 seconds.
+type ZoneDefaultTTLProvider interface {
+	ZoneDefaultTTL(ctx context.Context, zone string) (time.Duration, error)
+}
+
+// zoneDefaultTTL returns the provider's default TTL for the domain's zone
+// in seconds, or 0 if the provider doesn't report one.
+func (a *App) zoneDefaultTTL(domain *Domain) int {
+	p, ok := domain.provider.(ZoneDefaultTTLProvider)
+	if !ok {
+		return 0
+	}
+	ctx, cancel := a.readContext(domain)
+	ttl, err := p.ZoneDefaultTTL(ctx, domain.fqdn())
+	cancel()
+	if err != nil {
+		a.logger.Warn("failed to get zone default TTL, using built-in default",
+			zap.String("zone", domain.Zone),
+			zap.Error(err))
+		return 0
+	}
+	return int(ttl.Seconds())
+}
+
+// recordSetChanged reports whether the existing records of a set differ
+// from the desired ones. Values are compared as a set unless any
+// desired record is marked Ordered. TTLs are only compared if both are
+// known: existing records with a zero TTL come from providers that
+// don't report TTLs.
+func recordSetChanged(existing, desired []*Record) bool {
+	if len(existing) != len(desired) {
+		return true
+	}
+
+	ordered := false
+	for _, rec := range desired {
+		ordered = ordered || rec.Ordered
+	}
+	if !ordered {
+		existing, desired = sortedByValue(existing), sortedByValue(desired)
+	}
+
+	for i, rec := range desired {
+		if canonicalValue(existing[i]) != canonicalValue(rec) || (rec.TTL > 0 && existing[i].TTL > 0 && existing[i].TTL != rec.TTL) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalAddr returns the canonical form of an IP address, so that
+// forms differing in IPv6 zero compression or leading zeros compare
+// equal. Values that don't parse are returned as they are.
+func canonicalAddr(value string) string {
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return addr.String()
+	}
+	// netip rejects IPv4 octets with leading zeros
+	octets := strings.Split(value, ".")
+	if len(octets) != 4 {
+		return value
+	}
+	for i, octet := range octets {
+		if trimmed := strings.TrimLeft(octet, "0"); trimmed != "" {
+			octets[i] = trimmed
+		} else if octet != "" {
+			octets[i] = "0"
+		}
+	}
+	if addr, err := netip.ParseAddr(strings.Join(octets, ".")); err == nil {
+		return addr.String()
+	}
+	return value
+}
+
+// sortedByValue returns a copy of recs sorted by canonical value.
+func sortedByValue(recs []*Record) []*Record {
+	sorted := append([]*Record(nil), recs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return canonicalValue(sorted[i]) < canonicalValue(sorted[j])
+	})
+	return sorted
+}
+
+// canonicalValue returns the record's value in the form used to compare
+// it with provider records. Hostnames compare case-insensitively, and
+// addresses by their parsed value.
+func canonicalValue(rec *Record) string {
+	switch rec.Type {
+	case "A", "AAAA":
+		return canonicalAddr(rec.Value)
+	case "MX":
+		// Preference and target, separated by a single space
+		return foldHostnames(rec.Type, strings.Join(strings.Fields(rec.Value), " "))
+	default:
+		return foldHostnames(rec.Type, rec.Value)
+	}
+}
+
+// joinValues returns the values of a record set for logging.
+func joinValues(recs []*Record) string {
+	values := make([]string, len(recs))
+	for i, rec := range recs {
+		values[i] = rec.Value
+	}
+	return strings.Join(values, ", ")
 }
 
 const (
-	txtPrefix   = "_cdr."
-	txtHeritage = "caddy-dns-register"
+	markerPrefix   = "_cdr."
+	markerHeritage = "caddy-dns-register"
+	markerTTL      = 300 * time.Second
 )
 
-// parseOwnedRecords finds records owned by this instance based on TXT markers.
-func (a *App) parseOwnedRecords(records []libdns.Record) map[string]*Record {
-	owned := make(map[string]*Record)
+// parseOwnedRecords finds records of the zone owned by this instance
+// based on TXT markers, or as decided by the Ownership function if set.
+// Records sharing a name and type are returned together as a set.
+func (a *App) parseOwnedRecords(zone string, records []libdns.Record) map[string][]*Record {
+	owned := make(map[string][]*Record)
 
 	// First pass: find our ownership markers
 	markers := make(map[string]bool)
 	for _, rec := range records {
 		rr := rec.RR()
-		if rr.Type != "TXT" || !strings.HasPrefix(rr.Name, txtPrefix) {
+		if !a.isMarkerRecord(rr) {
 			continue
 		}
 
 		// Check if this marker is ours
-		expectedValue := fmt.Sprintf("owner=%s,heritage=%s", a.OwnerID, txtHeritage)
-		if rr.Data == expectedValue || rr.Data == "\""+expectedValue+"\"" {
+		if a.isOwnMarker(rr.Data) {
 			// Extract the original record name
-			origName := strings.TrimPrefix(rr.Name, txtPrefix)
+			origName := strings.TrimPrefix(rr.Name, markerPrefix)
 			markers[origName] = true
 		}
 	}
 
+	// Registry entries written by external-dns
+	extKeys, extNames, extEntries := a.externalDNSOwned(records)
+
 	// Second pass: collect records that have our markers
 	for _, rec := range records {
 		rr := rec.RR()
-		if strings.HasPrefix(rr.Name, txtPrefix) {
+		key := rr.Name + ":" + rr.Type
+		if strings.HasPrefix(rr.Name, markerPrefix) || extEntries[key] {
 			continue // Skip markers themselves
 		}
+		if a.isMarkerless(rr.Type) {
+			continue // Owned via the state file, not markers
+		}
 
-		if markers[rr.Name] {
-			owned[rr.Name+":"+rr.Type] = &Record{
+		marked := markers[rr.Name] || extKeys[key] || extNames[rr.Name]
+		if a.Ownership != nil {
+			marked = a.Ownership(zone, rr, marked)
+		}
+		if marked {
+			owned[key] = append(owned[key], &Record{
 				Name:  rr.Name,
 				Type:  rr.Type,
 				Value: a.extractValue(rec),
 				TTL:   int(rr.TTL.Seconds()),
-			}
+			})
 		}
 	}
 
 	return owned
 }
 
-// makeTXTMarker creates a TXT record to mark ownership.
-func (a *App) makeTXTMarker(name string) libdns.Record {
-	return libdns.TXT{
-		Name: txtPrefix + name,
-		TTL:  300 * time.Second,
-		Text: fmt.Sprintf("owner=%s,heritage=%s", a.OwnerID, txtHeritage),
+// authoritativeRecords returns the records at or below the domain's
+// authoritative prefixes that carry no ownership marker of any owner,
+// keyed by name and type. Markers, external-dns registry entries and
+// the records they own, and markerless types are never included.
+func (a *App) authoritativeRecords(domain *Domain, records []libdns.Record) map[string][]*Record {
+	found := make(map[string][]*Record)
+	if len(domain.AuthoritativePrefixes) == 0 {
+		return found
 	}
+
+	marked := make(map[string]bool)
+	for _, rec := range records {
+		rr := rec.RR()
+		if a.isMarkerRecord(rr) &&
+			a.markerFieldsOf(rr.Data)["heritage"] == markerHeritage {
+			marked[strings.TrimPrefix(rr.Name, markerPrefix)] = true
+		}
+	}
+	extKeys, extNames, extEntries := a.externalDNSEntries(records, "")
+
+	for _, rec := range records {
+		rr := rec.RR()
+		key := rr.Name + ":" + rr.Type
+		if strings.HasPrefix(rr.Name, markerPrefix) || marked[rr.Name] || a.isMarkerless(rr.Type) {
+			continue
+		}
+		if extKeys[key] || extNames[rr.Name] || extEntries[key] {
+			continue
+		}
+		if !underPrefix(rr.Name, domain.AuthoritativePrefixes) {
+			continue
+		}
+		found[key] = append(found[key], &Record{
+			Name:  rr.Name,
+			Type:  rr.Type,
+			Value: a.extractValue(rec),
+			TTL:   int(rr.TTL.Seconds()),
+		})
+	}
+	return found
+}
+
+// underPrefix reports whether name is one of prefixes or a name below
+// one of them.
+func underPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if name == prefix || strings.HasSuffix(name, "."+prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMarker parses the comma-separated key=value fields of an
+// ownership marker. Surrounding quotes are ignored, as are fields
+// without a "=".
+func parseMarker(data string) map[string]string {
+	data = strings.Trim(data, "\"")
+	fields := make(map[string]string)
+	for _, field := range strings.Split(data, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			continue
+		}
+		fields[k] = v
+	}
+	return fields
+}
+
+// isOwnMarker reports whether marker data identifies this instance as
+// the owner. Only the owner and heritage fields are compared, so field
+// order and additional fields don't matter.
+func (a *App) isOwnMarker(data string) bool {
+	fields := a.markerFieldsOf(data)
+	return fields["owner"] == a.OwnerID && fields["heritage"] == markerHeritage
+}
+
+// trackedRecords returns the existing records whose keys are tracked in
+// the state file, grouped into sets like parseOwnedRecords.
+func (a *App) trackedRecords(records []libdns.Record, tracked map[string]bool) map[string][]*Record {
+	owned := make(map[string][]*Record)
+	for _, rec := range records {
+		rr := rec.RR()
+		key := rr.Name + ":" + rr.Type
+		if !tracked[key] || !a.isMarkerless(rr.Type) {
+			continue
+		}
+		owned[key] = append(owned[key], &Record{
+			Name:  rr.Name,
+			Type:  rr.Type,
+			Value: a.extractValue(rec),
+			TTL:   int(rr.TTL.Seconds()),
+		})
+	}
+	return owned
+}
+
+// isMarkerless reports whether records of the given type are managed
+// without ownership markers.
+func (a *App) isMarkerless(recordType string) bool {
+	for _, t := range a.MarkerlessTypes {
+		if strings.EqualFold(t, recordType) {
+			return true
+		}
+	}
+	return false
+}
+
+// makeMarker creates the record marking ownership of the record set of
+// type typ at name.
+func (a *App) makeMarker(name, typ string) libdns.Record {
+	return a.markerAt(a.markerName(name, typ))
+}
+
+// markerAt creates an ownership marker with the given name.
+func (a *App) markerAt(name string) libdns.Record {
+	if a.markerType() == "TXT" {
+		return libdns.TXT{
+			Name: name,
+			TTL:  markerTTL,
+			Text: a.markerText(),
+		}
+	}
+	return libdns.RR{
+		Name: name,
+		Type: a.markerType(),
+		TTL:  markerTTL,
+		Data: a.markerText(),
+	}
+}
+
+// markerType returns the record type used for ownership markers.
+func (a *App) markerType() string {
+	if a.MarkerType == "" {
+		return "TXT"
+	}
+	return strings.ToUpper(a.MarkerType)
+}
+
+// isMarkerRecord reports whether rr is an ownership marker, of any owner.
+func (a *App) isMarkerRecord(rr libdns.RR) bool {
+	return rr.Type == a.markerType() && strings.HasPrefix(rr.Name, markerPrefix)
+}
+
+// markerText returns the text of this instance's ownership markers,
+// in the configured registry or marker format if there is one.
+func (a *App) markerText() string {
+	if a.externalDNS() {
+		return a.externalDNSMarkerText()
+	}
+	if a.MarkerFormat != "" {
+		return formatMarker(a.MarkerFormat, a.OwnerID, a.InstancePriority)
+	}
+	text := fmt.Sprintf("owner=%s,heritage=%s", a.OwnerID, markerHeritage)
+	if a.InstancePriority != 0 {
+		text += fmt.Sprintf(",priority=%d", a.InstancePriority)
+	}
+	return text
 }
 
 // toLibdnsRecord converts our Record to a libdns.Record.
@@ -364,6 +1934,26 @@ func (a *App) toLibdnsRecord(rec *Record) libdns.Record {
 			TTL:    ttl,
 		}
 
+	case "MX":
+		if mx, ok := parseMX(rec.Value); ok {
+			mx.Name = rec.Name
+			mx.TTL = ttl
+			return mx
+		}
+		return libdns.RR{
+			Name: rec.Name,
+			Type: rec.Type,
+			TTL:  ttl,
+			Data: rec.Value,
+		}
+
+	case "SRV":
+		// Names and values of SRV records are validated when they are
+		// loaded, so they parse
+		srv, _ := parseSRV(rec.Name, rec.Value)
+		srv.TTL = ttl
+		return srv
+
 	default:
 		return libdns.RR{
 			Name: rec.Name,
@@ -374,6 +1964,42 @@ func (a *App) toLibdnsRecord(rec *Record) libdns.Record {
 	}
 }
 
+// toLibdnsRecords converts a record set to libdns.Records.
+func (a *App) toLibdnsRecords(recs []*Record) []libdns.Record {
+	libRecs := make([]libdns.Record, len(recs))
+	for i, rec := range recs {
+		libRecs[i] = a.toLibdnsRecord(rec)
+	}
+	return libRecs
+}
+
+// parseMX parses an MX value of the form "<preference> <target>".
+func parseMX(value string) (libdns.MX, bool) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return libdns.MX{}, false
+	}
+	pref, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return libdns.MX{}, false
+	}
+	return libdns.MX{Preference: uint16(pref), Target: fields[1]}, true
+}
+
+// parseSRV parses an SRV value of the form "<priority> <weight> <port>
+// <target>" at name, which must start with the _<service>._<transport>
+// labels.
+func parseSRV(name, value string) (libdns.SRV, error) {
+	if !isSRVName(name) {
+		return libdns.SRV{}, fmt.Errorf("SRV name %q must start with _<service>._<transport> labels", name)
+	}
+	parsed, err := libdns.RR{Name: name, Type: "SRV", Data: value}.Parse()
+	if err != nil {
+		return libdns.SRV{}, err
+	}
+	return parsed.(libdns.SRV), nil
+}
+
 // extractValue gets the value from a libdns.Record.
 func (a *App) extractValue(rec libdns.Record) string {
 	switch r := rec.(type) {
@@ -385,6 +2011,8 @@ func (a *App) extractValue(rec libdns.Record) string {
 		return r.Target
 	case libdns.MX:
 		return fmt.Sprintf("%d %s", r.Preference, r.Target)
+	case libdns.SRV:
+		return fmt.Sprintf("%d %d %d %s", r.Priority, r.Weight, r.Port, r.Target)
 	case libdns.NS:
 		return r.Target
 	default:
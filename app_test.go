@@ -1,11 +1,19 @@
 package dnsregister
 
 import (
+	"context"
+	"errors"
 	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestToLibdnsRecord(t *testing.T) {
@@ -36,6 +44,16 @@ func TestToLibdnsRecord(t *testing.T) {
 			record:   &Record{Name: "www", Type: "CNAME", Value: "example.com.", TTL: 300},
 			wantType: "CNAME",
 		},
+		{
+			name:     "MX record",
+			record:   &Record{Name: "mail", Type: "MX", Value: "10 mail.example.com.", TTL: 300},
+			wantType: "MX",
+		},
+		{
+			name:     "SRV record",
+			record:   &Record{Name: "_xmpp-server._tcp", Type: "SRV", Value: "5 0 5269 xmpp.example.com.", TTL: 300},
+			wantType: "SRV",
+		},
 	}
 
 	for _, tc := range tests {
@@ -51,12 +69,28 @@ func TestToLibdnsRecord(t *testing.T) {
 			}
 		})
 	}
+
+	// MX values are split into preference and target
+	mx, ok := app.toLibdnsRecord(&Record{Name: "mail", Type: "MX", Value: "10 mail.example.com."}).(libdns.MX)
+	if !ok || mx.Preference != 10 || mx.Target != "mail.example.com." {
+		t.Errorf("expected libdns.MX with preference 10, got %+v", mx)
+	}
+
+	// SRV values are split into their fields, the name into service,
+	// transport and the name below them
+	srv, ok := app.toLibdnsRecord(&Record{Name: "_xmpp-server._tcp.chat", Type: "SRV", Value: "5 0 5269 xmpp.example.com."}).(libdns.SRV)
+	if !ok || srv.Service != "xmpp-server" || srv.Transport != "tcp" || srv.Name != "chat" || srv.Port != 5269 || srv.Target != "xmpp.example.com." {
+		t.Errorf("expected libdns.SRV for xmpp-server on port 5269, got %+v", srv)
+	}
+	if _, err := parseSRV("sip", "5 0 5060 sip.example.com."); err == nil {
+		t.Error("expected an SRV name without service labels not to parse")
+	}
 }
 
 func TestMakeTXTMarker(t *testing.T) {
 	app := &App{OwnerID: "test-caddy"}
 
-	marker := app.makeTXTMarker("www")
+	marker := app.makeMarker("www", "A")
 
 	txt, ok := marker.(libdns.TXT)
 	if !ok {
@@ -109,7 +143,7 @@ func TestParseOwnedRecords(t *testing.T) {
 		},
 	}
 
-	owned := app.parseOwnedRecords(records)
+	owned := app.parseOwnedRecords("example.com", records)
 
 	// Should only have www:A
 	if len(owned) != 1 {
@@ -117,12 +151,12 @@ func TestParseOwnedRecords(t *testing.T) {
 	}
 
 	wwwA, exists := owned["www:A"]
-	if !exists {
+	if !exists || len(wwwA) != 1 {
 		t.Fatal("expected www:A to be owned")
 	}
 
-	if wwwA.Value != "192.168.1.100" {
-		t.Errorf("Value: got %q, want %q", wwwA.Value, "192.168.1.100")
+	if wwwA[0].Value != "192.168.1.100" {
+		t.Errorf("Value: got %q, want %q", wwwA[0].Value, "192.168.1.100")
 	}
 
 	// Should not own api or manual
@@ -157,6 +191,11 @@ func TestExtractValue(t *testing.T) {
 			record: libdns.CNAME{Name: "www", Target: "example.com."},
 			want:   "example.com.",
 		},
+		{
+			name:   "SRV",
+			record: libdns.SRV{Service: "xmpp-server", Transport: "tcp", Name: "@", Priority: 5, Weight: 0, Port: 5269, Target: "xmpp.example.com."},
+			want:   "5 0 5269 xmpp.example.com.",
+		},
 	}
 
 	for _, tc := range tests {
@@ -168,3 +207,705 @@ func TestExtractValue(t *testing.T) {
 		})
 	}
 }
+
+// fakeProvider is an in-memory libdns provider for reconcile tests.
+type fakeProvider struct {
+	mu      sync.Mutex
+	records []libdns.Record
+	gets    int
+}
+
+func (p *fakeProvider) GetRecords(_ context.Context, _ string) ([]libdns.Record, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gets++
+	return append([]libdns.Record(nil), p.records...), nil
+}
+
+func (p *fakeProvider) AppendRecords(_ context.Context, _ string, recs []libdns.Record) ([]libdns.Record, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.records = append(p.records, recs...)
+	return recs, nil
+}
+
+func (p *fakeProvider) SetRecords(_ context.Context, _ string, recs []libdns.Record) ([]libdns.Record, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var kept []libdns.Record
+	for _, existing := range p.records {
+		ex := existing.RR()
+		replaced := false
+		for _, rec := range recs {
+			if rr := rec.RR(); rr.Name == ex.Name && rr.Type == ex.Type {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			kept = append(kept, existing)
+		}
+	}
+	p.records = append(kept, recs...)
+	return recs, nil
+}
+
+func (p *fakeProvider) DeleteRecords(_ context.Context, _ string, recs []libdns.Record) ([]libdns.Record, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var kept, deleted []libdns.Record
+	for _, existing := range p.records {
+		ex := existing.RR()
+		matched := false
+		for _, rec := range recs {
+			rr := rec.RR()
+			if rr.Name == ex.Name && (rr.Type == "" || rr.Type == ex.Type) && (rr.Data == "" || rr.Data == ex.Data) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			deleted = append(deleted, existing)
+		} else {
+			kept = append(kept, existing)
+		}
+	}
+	p.records = kept
+	return deleted, nil
+}
+
+// has reports whether the provider holds a record with the given name and type.
+func (p *fakeProvider) has(name, recordType string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, rec := range p.records {
+		if rr := rec.RR(); rr.Name == name && rr.Type == recordType {
+			return true
+		}
+	}
+	return false
+}
+
+// newTestApp returns an App reconciling a single domain against provider.
+func newTestApp(t *testing.T, provider any, records ...*Record) *App {
+	t.Helper()
+	return &App{
+		OwnerID: "test-caddy",
+		Domains: []*Domain{{Zone: "example.com", provider: provider, Records: records}},
+		logger:  zap.NewNop(),
+		ctx:     context.Background(),
+		dataDir: t.TempDir(),
+	}
+}
+
+func TestReconcileMarkerlessTypes(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		// Unmanaged record of a markerless type
+		libdns.RR{Name: "_xmpp._tcp", Type: "SRV", Data: "10 5 5222 xmpp.example.com.", TTL: 300 * time.Second},
+	}}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "_sip._tcp", Type: "SRV", Value: "10 5 5060 sip.example.com."},
+	)
+	app.MarkerlessTypes = []string{"SRV"}
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	if !provider.has("www", "A") || !provider.has("_cdr.www", "TXT") {
+		t.Error("expected www A and its marker to be created")
+	}
+	if !provider.has("_sip._tcp", "SRV") {
+		t.Error("expected _sip._tcp SRV to be created")
+	}
+	if provider.has("_cdr._sip._tcp", "TXT") {
+		t.Error("markerless SRV record should not get a marker")
+	}
+
+	tracked, err := app.loadState("example.com")
+	if err != nil {
+		t.Fatalf("loadState failed: %v", err)
+	}
+	if !tracked["_sip._tcp:SRV"] || len(tracked) != 1 {
+		t.Errorf("expected state to track only _sip._tcp:SRV, got %v", tracked)
+	}
+
+	// Removing the SRV from config deletes it but leaves the unmanaged one
+	app.Domains[0].Records = app.Domains[0].Records[:1]
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	if provider.has("_sip._tcp", "SRV") {
+		t.Error("expected _sip._tcp SRV to be deleted")
+	}
+	if !provider.has("_xmpp._tcp", "SRV") {
+		t.Error("unmanaged _xmpp._tcp SRV should not be deleted")
+	}
+	if !provider.has("www", "A") {
+		t.Error("www A should be kept")
+	}
+
+	tracked, err = app.loadState("example.com")
+	if err != nil {
+		t.Fatalf("loadState failed: %v", err)
+	}
+	if len(tracked) != 0 {
+		t.Errorf("expected empty state, got %v", tracked)
+	}
+}
+
+func TestRecordTemplateExpand(t *testing.T) {
+	tmpl := &RecordTemplate{
+		Record: Record{Name: "{host}", Type: "A", Value: "{ip}", TTL: 60},
+		Hosts: []map[string]string{
+			{"host": "web1", "ip": "10.0.0.1"},
+			{"host": "web2", "ip": "10.0.0.2"},
+			{"ip": "10.0.0.3"},
+		},
+	}
+
+	recs := tmpl.expand()
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(recs))
+	}
+	if recs[0].Name != "web1" || recs[0].Value != "10.0.0.1" || recs[0].TTL != 60 {
+		t.Errorf("unexpected first record: %+v", recs[0])
+	}
+	if recs[1].Name != "web2" || recs[1].Value != "10.0.0.2" {
+		t.Errorf("unexpected second record: %+v", recs[1])
+	}
+
+	// A host entry missing a placeholder fails validation
+	if err := validateRecord(recs[2]); err == nil {
+		t.Error("expected unexpanded placeholder to fail validation")
+	}
+	if err := validateRecord(recs[0]); err != nil {
+		t.Errorf("expected expanded record to validate, got %v", err)
+	}
+}
+
+func TestIsOwnMarker(t *testing.T) {
+	app := &App{OwnerID: "test-caddy"}
+
+	tests := []struct {
+		data string
+		want bool
+	}{
+		{"owner=test-caddy,heritage=caddy-dns-register", true},
+		{`"owner=test-caddy,heritage=caddy-dns-register"`, true},
+		{"heritage=caddy-dns-register,owner=test-caddy", true},
+		{"owner=test-caddy,heritage=caddy-dns-register,version=2,ts=1700000000", true},
+		{"owner=other-caddy,heritage=caddy-dns-register", false},
+		{"owner=test-caddy,heritage=external-dns", false},
+		{"owner=test-caddy", false},
+		{"owner=test-caddy-2,heritage=caddy-dns-register", false},
+	}
+
+	for _, tc := range tests {
+		if got := app.isOwnMarker(tc.data); got != tc.want {
+			t.Errorf("isOwnMarker(%q): got %v, want %v", tc.data, got, tc.want)
+		}
+	}
+}
+
+func TestRecordSetChanged(t *testing.T) {
+	a := func(value string) *Record { return &Record{Name: "www", Type: "A", Value: value} }
+	ordered := func(value string) *Record {
+		rec := a(value)
+		rec.Ordered = true
+		return rec
+	}
+
+	existing := []*Record{a("192.0.2.2"), a("192.0.2.1")}
+
+	if recordSetChanged(existing, []*Record{a("192.0.2.1"), a("192.0.2.2")}) {
+		t.Error("set comparison should ignore order")
+	}
+	if !recordSetChanged(existing, []*Record{a("192.0.2.1"), a("192.0.2.3")}) {
+		t.Error("set comparison should detect a changed value")
+	}
+	if !recordSetChanged(existing, []*Record{a("192.0.2.1")}) {
+		t.Error("set comparison should detect a removed value")
+	}
+
+	if !recordSetChanged(existing, []*Record{ordered("192.0.2.1"), ordered("192.0.2.2")}) {
+		t.Error("ordered comparison should detect reordering")
+	}
+	if recordSetChanged(existing, []*Record{ordered("192.0.2.2"), ordered("192.0.2.1")}) {
+		t.Error("ordered comparison should accept the same order")
+	}
+}
+
+func TestReconcileUnreportedTTL(t *testing.T) {
+	// The provider doesn't report TTLs
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1", TTL: 600})
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if result := app.history.get("example.com")[0]; len(result.Updated) != 0 {
+		t.Errorf("expected no update for a record without a reported TTL, got %v", result.Updated)
+	}
+
+	// Values are still compared
+	app.Domains[0].Records[0].Value = "192.0.2.2"
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if result := app.history.get("example.com")[1]; len(result.Updated) != 1 {
+		t.Errorf("expected an update for a changed value, got %v", result.Updated)
+	}
+}
+
+func TestRecordSetChangedAddressForms(t *testing.T) {
+	for _, tt := range []struct {
+		typ, existing, desired string
+		changed                bool
+	}{
+		{"AAAA", "2001:0db8:0000::1", "2001:db8::1", false},
+		{"AAAA", "2001:DB8:0:0:0:0:0:1", "2001:db8::1", false},
+		{"A", "192.000.002.001", "192.0.2.1", false},
+		{"A", "192.0.2.10", "192.0.2.1", true},
+		{"AAAA", "2001:db8::2", "2001:db8::1", true},
+	} {
+		existing := []*Record{{Name: "www", Type: tt.typ, Value: tt.existing}}
+		desired := []*Record{{Name: "www", Type: tt.typ, Value: tt.desired}}
+		if got := recordSetChanged(existing, desired); got != tt.changed {
+			t.Errorf("%s %s vs %s: changed = %v, want %v", tt.typ, tt.existing, tt.desired, got, tt.changed)
+		}
+	}
+}
+
+func TestReconcileAddressFormNoUpdate(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "AAAA", Data: "2001:0db8:0000::1", TTL: 300 * time.Second},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "AAAA", Value: "2001:db8::1"})
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if result := app.history.get("example.com")[0]; len(result.Updated) != 0 || len(result.Created) != 0 {
+		t.Errorf("expected no changes for an equal address in another form, got %+v", result)
+	}
+}
+
+func TestLoadValueFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dkim.txt")
+	if err := os.WriteFile(path, []byte("v=DKIM1; k=rsa; p=MIGf\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &Record{Name: "mail._domainkey", Type: "TXT", Value: "file:" + path}
+	if err := rec.loadValueFile(); err != nil {
+		t.Fatalf("loadValueFile failed: %v", err)
+	}
+	if rec.Value != "v=DKIM1; k=rsa; p=MIGf" {
+		t.Errorf("Value: got %q", rec.Value)
+	}
+
+	rec = &Record{Name: "www", Type: "A", Value: "192.0.2.1"}
+	if err := rec.loadValueFile(); err != nil || rec.Value != "192.0.2.1" {
+		t.Errorf("plain value should be untouched, got %q, %v", rec.Value, err)
+	}
+
+	rec = &Record{Name: "dkim", Type: "TXT", Value: "file:" + filepath.Join(t.TempDir(), "missing.txt")}
+	if err := rec.loadValueFile(); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+// fakeTTLProvider is a fakeProvider that reports a zone default TTL.
+type fakeTTLProvider struct {
+	fakeProvider
+	defaultTTL time.Duration
+}
+
+func (p *fakeTTLProvider) ZoneDefaultTTL(context.Context, string) (time.Duration, error) {
+	return p.defaultTTL, nil
+}
+
+func TestReconcileZoneDefaultTTL(t *testing.T) {
+	provider := &fakeTTLProvider{defaultTTL: time.Hour}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "api", Type: "A", Value: "192.0.2.2", TTL: 60},
+	)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	owned := app.parseOwnedRecords("example.com", provider.records)
+	if www := owned["www:A"]; len(www) != 1 || www[0].TTL != 3600 {
+		t.Errorf("expected www to get the zone default TTL, got %+v", www)
+	}
+	if api := owned["api:A"]; len(api) != 1 || api[0].TTL != 60 {
+		t.Errorf("expected api to keep its own TTL, got %+v", api)
+	}
+	if app.Domains[0].Records[0].TTL != 0 {
+		t.Error("configured record should not be modified")
+	}
+
+	// A second reconcile finds nothing to update
+	app.history = newReconcileHistory(0)
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if result := app.history.get("example.com")[0]; len(result.Updated) != 0 {
+		t.Errorf("expected no updates on second reconcile, got %v", result.Updated)
+	}
+}
+
+func TestReconcileMXSet(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider,
+		&Record{Name: "@", Type: "MX", Value: "10 mx1.example.com."},
+		&Record{Name: "@", Type: "MX", Value: "20 mx2.example.com."},
+	)
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	var mxs []libdns.MX
+	for _, rec := range provider.records {
+		if mx, ok := rec.(libdns.MX); ok {
+			mxs = append(mxs, mx)
+		}
+	}
+	if len(mxs) != 2 {
+		t.Fatalf("expected both MX records to be created, got %d", len(mxs))
+	}
+	if mxs[0].Preference != 10 || mxs[0].Target != "mx1.example.com." || mxs[1].Preference != 20 {
+		t.Errorf("unexpected MX records: %+v", mxs)
+	}
+	if !provider.has("_cdr.@", "TXT") {
+		t.Error("expected apex marker to be created")
+	}
+
+	// The provider returning the set in another order is not a change
+	provider.records[0], provider.records[1] = provider.records[1], provider.records[0]
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.get("example.com")[1]
+	if len(result.Created) != 0 || len(result.Updated) != 0 || len(result.Deleted) != 0 {
+		t.Errorf("expected no changes for an unchanged MX set, got %+v", result)
+	}
+
+	// Dropping one MX updates the set without touching the other
+	app.Domains[0].Records = app.Domains[0].Records[:1]
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	owned := app.parseOwnedRecords("example.com", provider.records)
+	if mx := owned["@:MX"]; len(mx) != 1 || mx[0].Value != "10 mx1.example.com." {
+		t.Errorf("expected only mx1 to remain, got %+v", mx)
+	}
+}
+
+func TestReconcileSRV(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider,
+		&Record{Name: "_xmpp-server._tcp", Type: "SRV", Value: "5 0 5269 xmpp.example.com."},
+	)
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !provider.has("_xmpp-server._tcp", "SRV") || !provider.has("_cdr._xmpp-server._tcp", "TXT") {
+		t.Fatalf("expected the SRV record and its marker to be created, got %v", provider.records)
+	}
+	if _, ok := provider.records[0].(libdns.SRV); !ok {
+		t.Errorf("expected libdns.SRV to be written, got %T", provider.records[0])
+	}
+
+	// The value read back compares equal to the configured one
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if result := app.history.last("example.com"); len(result.Created) != 0 || len(result.Updated) != 0 {
+		t.Errorf("expected no changes for an unchanged SRV record, got %+v", result)
+	}
+}
+
+func TestLogRecordChangeRedactValues(t *testing.T) {
+	recs := []*Record{{Name: "www", Type: "A", Value: "10.0.0.1"}}
+
+	for _, redact := range []bool{false, true} {
+		core, logs := observer.New(zap.DebugLevel)
+		app := &App{logger: zap.New(core), RedactValues: redact}
+		app.logRecordChange("created record", recs)
+
+		for _, entry := range logs.All() {
+			_, hasValue := entry.ContextMap()["value"]
+			switch {
+			case entry.Level == zap.InfoLevel && hasValue == redact:
+				t.Errorf("redact=%v: info log value present=%v", redact, hasValue)
+			case entry.Level == zap.DebugLevel && !hasValue:
+				t.Errorf("redact=%v: debug log missing value", redact)
+			}
+		}
+		if got := logs.FilterLevelExact(zap.DebugLevel).Len(); redact && got != 1 {
+			t.Errorf("expected value to be logged at debug when redacted, got %d debug logs", got)
+		}
+	}
+}
+
+func TestReconcileAuthoritativePrefix(t *testing.T) {
+	provider := &fakeProvider{
+		records: []libdns.Record{
+			libdns.RR{Name: "www", Type: "A", Data: "192.0.2.10"},
+			libdns.RR{Name: "old.www", Type: "CNAME", Data: "elsewhere.example.com."},
+			libdns.RR{Name: "shop.www", Type: "A", Data: "192.0.2.11"},
+			libdns.RR{Name: "_cdr.shop.www", Type: "TXT", Data: "owner=other,heritage=caddy-dns-register"},
+			libdns.RR{Name: "api", Type: "A", Data: "192.0.2.20"},
+			libdns.RR{Name: "mail", Type: "MX", Data: "10 mx.example.com."},
+			libdns.RR{Name: "wwwx", Type: "A", Data: "192.0.2.30"},
+		},
+	}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.Domains[0].AuthoritativePrefixes = []string{"www"}
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	if provider.has("old.www", "CNAME") {
+		t.Error("expected unmarked record under prefix to be deleted")
+	}
+	if !provider.has("shop.www", "A") {
+		t.Error("expected record owned by another instance to survive")
+	}
+	for _, name := range []string{"api", "wwwx"} {
+		if !provider.has(name, "A") {
+			t.Errorf("expected out-of-prefix record %s to survive", name)
+		}
+	}
+	if !provider.has("mail", "MX") {
+		t.Error("expected out-of-prefix MX record to survive")
+	}
+	owned := app.parseOwnedRecords("example.com", provider.records)
+	if www := owned["www:A"]; len(www) != 1 || www[0].Value != "192.0.2.1" {
+		t.Errorf("expected configured www record to be managed, got %+v", www)
+	}
+}
+
+func TestReconcileInstancePriority(t *testing.T) {
+	newProvider := func() *fakeProvider {
+		return &fakeProvider{
+			records: []libdns.Record{
+				libdns.RR{Name: "www", Type: "A", Data: "192.0.2.10"},
+				libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=other,heritage=caddy-dns-register,priority=10"},
+			},
+		}
+	}
+
+	// A lower-priority instance stands by
+	provider := newProvider()
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "api", Type: "A", Value: "192.0.2.2"},
+	)
+	app.InstancePriority = 5
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	owned := app.parseOwnedRecords("example.com", provider.records)
+	if _, ok := owned["www:A"]; ok {
+		t.Error("expected lower-priority instance to leave www to the other instance")
+	}
+	if _, ok := owned["api:A"]; !ok {
+		t.Error("expected uncontended api record to be created")
+	}
+
+	// A higher-priority instance takes over, recording its priority
+	provider = newProvider()
+	app = newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.InstancePriority = 20
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	owned = app.parseOwnedRecords("example.com", provider.records)
+	if www := owned["www:A"]; len(www) != 1 || www[0].Value != "192.0.2.1" {
+		t.Errorf("expected higher-priority instance to manage www, got %+v", www)
+	}
+	if got := markerPriority(parseMarker(app.markerText())); got != 20 {
+		t.Errorf("expected marker to carry priority 20, got %d", got)
+	}
+}
+
+func TestReconcileMarkerType(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.MarkerType = "spf"
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !provider.has("_cdr.www", "SPF") || provider.has("_cdr.www", "TXT") {
+		t.Error("expected marker to use the configured record type")
+	}
+
+	// Markers of the configured type establish ownership
+	owned := app.parseOwnedRecords("example.com", provider.records)
+	if _, ok := owned["www:A"]; !ok {
+		t.Error("expected www to be owned via SPF marker")
+	}
+	app.MarkerType = ""
+	if owned := app.parseOwnedRecords("example.com", provider.records); len(owned) != 0 {
+		t.Errorf("expected SPF marker to be ignored when markers are TXT, got %v", owned)
+	}
+}
+
+// fakeCNAMEProvider is a fakeProvider that, like real DNS servers,
+// rejects other records at a name that has a CNAME.
+type fakeCNAMEProvider struct {
+	fakeProvider
+	failDeletes bool
+}
+
+func (p *fakeCNAMEProvider) SetRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	for _, rec := range recs {
+		if rr := rec.RR(); rr.Type != "CNAME" && p.has(rr.Name, "CNAME") {
+			return nil, errors.New("CNAME conflict")
+		}
+	}
+	return p.fakeProvider.SetRecords(ctx, zone, recs)
+}
+
+func (p *fakeCNAMEProvider) DeleteRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	if p.failDeletes {
+		return nil, errors.New("delete failed")
+	}
+	return p.fakeProvider.DeleteRecords(ctx, zone, recs)
+}
+
+func TestReconcileTypeChange(t *testing.T) {
+	newProvider := func() *fakeCNAMEProvider {
+		return &fakeCNAMEProvider{fakeProvider: fakeProvider{records: []libdns.Record{
+			libdns.CNAME{Name: "www", Target: "web.example.com.", TTL: 300 * time.Second},
+			libdns.TXT{Name: "_cdr.www", Text: "owner=test-caddy,heritage=caddy-dns-register"},
+		}}}
+	}
+
+	provider := newProvider()
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.has("www", "CNAME") || !provider.has("www", "A") {
+		t.Error("expected www CNAME to be replaced by www A")
+	}
+	if !provider.has("_cdr.www", "TXT") {
+		t.Error("expected the marker to survive the type change")
+	}
+	if result := app.history.get("example.com")[0]; len(result.Errors) != 0 {
+		t.Errorf("expected no errors, got %v", result.Errors)
+	}
+
+	// A failed delete keeps the new type from being created
+	provider = newProvider()
+	provider.failDeletes = true
+	app = newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.get("example.com")[0]
+	if len(result.Created) != 0 || len(result.Errors) != 2 {
+		t.Errorf("expected delete and create to fail, got %+v", result)
+	}
+}
+
+func TestRecordActiveAt(t *testing.T) {
+	rec := &Record{
+		Name:       "maintenance",
+		Type:       "TXT",
+		Value:      "down for maintenance",
+		ValidFrom:  "2026-03-01T02:00:00Z",
+		ValidUntil: "2026-03-01T04:00:00Z",
+	}
+	from := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		t      time.Time
+		active bool
+	}{
+		{from.Add(-time.Second), false},
+		{from, true},
+		{until.Add(-time.Second), true},
+		{until, false},
+	} {
+		if got := rec.activeAt(tc.t); got != tc.active {
+			t.Errorf("activeAt(%s) = %v, want %v", tc.t, got, tc.active)
+		}
+	}
+
+	if !(&Record{ValidUntil: "2026-03-01T04:00:00Z"}).activeAt(from) {
+		t.Error("expected record without valid_from to be active before valid_until")
+	}
+	if err := validateRecord(&Record{Name: "www", Type: "A", ValidFrom: "tomorrow"}); err == nil {
+		t.Error("expected error for invalid valid_from")
+	}
+}
+
+func TestReconcileRecordValidity(t *testing.T) {
+	provider := &fakeProvider{}
+	rec := &Record{Name: "maintenance", Type: "TXT", Value: "down",
+		ValidUntil: time.Now().Add(time.Hour).Format(time.RFC3339)}
+	app := newTestApp(t, provider, rec)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !provider.has("maintenance", "TXT") {
+		t.Fatal("expected record to be published within its window")
+	}
+
+	// Once the window has passed the record is deleted
+	rec.ValidUntil = time.Now().Add(-time.Second).Format(time.RFC3339)
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.has("maintenance", "TXT") {
+		t.Error("expected record to be deleted after its window")
+	}
+}
+
+func TestChangesByName(t *testing.T) {
+	plan := &reconcilePlan{
+		owned: map[string][]*Record{
+			"www:A":   {{Name: "www", Type: "A"}},
+			"old:TXT": {{Name: "old", Type: "TXT"}},
+		},
+		desired: map[string][]*Record{
+			"www:A":    {{Name: "www", Type: "A"}},
+			"www:AAAA": {{Name: "www", Type: "AAAA"}},
+			"api:A":    {{Name: "api", Type: "A"}},
+		},
+		toCreate: []string{"api:A", "www:AAAA"},
+		toUpdate: []string{"www:A"},
+		toDelete: []string{"old:TXT"},
+	}
+
+	got := plan.changesByName()
+	want := []string{"api: A created", "old: TXT deleted", "www: A updated, AAAA created"}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("changesByName() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package dnsregister
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// auditLoggerName is the name of the logger audit entries are written
+// to, below the app's logger (dns_register.audit), so log pipelines can
+// route them separately.
+const auditLoggerName = "audit"
+
+// auditSchemaVersion is the version of the audit entry schema. It is
+// incremented whenever a field is renamed, removed or changes meaning;
+// adding fields doesn't change it.
+const auditSchemaVersion = 1
+
+// auditChanges writes one audit entry per record set change applied by
+// a reconcile. Entries are logged at info level with the message
+// "dns record change" and these fields (schema version 1):
+//
+//	audit_schema    int       the schema version, auditSchemaVersion
+//	zone            string    the zone, without a trailing dot
+//	action          string    "create", "update", "delete" or "release"
+//	name            string    the record name relative to the zone
+//	type            string    the record type
+//	values          []string  the set's values after a create or update, or the deleted or released values; omitted with redact_values
+//	ttl             int       the set's TTL in seconds after a create or update; omitted for deletes and releases
+//	owner           string    the owner ID of this instance
+//	reconcile_time  string    the start of the reconcile, in RFC 3339 format
+func (a *App) auditChanges(plan *reconcilePlan, result ReconcileResult) {
+	logger := a.logger.Named(auditLoggerName)
+	audit := func(action string, recs []*Record) {
+		if len(recs) == 0 {
+			return
+		}
+		fields := []zap.Field{
+			zap.Int("audit_schema", auditSchemaVersion),
+			zap.String("zone", result.Zone),
+			zap.String("action", action),
+			zap.String("name", recs[0].Name),
+			zap.String("type", recs[0].Type),
+		}
+		if !a.RedactValues {
+			values := make([]string, len(recs))
+			for i, rec := range recs {
+				values[i] = rec.Value
+			}
+			fields = append(fields, zap.Strings("values", values))
+		}
+		if action == "create" || action == "update" {
+			ttl := recs[0].TTL
+			if ttl == 0 {
+				ttl = 300
+			}
+			fields = append(fields, zap.Int("ttl", ttl))
+		}
+		fields = append(fields,
+			zap.String("owner", a.OwnerID),
+			zap.String("reconcile_time", result.Time.Format(time.RFC3339)))
+		logger.Info("dns record change", fields...)
+	}
+
+	for _, key := range result.Created {
+		audit("create", plan.desired[key])
+	}
+	for _, key := range result.Updated {
+		audit("update", plan.desired[key])
+	}
+	for _, key := range result.Deleted {
+		audit("delete", plan.owned[key])
+	}
+	for _, key := range result.Released {
+		audit("release", plan.owned[key])
+	}
+}
@@ -0,0 +1,117 @@
+package dnsregister
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAuditChanges(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.2"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1", TTL: 60})
+	core, logs := observer.New(zap.InfoLevel)
+	app.logger = zap.New(core)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	entries := auditEntries(logs)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(entries))
+	}
+	want := map[string]map[string]any{
+		"create": {"name": "www", "type": "A", "values": []any{"192.0.2.1"}, "ttl": int64(60)},
+		"delete": {"name": "old", "type": "A", "values": []any{"192.0.2.2"}},
+	}
+	for _, entry := range entries {
+		if entry.Message != "dns record change" {
+			t.Errorf("unexpected audit message %q", entry.Message)
+		}
+		fields := entry.ContextMap()
+		for _, name := range []string{"audit_schema", "zone", "action", "name", "type", "owner", "reconcile_time"} {
+			if _, ok := fields[name]; !ok {
+				t.Errorf("audit entry missing field %q: %v", name, fields)
+			}
+		}
+		if fields["audit_schema"] != int64(auditSchemaVersion) || fields["zone"] != "example.com" || fields["owner"] != "test-caddy" {
+			t.Errorf("unexpected audit entry: %v", fields)
+		}
+		action, _ := fields["action"].(string)
+		expected, ok := want[action]
+		if !ok {
+			t.Errorf("unexpected action %q", action)
+			continue
+		}
+		for name, value := range expected {
+			if got, ok := fields[name].([]any); ok {
+				if !slices.Equal(got, value.([]any)) {
+					t.Errorf("%s: %s = %v, want %v", action, name, got, value)
+				}
+			} else if fields[name] != value {
+				t.Errorf("%s: %s = %v, want %v", action, name, fields[name], value)
+			}
+		}
+		if _, hasTTL := fields["ttl"]; action == "delete" && hasTTL {
+			t.Error("expected no ttl for a delete")
+		}
+	}
+
+	// Values are left out when redacted
+	logs.TakeAll()
+	app.RedactValues = true
+	app.Domains[0].Records[0].Value = "192.0.2.9"
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	entries = auditEntries(logs)
+	if len(entries) != 1 || entries[0].ContextMap()["action"] != "update" {
+		t.Fatalf("expected 1 update entry, got %v", entries)
+	}
+	if _, ok := entries[0].ContextMap()["values"]; ok {
+		t.Error("expected values to be redacted")
+	}
+}
+
+func TestAuditAppliedPlan(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.2"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.PlanDir = t.TempDir()
+	core, logs := observer.New(zap.InfoLevel)
+	app.logger = zap.New(core)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if entries := auditEntries(logs); len(entries) != 0 {
+		t.Fatalf("expected no audit entries for a written plan, got %v", entries)
+	}
+
+	// Changes applied from the plan are audited like any other
+	if _, err := app.applyPlanFile(app.Domains[0]); err != nil {
+		t.Fatalf("applyPlanFile failed: %v", err)
+	}
+	var actions []string
+	for _, entry := range auditEntries(logs) {
+		actions = append(actions, entry.ContextMap()["action"].(string)+" "+entry.ContextMap()["name"].(string))
+	}
+	slices.Sort(actions)
+	if !slices.Equal(actions, []string{"create www", "delete old"}) {
+		t.Errorf("expected the applied create and delete to be audited, got %v", actions)
+	}
+}
+
+func auditEntries(logs *observer.ObservedLogs) []observer.LoggedEntry {
+	return logs.Filter(func(entry observer.LoggedEntry) bool {
+		return entry.LoggerName == auditLoggerName
+	}).All()
+}
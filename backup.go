@@ -0,0 +1,136 @@
+package dnsregister
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// errConfigOverridesRestore is returned when restoring a backup of a
+// record set whose config would win over the restored value.
+var errConfigOverridesRestore = errors.New("configured, which takes precedence over the restored value; change its config or set patch_precedence patch")
+
+// recordBackup is the prior value of an owned record set, taken before
+// a reconcile updated or deleted it.
+type recordBackup struct {
+	Name    string    `json:"name"`
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Op      string    `json:"op"`
+	Records []*Record `json:"records"`
+}
+
+// key returns the key (name:type) of the backed-up record set.
+func (b recordBackup) key() string {
+	return b.Name + ":" + b.Type
+}
+
+// backupPath returns the path of the backup file for a zone.
+func (a *App) backupPath(zone string) string {
+	return filepath.Join(a.dataDir, "backups", a.OwnerID, strings.TrimSuffix(zone, ".")+".json")
+}
+
+// loadBackups returns the backups of a zone, oldest first.
+func (a *App) loadBackups(zone string) ([]recordBackup, error) {
+	var backups []recordBackup
+	if _, err := readJSONFile(a.backupPath(zone), &backups); err != nil {
+		return nil, fmt.Errorf("reading backups: %w", err)
+	}
+	return backups, nil
+}
+
+// backupPriorValues backs up the owned record sets the plan updates or
+// deletes, keeping the newest KeepBackups backups of each set. Nothing
+// is backed up if KeepBackups is zero.
+func (a *App) backupPriorValues(zone string, plan *reconcilePlan) error {
+	if a.KeepBackups <= 0 || len(plan.toUpdate)+len(plan.toDelete) == 0 {
+		return nil
+	}
+	backups, err := a.loadBackups(zone)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	add := func(keys []string, op string) {
+		for _, key := range keys {
+			recs := plan.owned[key]
+			if len(recs) == 0 {
+				continue
+			}
+			backup := recordBackup{Name: recs[0].Name, Type: recs[0].Type, Time: now, Op: op}
+			for _, rec := range recs {
+				backup.Records = append(backup.Records, &Record{Name: rec.Name, Type: rec.Type, Value: rec.Value, TTL: rec.TTL})
+			}
+			backups = append(backups, backup)
+		}
+	}
+	add(plan.toUpdate, "update")
+	add(plan.toDelete, "delete")
+
+	// Drop the oldest backups of sets with more than KeepBackups
+	kept := make(map[string]int)
+	trimmed := make([]recordBackup, 0, len(backups))
+	for i := len(backups) - 1; i >= 0; i-- {
+		key := backups[i].key()
+		if kept[key] >= a.KeepBackups {
+			continue
+		}
+		kept[key]++
+		trimmed = append(trimmed, backups[i])
+	}
+	for i, j := 0, len(trimmed)-1; i < j; i, j = i+1, j-1 {
+		trimmed[i], trimmed[j] = trimmed[j], trimmed[i]
+	}
+
+	if err := writeJSONFile(a.backupPath(zone), trimmed); err != nil {
+		return fmt.Errorf("writing backups: %w", err)
+	}
+	return nil
+}
+
+// findBackups returns the backups of a zone whose set has the given
+// name and type, oldest first. An empty name or type matches any.
+func (a *App) findBackups(zone, name, typ string) ([]recordBackup, error) {
+	backups, err := a.loadBackups(zone)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		encoded, err := recordName(name, zone)
+		if err != nil {
+			return nil, fmt.Errorf("record %s: %v", name, err)
+		}
+		name = encoded
+	}
+	typ = strings.ToUpper(typ)
+
+	found := make([]recordBackup, 0, len(backups))
+	for _, backup := range backups {
+		if (name == "" || strings.EqualFold(backup.Name, name)) && (typ == "" || backup.Type == typ) {
+			found = append(found, backup)
+		}
+	}
+	return found, nil
+}
+
+// restoreBackup publishes the records of a backup again by patching the
+// zone with them, replacing any patched records of the set, so that
+// reconciles don't revert them. A configured set wins over the patch
+// unless PatchPrecedence is "patch", so restoring one is refused.
+func (a *App) restoreBackup(domain *Domain, backup recordBackup) error {
+	if a.PatchPrecedence != patchPrecedencePatch {
+		for _, rec := range domain.Records {
+			if recordKey(rec) == backup.key() {
+				return fmt.Errorf("record set %s is %w", backup.key(), errConfigOverridesRestore)
+			}
+		}
+	}
+	records := make([]*Record, 0, len(backup.Records))
+	for _, rec := range backup.Records {
+		records = append(records, &Record{Name: rec.Name, Type: rec.Type, Value: rec.Value, TTL: rec.TTL})
+	}
+	return a.addPatch(domain.Zone, records, []patchRemoval{{Name: backup.Name, Type: backup.Type}})
+}
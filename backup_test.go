@@ -0,0 +1,120 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+)
+
+func TestReconcileBackups(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+	app.patches = newZonePatches()
+	app.KeepBackups = 2
+	domain := app.Domains[0]
+
+	for _, value := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+		domain.Records[0].Value = value
+		if err := app.reconcileDomain(domain); err != nil {
+			t.Fatalf("reconcileDomain failed: %v", err)
+		}
+	}
+	domain.Records = nil
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.has("www", "A") {
+		t.Fatalf("expected www to be deleted, got %v", provider.records)
+	}
+
+	// Only the newest two backups are kept
+	backups, err := app.findBackups("example.com", "www", "a")
+	if err != nil {
+		t.Fatalf("findBackups failed: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %+v", backups)
+	}
+	if backups[0].Op != "update" || backups[0].Records[0].Value != "192.0.2.2" {
+		t.Errorf("expected a backup of 192.0.2.2 before its update, got %+v", backups[0])
+	}
+	if backups[1].Op != "delete" || backups[1].Records[0].Value != "192.0.2.3" {
+		t.Errorf("expected a backup of 192.0.2.3 before its delete, got %+v", backups[1])
+	}
+
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"backups?zone=example.com&name=www&type=A", nil)
+	if err := api.handleAPIEndpoints(rec, req); err != nil {
+		t.Fatalf("GET backups failed: %v", err)
+	}
+	var listed []recordBackup
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(listed) != 2 {
+		t.Errorf("expected 2 backups listed, got %+v", listed)
+	}
+
+	// The newest backup is restored by default, published by the next
+	// reconcile while reconciliation is paused
+	app.paused = new(atomic.Bool)
+	app.paused.Store(true)
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, adminEndpointBase+"restore?zone=example.com&name=www&type=A", nil)
+	if err := api.handleAPIEndpoints(rec, req); err != nil {
+		t.Fatalf("POST restore failed: %v", err)
+	}
+	if provider.has("www", "A") {
+		t.Errorf("expected no reconcile while paused, got %v", provider.records)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != "null" {
+		t.Errorf("expected no result while paused, got %s", body)
+	}
+	app.paused.Store(false)
+	app.triggerReconcile(app.ctx, domain, "test")
+	if !hasRecord(provider.records, "www", "A", "192.0.2.3") {
+		t.Errorf("expected www to be restored to 192.0.2.3, got %v", provider.records)
+	}
+}
+
+func TestRestoreConfiguredRecord(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+	app.patches = newZonePatches()
+	app.KeepBackups = 1
+	domain := app.Domains[0]
+
+	for _, value := range []string{"192.0.2.1", "192.0.2.2"} {
+		domain.Records[0].Value = value
+		if err := app.reconcileDomain(domain); err != nil {
+			t.Fatalf("reconcileDomain failed: %v", err)
+		}
+	}
+
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+	req := httptest.NewRequest(http.MethodPost, adminEndpointBase+"restore?zone=example.com&name=www&type=A", nil)
+	err := api.handleAPIEndpoints(httptest.NewRecorder(), req)
+	var apiErr caddy.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusConflict {
+		t.Fatalf("expected restoring a configured set to conflict, got %v", err)
+	}
+
+	// With patch precedence the restored value wins over the config
+	app.PatchPrecedence = patchPrecedencePatch
+	if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); err != nil {
+		t.Fatalf("POST restore failed: %v", err)
+	}
+	if !hasRecord(provider.records, "www", "A", "192.0.2.1") {
+		t.Errorf("expected www to be restored to 192.0.2.1, got %v", provider.records)
+	}
+}
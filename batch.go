@@ -0,0 +1,243 @@
+package dnsregister
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// applyBatched applies a plan in at most two provider calls: one
+// DeleteRecords call for all deletes, then one SetRecords (or, for
+// append-only providers, AppendRecords) call for all creates and
+// updates. Applied changes are recorded in result. It reports whether
+// every change was applied; if not, the remaining changes are left for
+// applyEachRecordSet.
+func (a *App) applyBatched(domain *Domain, plan *reconcilePlan, result *ReconcileResult) bool {
+	setter, hasSetter := domain.provider.(libdns.RecordSetter)
+	appender, _ := domain.provider.(libdns.RecordAppender)
+	deleter, hasDeleter := domain.provider.(libdns.RecordDeleter)
+
+	if hasDeleter && len(plan.toDelete) > 0 {
+		var deletes []libdns.Record
+		for _, key := range plan.toDelete {
+			deletes = append(deletes, a.deletionRecords(plan, key)...)
+		}
+
+		ctx, cancel := a.writeContext(domain)
+		_, err := providerDeleteRecords(ctx, domain, deleter, uniqueRecords(deletes))
+		cancel()
+		if err != nil {
+			a.logger.Debug("batched delete failed, deleting record sets one by one",
+				zap.String("zone", domain.Zone),
+				zap.Error(err))
+			return false
+		}
+
+		// Sets whose delete didn't take effect are left for
+		// applyEachRecordSet, which deletes them again
+		var lingering []string
+		if a.ConfirmDeletes {
+			if lingering, err = a.lingeringDeletes(domain, plan, plan.toDelete); err != nil {
+				lingering = plan.toDelete
+			}
+			if len(lingering) > 0 {
+				a.logger.Warn("batched delete did not take effect, deleting record sets one by one",
+					zap.String("zone", domain.Zone),
+					zap.Strings("records", lingering),
+					zap.Error(err))
+			}
+		}
+		for _, key := range plan.toDelete {
+			if slices.Contains(lingering, key) {
+				continue
+			}
+			a.logger.Info("deleted record",
+				zap.String("name", plan.owned[key][0].Name),
+				zap.String("type", plan.owned[key][0].Type))
+			result.Deleted = append(result.Deleted, key)
+		}
+		if len(lingering) > 0 {
+			return false
+		}
+	}
+
+	// Without a setter, updates replace each set, which is left to
+	// applyEachRecordSet
+	replaceUpdates := !hasSetter && hasDeleter && len(plan.toUpdate) > 0
+	if len(plan.toCreate) == 0 && (len(plan.toUpdate) == 0 || !hasSetter) {
+		return !replaceUpdates
+	}
+
+	// A single call doesn't order its records, so sets that depend on
+	// others created alongside them are created one by one
+	if len(plan.createDependencies()) > 0 {
+		return false
+	}
+
+	var changes []libdns.Record
+	for _, key := range plan.toCreate {
+		changes = append(changes, a.createRecords(plan.desired[key])...)
+	}
+	if hasSetter {
+		for _, key := range plan.toUpdate {
+			changes = append(changes, a.toLibdnsRecords(plan.desired[key])...)
+		}
+	}
+	changes = uniqueRecords(changes)
+
+	var written []libdns.Record
+	var err error
+	ctx, cancel := a.writeContext(domain)
+	if hasSetter {
+		written, err = providerSetRecords(ctx, domain, setter, changes)
+	} else {
+		written, err = providerAppendRecords(ctx, domain, appender, changes)
+	}
+	cancel()
+
+	if err != nil {
+		a.logger.Debug("batched write failed, writing record sets one by one",
+			zap.String("zone", domain.Zone),
+			zap.Error(err))
+		if !hasSetter {
+			// Appends are not idempotent; don't repeat what the failed
+			// batch may have applied
+			a.claimAppended(domain, plan, result)
+		}
+		return false
+	}
+
+	if hasSetter {
+		for _, key := range plan.toUpdate {
+			a.logRecordChange("updated record", plan.desired[key])
+			result.Updated = append(result.Updated, key)
+		}
+	}
+	a.markCreated(domain, plan, a.checkBatchedCreates(domain, plan, written, result), result)
+	return !replaceUpdates
+}
+
+// checkBatchedCreates checks the records a provider returned for a
+// batched write of sets with their ownership markers, and returns the
+// keys of the sets created complete. A set whose records or marker are
+// missing from the response, as when the provider applied the batch in
+// part, is recorded as failed so that it is retried; one missing just
+// its marker is remembered as unmarked, so the retry writes only the
+// marker. Responses without any records, from providers that don't
+// return what they wrote, can't be checked.
+func (a *App) checkBatchedCreates(domain *Domain, plan *reconcilePlan, written []libdns.Record, result *ReconcileResult) []string {
+	if a.TwoPhaseMarkers || len(written) == 0 {
+		return plan.toCreate
+	}
+	written = a.normalizeRecordTypes(domain, encodeRecordNames(domain.Zone, written))
+
+	var complete []string
+	for _, key := range plan.toCreate {
+		recs := plan.desired[key]
+		if len(missingRecords(a.toLibdnsRecords(recs), written)) > 0 {
+			a.logger.Warn("records missing from batched write response",
+				zap.String("zone", domain.Zone),
+				zap.String("record", key))
+			result.Errors = append(result.Errors, fmt.Sprintf("create %s: records missing from batched write response", key))
+			result.failed = append(result.failed, key)
+			continue
+		}
+		if !a.isMarkerless(recs[0].Type) && len(missingRecords([]libdns.Record{a.makeMarker(recs[0].Name, recs[0].Type)}, written)) > 0 {
+			err := errors.New("marker missing from batched write response")
+			a.markerWriteFailed(domain, key, err)
+			result.Errors = append(result.Errors, fmt.Sprintf("create %s: records written but not marked: %v", key, err))
+			result.failed = append(result.failed, key)
+			continue
+		}
+		complete = append(complete, key)
+	}
+	return complete
+}
+
+// claimAppended records as created the sets of plan that are already
+// fully present in the zone after a failed batched append.
+func (a *App) claimAppended(domain *Domain, plan *reconcilePlan, result *ReconcileResult) {
+	getter, ok := domain.provider.(libdns.RecordGetter)
+	if !ok {
+		return
+	}
+	ctx, cancel := a.readContext(domain)
+	existing, err := providerGetRecords(ctx, domain, getter)
+	cancel()
+	if err != nil {
+		return
+	}
+	existing = a.normalizeRecordTypes(domain, encodeRecordNames(domain.Zone, existing))
+
+	var present []string
+	for _, key := range plan.toCreate {
+		if len(missingRecords(a.createRecords(plan.desired[key]), existing)) == 0 {
+			present = append(present, key)
+		}
+	}
+	a.markCreated(domain, plan, present, result)
+}
+
+// markCreated records the sets of keys, whose records have been
+// written, as created. With two-phase markers, their ownership markers
+// are written first in one batch, falling back to one set at a time;
+// sets whose marker can't be written are recorded as failed, and just
+// their marker is written by the next reconcile.
+func (a *App) markCreated(domain *Domain, plan *reconcilePlan, keys []string, result *ReconcileResult) {
+	created := func(key string) {
+		a.logRecordChange("created record", plan.desired[key])
+		result.Created = append(result.Created, key)
+	}
+	if !a.TwoPhaseMarkers {
+		for _, key := range keys {
+			created(key)
+		}
+		return
+	}
+
+	var markers []libdns.Record
+	for _, key := range keys {
+		if recs := plan.desired[key]; !a.isMarkerless(recs[0].Type) {
+			markers = append(markers, a.makeMarker(recs[0].Name, recs[0].Type))
+		}
+	}
+	if len(markers) == 0 || a.writeRecords(domain, uniqueRecords(markers)) == nil {
+		for _, key := range keys {
+			created(key)
+		}
+		return
+	}
+
+	for _, key := range keys {
+		recs := plan.desired[key]
+		if !a.isMarkerless(recs[0].Type) {
+			if err := a.writeRecords(domain, []libdns.Record{a.makeMarker(recs[0].Name, recs[0].Type)}); err != nil {
+				a.markerWriteFailed(domain, key, err)
+				result.Errors = append(result.Errors, fmt.Sprintf("create %s: records written but not marked: %v", key, err))
+				result.failed = append(result.failed, key)
+				continue
+			}
+		}
+		created(key)
+	}
+}
+
+// uniqueRecords returns recs without duplicates, compared by name, type,
+// TTL and data. Sets sharing a name share one marker, which must only
+// be sent once.
+func uniqueRecords(recs []libdns.Record) []libdns.Record {
+	seen := make(map[libdns.RR]bool, len(recs))
+	unique := recs[:0:0]
+	for _, rec := range recs {
+		rr := rec.RR()
+		if seen[rr] {
+			continue
+		}
+		seen[rr] = true
+		unique = append(unique, rec)
+	}
+	return unique
+}
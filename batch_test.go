@@ -0,0 +1,210 @@
+package dnsregister
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// fakeCountingProvider is a fakeProvider that counts provider calls and
+// rejects writes that include a given name.
+type fakeCountingProvider struct {
+	fakeProvider
+	calls    int
+	failName string
+}
+
+func (p *fakeCountingProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	p.calls++
+	return p.fakeProvider.GetRecords(ctx, zone)
+}
+
+func (p *fakeCountingProvider) SetRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	p.calls++
+	for _, rec := range recs {
+		if rec.RR().Name == p.failName {
+			return nil, errors.New("rejected")
+		}
+	}
+	return p.fakeProvider.SetRecords(ctx, zone, recs)
+}
+
+func (p *fakeCountingProvider) DeleteRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	p.calls++
+	return p.fakeProvider.DeleteRecords(ctx, zone, recs)
+}
+
+// newBatchTestApp returns an app with n records to create, one to update
+// and one to delete.
+func newBatchTestApp(n int) (*App, *fakeCountingProvider) {
+	provider := &fakeCountingProvider{fakeProvider: fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.250"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.251"},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}}
+
+	records := []*Record{{Name: "www", Type: "A", Value: "192.0.2.1"}}
+	for i := 0; i < n; i++ {
+		records = append(records, &Record{Name: fmt.Sprintf("host%d", i), Type: "A", Value: fmt.Sprintf("10.0.0.%d", i)})
+	}
+	app := &App{
+		OwnerID: "test-caddy",
+		Domains: []*Domain{{Zone: "example.com", provider: provider, Records: records}},
+		logger:  zap.NewNop(),
+		ctx:     context.Background(),
+		history: newReconcileHistory(0),
+	}
+	return app, provider
+}
+
+func TestReconcileBatched(t *testing.T) {
+	app, provider := newBatchTestApp(10)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.calls != 3 {
+		t.Errorf("expected one get, one delete and one set, got %d calls", provider.calls)
+	}
+	result := app.history.get("example.com")[0]
+	if len(result.Created) != 10 || len(result.Updated) != 1 || len(result.Deleted) != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if owned := app.parseOwnedRecords("example.com", provider.records); len(owned) != 11 {
+		t.Errorf("expected 11 owned record sets, got %d", len(owned))
+	}
+}
+
+func TestReconcileBatchedFallback(t *testing.T) {
+	app, provider := newBatchTestApp(3)
+	provider.failName = "host1"
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	result := app.history.get("example.com")[0]
+	if len(result.Errors) != 1 || len(result.failed) != 1 || result.failed[0] != "host1:A" {
+		t.Errorf("expected only host1 to fail, got %+v", result)
+	}
+	if len(result.Created) != 2 || len(result.Updated) != 1 || len(result.Deleted) != 1 {
+		t.Errorf("expected the other changes to be applied one by one, got %+v", result)
+	}
+}
+
+func TestReconcileTwoPhaseMarkers(t *testing.T) {
+	app, provider := newBatchTestApp(3)
+	app.TwoPhaseMarkers = true
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.calls != 4 {
+		t.Errorf("expected get, delete, set records and set markers, got %d calls", provider.calls)
+	}
+	if owned := app.parseOwnedRecords("example.com", provider.records); len(owned) != 4 {
+		t.Errorf("expected 4 owned record sets, got %d", len(owned))
+	}
+
+	// A marker that fails to write leaves its records unmarked
+	app, provider = newBatchTestApp(3)
+	app.TwoPhaseMarkers = true
+	provider.failName = "_cdr.host1"
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.get("example.com")[0]
+	if len(result.failed) != 1 || result.failed[0] != "host1:A" || len(result.Created) != 2 {
+		t.Errorf("expected only host1 to fail, got %+v", result)
+	}
+	if !hasRecord(provider.records, "host1", "A", "10.0.0.1") {
+		t.Errorf("expected host1's record to be written, got %v", provider.records)
+	}
+	if _, owned := app.parseOwnedRecords("example.com", provider.records)["host1:A"]; owned {
+		t.Error("expected host1 to be left unmarked")
+	}
+}
+
+// BenchmarkApplyProviderCalls reports the provider calls needed to apply
+// 100 creates, an update and a delete, batched and one set at a time.
+func BenchmarkApplyProviderCalls(b *testing.B) {
+	for _, batched := range []bool{true, false} {
+		b.Run(fmt.Sprintf("batched=%v", batched), func(b *testing.B) {
+			calls := 0
+			for i := 0; i < b.N; i++ {
+				app, provider := newBatchTestApp(100)
+				desired, _ := app.desiredRecords(app.Domains[0])
+				plan := &reconcilePlan{
+					owned:    app.parseOwnedRecords("example.com", provider.records),
+					desired:  desired,
+					toUpdate: []string{"www:A"},
+					toDelete: []string{"old:A"},
+				}
+				for key := range desired {
+					if key != "www:A" {
+						plan.toCreate = append(plan.toCreate, key)
+					}
+				}
+
+				result := &ReconcileResult{}
+				if batched {
+					app.applyRecordChanges(app.Domains[0], plan, result)
+				} else {
+					app.applyEachRecordSet(app.Domains[0], plan, result)
+				}
+				calls += provider.calls
+			}
+			b.ReportMetric(float64(calls)/float64(b.N), "calls/op")
+		})
+	}
+}
+
+// fakeDroppingProvider applies writes without the records of a given
+// name, returning only what it applied.
+type fakeDroppingProvider struct {
+	fakeCountingProvider
+	dropName string
+}
+
+func (p *fakeDroppingProvider) SetRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	var applied []libdns.Record
+	for _, rec := range recs {
+		if rec.RR().Name != p.dropName {
+			applied = append(applied, rec)
+		}
+	}
+	return p.fakeCountingProvider.SetRecords(ctx, zone, applied)
+}
+
+func TestReconcileBatchedPartialApply(t *testing.T) {
+	app, counting := newBatchTestApp(3)
+	provider := &fakeDroppingProvider{dropName: "_cdr.host1"}
+	provider.records = counting.records
+	app.Domains[0].provider = provider
+	app.unmarked = newUnmarkedRecords()
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.last("example.com")
+	if len(result.failed) != 1 || result.failed[0] != "host1:A" || len(result.Created) != 2 {
+		t.Errorf("expected host1 to be reported as failed, got %+v", result)
+	}
+	if keys := app.unmarked.keys("example.com"); len(keys) != 1 || keys[0] != "host1:A" {
+		t.Errorf("expected host1 to be remembered as unmarked, got %v", keys)
+	}
+
+	// The next reconcile writes the missing marker
+	provider.dropName = ""
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if _, owned := app.parseOwnedRecords("example.com", provider.records)["host1:A"]; !owned {
+		t.Errorf("expected host1 to be marked, got %v", provider.records)
+	}
+}
@@ -0,0 +1,113 @@
+package dnsregister
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// recordsCache is a read-through cache of provider GetRecords results,
+// keyed by provider config and zone, so that domains sharing a provider
+// and zone don't fetch the same records repeatedly.
+//
+// With a zero TTL, results are only cached while a reconcile cycle
+// (a reconcile of all domains) is in progress and are dropped when it
+// ends. With a positive TTL, results are reused until they are older
+// than the TTL.
+type recordsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	cycles  int
+	entries map[string]cachedRecords
+}
+
+type cachedRecords struct {
+	records []libdns.Record
+	fetched time.Time
+}
+
+func newRecordsCache(ttl time.Duration) *recordsCache {
+	return &recordsCache{
+		ttl:     ttl,
+		entries: make(map[string]cachedRecords),
+	}
+}
+
+// cacheKey returns the cache key for a domain's provider config and
+// zone.
+func cacheKey(domain *Domain) string {
+	return domain.providerHash + "\x00" + domain.Zone
+}
+
+// startCycle marks the start of a reconcile cycle. The returned func
+// ends it; with a zero TTL, ending the outermost cycle empties the cache.
+func (c *recordsCache) startCycle() (end func()) {
+	if c == nil {
+		return func() {}
+	}
+	c.mu.Lock()
+	c.cycles++
+	c.mu.Unlock()
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.cycles--
+		if c.cycles == 0 && c.ttl <= 0 {
+			clear(c.entries)
+		}
+	}
+}
+
+// getRecords returns the records in the domain's zone, from the cache
+// if a usable entry exists and from the provider otherwise. Partial
+// results are returned with their error and not cached.
+func (c *recordsCache) getRecords(ctx context.Context, domain *Domain, getter libdns.RecordGetter) ([]libdns.Record, error) {
+	if c == nil {
+		return providerGetRecords(ctx, domain, getter)
+	}
+	key := cacheKey(domain)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && c.ttl > 0 && time.Since(entry.fetched) >= c.ttl {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+	if ok {
+		return append([]libdns.Record(nil), entry.records...), nil
+	}
+
+	records, err := providerGetRecords(ctx, domain, getter)
+	if isPartialResult(err) {
+		return records, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.ttl > 0 || c.cycles > 0 {
+		c.entries[key] = cachedRecords{
+			records: append([]libdns.Record(nil), records...),
+			fetched: time.Now(),
+		}
+	}
+	c.mu.Unlock()
+
+	return records, nil
+}
+
+// invalidate drops the cached records of the domain's provider and
+// zone. It is called after changes are written to the zone.
+func (c *recordsCache) invalidate(domain *Domain) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, cacheKey(domain))
+}
@@ -0,0 +1,67 @@
+package dnsregister
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRecordsCache(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{}
+	hash := providerConfigHash(json.RawMessage(`{"name":"fake"}`))
+	domain := &Domain{Zone: "example.com", providerHash: hash}
+	other := &Domain{Zone: "example.com", providerHash: hash}
+	account := &Domain{Zone: "example.com", providerHash: providerConfigHash(json.RawMessage(`{"name":"fake","account":"other"}`))}
+
+	fetch := func(c *recordsCache, d *Domain) {
+		t.Helper()
+		if _, err := c.getRecords(ctx, d, provider); err != nil {
+			t.Fatalf("getRecords failed: %v", err)
+		}
+	}
+
+	// Zero TTL: cached only within a cycle
+	cache := newRecordsCache(0)
+	fetch(cache, domain)
+	fetch(cache, domain)
+	if provider.gets != 2 {
+		t.Errorf("expected no caching outside a cycle, got %d fetches", provider.gets)
+	}
+
+	end := cache.startCycle()
+	fetch(cache, domain)
+	fetch(cache, other)
+	if provider.gets != 3 {
+		t.Errorf("expected domains sharing provider and zone to share a fetch, got %d fetches", provider.gets)
+	}
+	fetch(cache, account)
+	if provider.gets != 4 {
+		t.Errorf("expected a different provider config to fetch separately, got %d fetches", provider.gets)
+	}
+	cache.invalidate(domain)
+	fetch(cache, other)
+	if provider.gets != 5 {
+		t.Errorf("expected a fetch after invalidation, got %d fetches", provider.gets)
+	}
+	end()
+	fetch(cache, domain)
+	if provider.gets != 6 {
+		t.Errorf("expected cache to be emptied when the cycle ends, got %d fetches", provider.gets)
+	}
+
+	// Positive TTL: cached until expiry
+	provider.gets = 0
+	cache = newRecordsCache(time.Hour)
+	fetch(cache, domain)
+	fetch(cache, other)
+	if provider.gets != 1 {
+		t.Errorf("expected one fetch within TTL, got %d", provider.gets)
+	}
+	cache.entries[cacheKey(domain)] = cachedRecords{fetched: time.Now().Add(-2 * time.Hour)}
+	fetch(cache, domain)
+	if provider.gets != 2 {
+		t.Errorf("expected a fetch after TTL expiry, got %d", provider.gets)
+	}
+}
@@ -2,8 +2,13 @@ package dnsregister
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/caddyserver/caddy/v2"
 	"github.com/caddyserver/caddy/v2/caddyconfig"
 	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
 	"github.com/caddyserver/caddy/v2/caddyconfig/httpcaddyfile"
@@ -19,11 +24,57 @@ func init() {
 //
 //	dns_register {
 //	    owner_id <id>
+//	    history_size <n>
+//	    markerless_types <type...>
+//	    marker_type <type>
+//	    marker_format <format>
+//	    registry_format <format>
+//	    registry_layout <layout>
+//	    resume_on_crash
+//	    freeze_until <rfc3339-timestamp>
+//	    plan_dir <path>
+//	    reconcile_debounce <duration>
+//	    reconcile_interval <duration>
+//	    max_concurrency <n>
+//	    cycle_deadline <duration>
+//	    records_cache_ttl <duration>
+//	    verify_interval <duration>
+//	    instance_priority <n>
+//	    redact_values
+//	    print_changes
+//	    preserve_case
+//	    two_phase_markers
+//	    confirm_deletes
+//	    resolve_type_conflicts
+//	    remove_duplicates
+//	    max_records_per_zone <n>
+//	    keep_backups <n>
+//	    lazy_providers
+//	    patch_precedence config|patch
+//	    zone_boundary warn|skip|off
+//	    http_timeout <duration>
+//	    ca_cert <path>
+//	    status_webhook <url>
+//	    disable_record_metrics
 //	    domain <zone> {
 //	        dns <provider> {
 //	            <provider-specific-options>
 //	        }
+//	        authoritative_prefix <name...>
+//	        min_ttl <seconds>
+//	        clamp_ttl
+//	        read_timeout <duration>
+//	        write_timeout <duration>
+//	        inter_call_delay <duration>
+//	        provider_concurrency <n>
+//	        bump_soa_serial [counter|date]
+//	        manage_when <left> <==|!=|=~|!~> <right>
 //	        record <name> <type> <value> [<ttl>]
+//	        record <name> <A|AAAA> from_srv <service> [<ttl>]
+//	        records_json <json-array>
+//	        record_template <name> <type> <value> [<ttl>] {
+//	            hosts <placeholder>=<value>...
+//	        }
 //	    }
 //	}
 //
@@ -52,94 +103,8 @@ func parseGlobalDNSRegister(d *caddyfile.Dispenser, existingVal any) (any, error
 		}
 	}
 
-	for d.Next() {
-		for nesting := d.Nesting(); d.NextBlock(nesting); {
-			switch d.Val() {
-			case "owner_id":
-				if !d.NextArg() {
-					return nil, d.ArgErr()
-				}
-				app.OwnerID = d.Val()
-
-			case "domain":
-				// Parse domain block
-				if !d.NextArg() {
-					return nil, d.ArgErr()
-				}
-				zone := d.Val()
-
-				domain := &Domain{Zone: zone}
-
-				for innerNesting := d.Nesting(); d.NextBlock(innerNesting); {
-					switch d.Val() {
-					case "dns":
-						// Parse DNS provider
-						if !d.NextArg() {
-							return nil, d.ArgErr()
-						}
-						providerName := d.Val()
-
-						providerConfig := map[string]any{
-							"name": providerName,
-						}
-
-						// Parse provider block if present
-						for providerNesting := d.Nesting(); d.NextBlock(providerNesting); {
-							key := d.Val()
-							if !d.NextArg() {
-								return nil, d.ArgErr()
-							}
-							value := d.Val()
-							providerConfig[key] = value
-						}
-
-						providerJSON, err := json.Marshal(providerConfig)
-						if err != nil {
-							return nil, d.Errf("marshaling DNS provider config: %v", err)
-						}
-						domain.DNSProviderRaw = providerJSON
-
-					case "record":
-						// Parse record: <name> <type> <value> [<ttl>]
-						rec := &Record{}
-
-						if !d.NextArg() {
-							return nil, d.ArgErr()
-						}
-						rec.Name = d.Val()
-
-						if !d.NextArg() {
-							return nil, d.ArgErr()
-						}
-						rec.Type = d.Val()
-
-						if !d.NextArg() {
-							return nil, d.ArgErr()
-						}
-						rec.Value = d.Val()
-
-						// Optional TTL
-						if d.NextArg() {
-							ttl, err := strconv.Atoi(d.Val())
-							if err != nil {
-								return nil, d.Errf("invalid TTL: %s", d.Val())
-							}
-							rec.TTL = ttl
-						}
-
-						domain.Records = append(domain.Records, rec)
-
-					default:
-						return nil, d.Errf("unrecognized domain option: %s", d.Val())
-					}
-				}
-
-				app.Domains = append(app.Domains, domain)
-
-			default:
-				return nil, d.Errf("unrecognized dns_register option: %s", d.Val())
-			}
-		}
+	if err := app.UnmarshalCaddyfile(d); err != nil {
+		return nil, err
 	}
 
 	return httpcaddyfile.App{
@@ -159,70 +124,262 @@ func (a *App) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
 				}
 				a.OwnerID = d.Val()
 
-			case "domain":
+			case "history_size":
 				if !d.NextArg() {
 					return d.ArgErr()
 				}
-				zone := d.Val()
-
-				domain := &Domain{Zone: zone}
-
-				for innerNesting := d.Nesting(); d.NextBlock(innerNesting); {
-					switch d.Val() {
-					case "dns":
-						if !d.NextArg() {
-							return d.ArgErr()
-						}
-						providerName := d.Val()
-
-						providerConfig := map[string]any{
-							"name": providerName,
-						}
-
-						for providerNesting := d.Nesting(); d.NextBlock(providerNesting); {
-							key := d.Val()
-							if !d.NextArg() {
-								return d.ArgErr()
-							}
-							value := d.Val()
-							providerConfig[key] = value
-						}
-
-						providerJSON, err := json.Marshal(providerConfig)
-						if err != nil {
-							return d.Errf("marshaling DNS provider config: %v", err)
-						}
-						domain.DNSProviderRaw = providerJSON
-
-					case "record":
-						rec := &Record{}
-						if !d.NextArg() {
-							return d.ArgErr()
-						}
-						rec.Name = d.Val()
-						if !d.NextArg() {
-							return d.ArgErr()
-						}
-						rec.Type = d.Val()
-						if !d.NextArg() {
-							return d.ArgErr()
-						}
-						rec.Value = d.Val()
-						if d.NextArg() {
-							ttl, err := strconv.Atoi(d.Val())
-							if err != nil {
-								return d.Errf("invalid TTL: %s", d.Val())
-							}
-							rec.TTL = ttl
-						}
-						domain.Records = append(domain.Records, rec)
-					}
+				size, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return d.Errf("invalid history_size: %s", d.Val())
+				}
+				a.HistorySize = size
+
+			case "markerless_types":
+				args := d.RemainingArgs()
+				if len(args) == 0 {
+					return d.ArgErr()
+				}
+				a.MarkerlessTypes = append(a.MarkerlessTypes, args...)
+
+			case "marker_type":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				a.MarkerType = d.Val()
+
+			case "marker_format":
+				if !d.NextArg() {
+					return d.ArgErr()
 				}
+				a.MarkerFormat = d.Val()
 
+			case "registry_format":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				a.RegistryFormat = d.Val()
+
+			case "registry_layout":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				a.RegistryLayout = d.Val()
+
+			case "resume_on_crash":
+				if d.NextArg() {
+					return d.ArgErr()
+				}
+				a.ResumeOnCrash = true
+
+			case "records_cache_ttl":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				ttl, err := caddy.ParseDuration(d.Val())
+				if err != nil {
+					return d.Errf("invalid records_cache_ttl: %v", err)
+				}
+				a.RecordsCacheTTL = caddy.Duration(ttl)
+
+			case "verify_interval":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				interval, err := caddy.ParseDuration(d.Val())
+				if err != nil {
+					return d.Errf("invalid verify_interval: %v", err)
+				}
+				a.VerifyInterval = caddy.Duration(interval)
+
+			case "zone_boundary":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				switch d.Val() {
+				case zoneBoundaryWarn, zoneBoundarySkip, zoneBoundaryOff:
+					a.ZoneBoundary = d.Val()
+				default:
+					return d.Errf("invalid zone_boundary: %s (must be warn, skip or off)", d.Val())
+				}
+
+			case "http_timeout":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				timeout, err := caddy.ParseDuration(d.Val())
+				if err != nil {
+					return d.Errf("invalid http_timeout: %v", err)
+				}
+				a.HTTPTimeout = caddy.Duration(timeout)
+
+			case "ca_cert":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				a.CACert = d.Val()
+
+			case "status_webhook":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				a.StatusWebhook = d.Val()
+
+			case "disable_record_metrics":
+				if d.NextArg() {
+					return d.ArgErr()
+				}
+				a.DisableRecordMetrics = true
+
+			case "instance_priority":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				priority, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return d.Errf("invalid instance_priority: %s", d.Val())
+				}
+				a.InstancePriority = priority
+
+			case "lazy_providers":
+				if d.NextArg() {
+					return d.ArgErr()
+				}
+				a.LazyProviders = true
+
+			case "two_phase_markers":
+				if d.NextArg() {
+					return d.ArgErr()
+				}
+				a.TwoPhaseMarkers = true
+
+			case "confirm_deletes":
+				if d.NextArg() {
+					return d.ArgErr()
+				}
+				a.ConfirmDeletes = true
+
+			case "resolve_type_conflicts":
+				if d.NextArg() {
+					return d.ArgErr()
+				}
+				a.ResolveTypeConflicts = true
+
+			case "print_changes":
+				if d.NextArg() {
+					return d.ArgErr()
+				}
+				a.PrintChanges = true
+
+			case "remove_duplicates":
+				if d.NextArg() {
+					return d.ArgErr()
+				}
+				a.RemoveDuplicates = true
+
+			case "preserve_case":
+				if d.NextArg() {
+					return d.ArgErr()
+				}
+				a.PreserveCase = true
+
+			case "redact_values":
+				if d.NextArg() {
+					return d.ArgErr()
+				}
+				a.RedactValues = true
+
+			case "patch_precedence":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				switch d.Val() {
+				case patchPrecedenceConfig, patchPrecedencePatch:
+					a.PatchPrecedence = d.Val()
+				default:
+					return d.Errf("invalid patch_precedence: %s (must be config or patch)", d.Val())
+				}
+
+			case "freeze_until":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				a.FreezeUntil = d.Val()
+
+			case "plan_dir":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				a.PlanDir = d.Val()
+
+			case "reconcile_debounce":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				dur, err := caddy.ParseDuration(d.Val())
+				if err != nil {
+					return d.Errf("invalid reconcile_debounce: %v", err)
+				}
+				a.ReconcileDebounce = caddy.Duration(dur)
+
+			case "reconcile_interval":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				dur, err := caddy.ParseDuration(d.Val())
+				if err != nil || dur < 0 {
+					return d.Errf("invalid reconcile_interval: %s", d.Val())
+				}
+				a.ReconcileInterval = caddy.Duration(dur)
+
+			case "cycle_deadline":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				dur, err := caddy.ParseDuration(d.Val())
+				if err != nil {
+					return d.Errf("invalid cycle_deadline: %v", err)
+				}
+				a.CycleDeadline = caddy.Duration(dur)
+
+			case "max_records_per_zone":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				n, err := strconv.Atoi(d.Val())
+				if err != nil || n < 0 {
+					return d.Errf("invalid max_records_per_zone: %s", d.Val())
+				}
+				a.MaxRecordsPerZone = n
+
+			case "keep_backups":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				n, err := strconv.Atoi(d.Val())
+				if err != nil || n < 0 {
+					return d.Errf("invalid keep_backups: %s", d.Val())
+				}
+				a.KeepBackups = n
+
+			case "max_concurrency":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				n, err := strconv.Atoi(d.Val())
+				if err != nil || n < 0 {
+					return d.Errf("invalid max_concurrency: %s", d.Val())
+				}
+				a.MaxConcurrency = n
+
+			case "domain":
+				domain, err := parseDomain(d)
+				if err != nil {
+					return err
+				}
 				a.Domains = append(a.Domains, domain)
 
 			default:
-				return d.Errf("unrecognized option: %s", d.Val())
+				return d.Errf("unrecognized dns_register option: %s", d.Val())
 			}
 		}
 	}
@@ -230,6 +387,359 @@ func (a *App) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
 	return nil
 }
 
+// parseDomain parses a domain block. The dispenser must be
+// positioned on the "domain" token.
+func parseDomain(d *caddyfile.Dispenser) (*Domain, error) {
+	if !d.NextArg() {
+		return nil, d.ArgErr()
+	}
+	domain := &Domain{Zone: d.Val()}
+
+	for nesting := d.Nesting(); d.NextBlock(nesting); {
+		switch d.Val() {
+		case "dns":
+			// Parse DNS provider
+			if !d.NextArg() {
+				return nil, d.ArgErr()
+			}
+			providerName := d.Val()
+
+			providerConfig := map[string]any{
+				"name": providerName,
+			}
+
+			// Parse provider block if present
+			for providerNesting := d.Nesting(); d.NextBlock(providerNesting); {
+				key := d.Val()
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+				value := d.Val()
+				providerConfig[key] = value
+			}
+
+			providerJSON, err := json.Marshal(providerConfig)
+			if err != nil {
+				return nil, d.Errf("marshaling DNS provider config: %v", err)
+			}
+			domain.DNSProviderRaw = providerJSON
+
+		case "record":
+			rec, err := parseRecord(d)
+			if err != nil {
+				return nil, err
+			}
+			domain.Records = append(domain.Records, rec)
+
+		case "authoritative_prefix":
+			args := d.RemainingArgs()
+			if len(args) == 0 {
+				return nil, d.ArgErr()
+			}
+			domain.AuthoritativePrefixes = append(domain.AuthoritativePrefixes, args...)
+
+		case "min_ttl":
+			if !d.NextArg() {
+				return nil, d.ArgErr()
+			}
+			ttl, err := strconv.Atoi(d.Val())
+			if err != nil || ttl <= 0 {
+				return nil, d.Errf("invalid min_ttl: %s", d.Val())
+			}
+			domain.MinTTL = ttl
+
+		case "clamp_ttl":
+			if d.NextArg() {
+				return nil, d.ArgErr()
+			}
+			domain.ClampTTL = true
+
+		case "read_timeout", "write_timeout":
+			option := d.Val()
+			if !d.NextArg() {
+				return nil, d.ArgErr()
+			}
+			dur, err := caddy.ParseDuration(d.Val())
+			if err != nil {
+				return nil, d.Errf("invalid %s: %v", option, err)
+			}
+			if option == "read_timeout" {
+				domain.ReadTimeout = caddy.Duration(dur)
+			} else {
+				domain.WriteTimeout = caddy.Duration(dur)
+			}
+
+		case "inter_call_delay":
+			if !d.NextArg() {
+				return nil, d.ArgErr()
+			}
+			dur, err := caddy.ParseDuration(d.Val())
+			if err != nil || dur < 0 {
+				return nil, d.Errf("invalid inter_call_delay: %s", d.Val())
+			}
+			domain.InterCallDelay = caddy.Duration(dur)
+
+		case "bump_soa_serial":
+			domain.BumpSOASerial = soaSerialCounter
+			if d.NextArg() {
+				switch d.Val() {
+				case soaSerialCounter, soaSerialDate:
+					domain.BumpSOASerial = d.Val()
+				default:
+					return nil, d.Errf("invalid bump_soa_serial: %s (must be counter or date)", d.Val())
+				}
+			}
+			if d.NextArg() {
+				return nil, d.ArgErr()
+			}
+
+		case "provider_concurrency":
+			if !d.NextArg() {
+				return nil, d.ArgErr()
+			}
+			n, err := strconv.Atoi(d.Val())
+			if err != nil || n < 1 {
+				return nil, d.Errf("invalid provider_concurrency: %s", d.Val())
+			}
+			domain.ProviderConcurrency = n
+
+		case "manage_when":
+			args := d.RemainingArgs()
+			if len(args) != 3 {
+				return nil, d.ArgErr()
+			}
+			cond := &Condition{Left: args[0], Op: args[1], Right: args[2]}
+			if err := cond.provision(); err != nil {
+				return nil, d.Errf("invalid manage_when: %v", err)
+			}
+			domain.ManageWhen = cond
+
+		case "records_json":
+			if !d.NextArg() {
+				return nil, d.ArgErr()
+			}
+			records, err := parseRecordsJSON(d.Val())
+			if err != nil {
+				return nil, d.Errf("invalid records_json: %v", err)
+			}
+			if d.NextArg() {
+				return nil, d.ArgErr()
+			}
+			domain.Records = append(domain.Records, records...)
+
+		case "record_template":
+			rec, err := parseRecordLine(d)
+			if err != nil {
+				return nil, err
+			}
+			tmpl := &RecordTemplate{Record: *rec}
+
+			for tmplNesting := d.Nesting(); d.NextBlock(tmplNesting); {
+				if d.Val() != "hosts" {
+					ok, err := parseRecordOption(d, &tmpl.Record)
+					if err != nil {
+						return nil, err
+					}
+					if !ok {
+						return nil, d.Errf("unrecognized record_template option: %s", d.Val())
+					}
+					continue
+				}
+				args := d.RemainingArgs()
+				if len(args) == 0 {
+					return nil, d.ArgErr()
+				}
+				vars := make(map[string]string, len(args))
+				for _, arg := range args {
+					k, v, ok := strings.Cut(arg, "=")
+					if !ok || k == "" {
+						return nil, d.Errf("invalid hosts entry %q: expected <placeholder>=<value>", arg)
+					}
+					vars[k] = v
+				}
+				tmpl.Hosts = append(tmpl.Hosts, vars)
+			}
+
+			domain.RecordTemplates = append(domain.RecordTemplates, tmpl)
+
+		default:
+			return nil, d.Errf("unrecognized domain option: %s", d.Val())
+		}
+	}
+
+	return domain, nil
+}
+
+// parseRecord parses a record line and its optional block of record
+// options:
+//
+//	record <name> <type> <value> [<ttl>] {
+//	    ordered
+//	    spf
+//	    valid_from <rfc3339-timestamp>
+//	    valid_until <rfc3339-timestamp>
+//	    depends_on <name>[:<type>]...
+//	    release
+//	    create_only
+//	    provider_comment <text>
+//	}
+func parseRecord(d *caddyfile.Dispenser) (*Record, error) {
+	rec, err := parseRecordLine(d)
+	if err != nil {
+		return nil, err
+	}
+
+	for nesting := d.Nesting(); d.NextBlock(nesting); {
+		ok, err := parseRecordOption(d, rec)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, d.Errf("unrecognized record option: %s", d.Val())
+		}
+	}
+
+	return rec, nil
+}
+
+// parseRecordLine parses the arguments of a record line:
+//
+//	record <name> <type> <value> [<ttl>]
+//	record <name> <A|AAAA> from_srv <service> [<ttl>]
+func parseRecordLine(d *caddyfile.Dispenser) (*Record, error) {
+	rec := &Record{}
+
+	if !d.NextArg() {
+		return nil, d.ArgErr()
+	}
+	rec.Name = d.Val()
+
+	if !d.NextArg() {
+		return nil, d.ArgErr()
+	}
+	rec.Type = d.Val()
+
+	if !d.NextArg() {
+		return nil, d.ArgErr()
+	}
+	rec.Value = d.Val()
+
+	// Values resolved from SRV targets: from_srv <service>
+	if rec.Value == "from_srv" {
+		if !d.NextArg() {
+			return nil, d.ArgErr()
+		}
+		rec.Value = ""
+		rec.FromSRV = d.Val()
+	}
+
+	// Optional TTL
+	if d.NextArg() {
+		ttl, err := strconv.Atoi(d.Val())
+		if err != nil {
+			return nil, d.Errf("invalid TTL: %s", d.Val())
+		}
+		rec.TTL = ttl
+	}
+
+	return rec, nil
+}
+
+// parseRecordOption parses one option of a record block into rec. It
+// reports false if the current token is not a record option.
+func parseRecordOption(d *caddyfile.Dispenser, rec *Record) (bool, error) {
+	switch d.Val() {
+	case "ordered":
+		if d.NextArg() {
+			return true, d.ArgErr()
+		}
+		rec.Ordered = true
+
+	case "release":
+		if d.NextArg() {
+			return true, d.ArgErr()
+		}
+		rec.Release = true
+
+	case "create_only":
+		if d.NextArg() {
+			return true, d.ArgErr()
+		}
+		rec.CreateOnly = true
+
+	case "provider_comment":
+		if !d.NextArg() {
+			return true, d.ArgErr()
+		}
+		rec.ProviderComment = d.Val()
+		if d.NextArg() {
+			return true, d.ArgErr()
+		}
+
+	case "spf":
+		if d.NextArg() {
+			return true, d.ArgErr()
+		}
+		rec.SPF = true
+
+	case "valid_from", "valid_until":
+		option := d.Val()
+		if !d.NextArg() {
+			return true, d.ArgErr()
+		}
+		if _, err := time.Parse(time.RFC3339, d.Val()); err != nil {
+			return true, d.Errf("invalid %s: %v", option, err)
+		}
+		if option == "valid_from" {
+			rec.ValidFrom = d.Val()
+		} else {
+			rec.ValidUntil = d.Val()
+		}
+
+	case "depends_on":
+		refs := d.RemainingArgs()
+		if len(refs) == 0 {
+			return true, d.ArgErr()
+		}
+		rec.DependsOn = append(rec.DependsOn, refs...)
+
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// parseRecordsJSON decodes a JSON array of record objects, as used by
+// the records_json directive. Unknown fields are rejected, and errors
+// report the byte offset at which decoding failed.
+func parseRecordsJSON(input string) ([]*Record, error) {
+	dec := json.NewDecoder(strings.NewReader(input))
+	dec.DisallowUnknownFields()
+
+	var records []*Record
+	if err := dec.Decode(&records); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			return nil, fmt.Errorf("at offset %d: %v", syntaxErr.Offset, err)
+		case errors.As(err, &typeErr):
+			return nil, fmt.Errorf("at offset %d: %v", typeErr.Offset, err)
+		}
+		return nil, fmt.Errorf("at offset %d: %v", dec.InputOffset(), err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("at offset %d: unexpected data after array", dec.InputOffset())
+	}
+
+	for i, rec := range records {
+		if rec == nil || rec.Name == "" || rec.Type == "" {
+			return nil, fmt.Errorf("record %d: name and type are required", i)
+		}
+	}
+	return records, nil
+}
+
 // Interface guards
 var (
 	_ caddyfile.Unmarshaler = (*App)(nil)
@@ -0,0 +1,128 @@
+package dnsregister
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+)
+
+func TestUnmarshalCaddyfileUnknownDomainOption(t *testing.T) {
+	d := caddyfile.NewTestDispenser(`dns_register {
+		domain example.com {
+			recrod www A 192.0.2.1
+		}
+	}`)
+
+	app := &App{}
+	err := app.UnmarshalCaddyfile(d)
+	if err == nil || !strings.Contains(err.Error(), "unrecognized domain option: recrod") {
+		t.Errorf("expected error for unknown domain option, got %v", err)
+	}
+}
+
+func TestUnmarshalCaddyfileRecordTemplate(t *testing.T) {
+	d := caddyfile.NewTestDispenser(`dns_register {
+		domain example.com {
+			record_template {host} A {ip} 60 {
+				hosts host=web1 ip=10.0.0.1
+				hosts host=web2 ip=10.0.0.2
+			}
+		}
+	}`)
+
+	app := &App{}
+	if err := app.UnmarshalCaddyfile(d); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+
+	if len(app.Domains) != 1 || len(app.Domains[0].RecordTemplates) != 1 {
+		t.Fatalf("expected one domain with one template, got %+v", app.Domains)
+	}
+	tmpl := app.Domains[0].RecordTemplates[0]
+	if tmpl.Name != "{host}" || tmpl.Type != "A" || tmpl.Value != "{ip}" || tmpl.TTL != 60 {
+		t.Errorf("unexpected template record: %+v", tmpl.Record)
+	}
+	if len(tmpl.Hosts) != 2 || tmpl.Hosts[1]["host"] != "web2" || tmpl.Hosts[1]["ip"] != "10.0.0.2" {
+		t.Errorf("unexpected hosts: %v", tmpl.Hosts)
+	}
+
+	d = caddyfile.NewTestDispenser(`dns_register {
+		domain example.com {
+			record_template {host} A {ip} {
+				hosts web1
+			}
+		}
+	}`)
+	if err := (&App{}).UnmarshalCaddyfile(d); err == nil {
+		t.Error("expected error for hosts entry without '='")
+	}
+}
+
+func TestUnmarshalCaddyfileRecordOptions(t *testing.T) {
+	d := caddyfile.NewTestDispenser(`dns_register {
+		domain example.com {
+			record www A 192.0.2.1 {
+				ordered
+			}
+			record www A 192.0.2.2
+		}
+	}`)
+
+	app := &App{}
+	if err := app.UnmarshalCaddyfile(d); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+
+	recs := app.Domains[0].Records
+	if len(recs) != 2 || !recs[0].Ordered || recs[1].Ordered {
+		t.Errorf("expected only the first record to be ordered, got %+v, %+v", recs[0], recs[1])
+	}
+
+	d = caddyfile.NewTestDispenser(`dns_register {
+		domain example.com {
+			record www A 192.0.2.1 {
+				bogus
+			}
+		}
+	}`)
+	if err := (&App{}).UnmarshalCaddyfile(d); err == nil {
+		t.Error("expected error for unrecognized record option")
+	}
+}
+
+func TestUnmarshalCaddyfileRecordsJSON(t *testing.T) {
+	d := caddyfile.NewTestDispenser("dns_register {\n" +
+		"\tdomain example.com {\n" +
+		"\t\trecord www A 192.0.2.1\n" +
+		"\t\trecords_json `[{\"name\":\"api\",\"type\":\"A\",\"value\":\"192.0.2.2\",\"ttl\":60},{\"name\":\"@\",\"type\":\"MX\",\"value\":\"10 mx.example.com.\"}]`\n" +
+		"\t}\n" +
+		"}")
+
+	app := &App{}
+	if err := app.UnmarshalCaddyfile(d); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	records := app.Domains[0].Records
+	if len(records) != 3 {
+		t.Fatalf("expected record and records_json to be merged into 3 records, got %d", len(records))
+	}
+	if api := records[1]; api.Name != "api" || api.Value != "192.0.2.2" || api.TTL != 60 {
+		t.Errorf("unexpected record from JSON: %+v", api)
+	}
+
+	for _, input := range []string{
+		`[{"name":"www","type":"A",}]`,
+		`[{"name":"www","type":"A","vaule":"192.0.2.1"}]`,
+		`[{"name":"www","type":"A","ttl":"60"}]`,
+		`[{"type":"A","value":"192.0.2.1"}]`,
+		`{"name":"www","type":"A"}`,
+	} {
+		if _, err := parseRecordsJSON(input); err == nil {
+			t.Errorf("expected error for %s", input)
+		}
+	}
+	if _, err := parseRecordsJSON(`[{"name":"www","type":"A",}]`); err == nil || !strings.Contains(err.Error(), "offset") {
+		t.Errorf("expected error to report position, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package dnsregister
+
+import (
+	"context"
+	"sync"
+)
+
+// runningReconciles tracks the reconciles in progress per zone, each
+// zone with a context that its provider calls derive from, so that a
+// hung reconcile can be cancelled without stopping the app.
+type runningReconciles struct {
+	mu    sync.Mutex
+	zones map[string]*zoneRun
+}
+
+// zoneRun is the cancellable context shared by the reconciles running
+// for a zone.
+type zoneRun struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running int
+}
+
+func newRunningReconciles() *runningReconciles {
+	return &runningReconciles{zones: make(map[string]*zoneRun)}
+}
+
+// start marks a reconcile of zone as running. The returned func marks
+// it as done.
+func (r *runningReconciles) start(parent context.Context, zone string) (end func()) {
+	if r == nil {
+		return func() {}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.zones[zone]
+	if !ok {
+		run = &zoneRun{}
+		run.ctx, run.cancel = context.WithCancel(parent)
+		r.zones[zone] = run
+	}
+	run.running++
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		run.running--
+		if run.running == 0 {
+			run.cancel()
+			delete(r.zones, zone)
+		}
+	}
+}
+
+// context returns the context of the reconciles running for zone, or
+// parent if none is running.
+func (r *runningReconciles) context(parent context.Context, zone string) context.Context {
+	if r == nil {
+		return parent
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if run, ok := r.zones[zone]; ok {
+		return run.ctx
+	}
+	return parent
+}
+
+// cancel cancels the reconciles running for zone. It reports whether
+// any was running.
+func (r *runningReconciles) cancel(zone string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.zones[zone]
+	if !ok || run.ctx.Err() != nil {
+		return false
+	}
+	run.cancel()
+	return true
+}
@@ -0,0 +1,47 @@
+package dnsregister
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SupportedTypesProvider is implemented by DNS providers that support
+// only some record types. Configured records of other types are then
+// reported when the provider is loaded rather than failing on apply.
+type SupportedTypesProvider interface {
+	SupportedTypes() []string
+}
+
+// checkSupportedTypes returns an error naming the record types the
+// domain's records, and the ownership markers written for them, use
+// that its provider doesn't support. Providers that don't report the
+// types they support are not checked.
+func (a *App) checkSupportedTypes(domain *Domain) error {
+	p, ok := domain.provider.(SupportedTypesProvider)
+	if !ok {
+		return nil
+	}
+	supported := make(map[string]bool)
+	for _, typ := range p.SupportedTypes() {
+		supported[strings.ToUpper(typ)] = true
+	}
+
+	var unsupported []string
+	check := func(typ string) {
+		if !supported[typ] && !slices.Contains(unsupported, typ) {
+			unsupported = append(unsupported, typ)
+		}
+	}
+	for _, rec := range domain.Records {
+		check(rec.Type)
+		if !a.isMarkerless(rec.Type) {
+			check(a.markerType())
+		}
+	}
+	if len(unsupported) == 0 {
+		return nil
+	}
+	slices.Sort(unsupported)
+	return fmt.Errorf("provider %s does not support record types %s", providerName(domain.provider), strings.Join(unsupported, ", "))
+}
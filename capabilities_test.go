@@ -0,0 +1,52 @@
+package dnsregister
+
+import (
+	"strings"
+	"testing"
+)
+
+// fakeTypedProvider is a fakeProvider that supports only some record
+// types.
+type fakeTypedProvider struct {
+	fakeProvider
+	types []string
+}
+
+func (p *fakeTypedProvider) SupportedTypes() []string { return p.types }
+
+func TestCheckSupportedTypes(t *testing.T) {
+	for _, tc := range []struct {
+		types      []string
+		markerless []string
+		want       string
+	}{
+		{types: []string{"A", "AAAA", "TXT", "MX"}},
+		{types: []string{"a", "txt"}, want: "does not support record types AAAA, MX"},
+		{types: []string{"A", "AAAA", "MX"}, want: "does not support record types TXT"},
+		{types: []string{"A", "AAAA", "MX"}, markerless: []string{"A", "AAAA", "MX"}},
+	} {
+		app := newTestApp(t, nil,
+			&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+			&Record{Name: "www", Type: "AAAA", Value: "2001:db8::1"},
+			&Record{Name: "@", Type: "MX", Value: "10 mx.example.com."})
+		app.MarkerlessTypes = tc.markerless
+		provider := &fakeTypedProvider{types: tc.types}
+
+		err := app.setProvider(app.Domains[0], func() (any, error) { return provider, nil })
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("%v: expected no error, got %v", tc.types, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: expected error %q, got %v", tc.types, tc.want, err)
+		}
+	}
+
+	// Providers that don't report their types aren't checked
+	app := newTestApp(t, nil, &Record{Name: "www", Type: "HTTPS", Value: "1 . alpn=h2"})
+	if err := app.setProvider(app.Domains[0], func() (any, error) { return &fakeProvider{}, nil }); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
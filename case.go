@@ -0,0 +1,68 @@
+package dnsregister
+
+import (
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// foldHostnames returns value with the hostnames in it lowercased, for
+// record types whose data holds hostnames (RFC 4343). Other data, such
+// as TXT content, is returned unchanged.
+func foldHostnames(typ, value string) string {
+	switch typ {
+	case "CNAME", "NS", "PTR", "DNAME":
+		return strings.ToLower(value)
+	case "MX", "SRV":
+		// The target is the last field
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			return value
+		}
+		fields[len(fields)-1] = strings.ToLower(fields[len(fields)-1])
+		return strings.Join(fields, " ")
+	default:
+		return value
+	}
+}
+
+// foldRecordCase lowercases the name of rec and the hostnames in its
+// value, unless configured casing is preserved.
+func (a *App) foldRecordCase(rec *Record) {
+	if a.PreserveCase {
+		return
+	}
+	rec.Name = strings.ToLower(rec.Name)
+	rec.Value = foldHostnames(rec.Type, rec.Value)
+}
+
+// adoptConfiguredCase returns records with names that differ from a
+// configured record name only in case renamed to the configured
+// spelling, for the records themselves and their ownership markers.
+// Names are case-insensitive in DNS, and providers may return them in a
+// different case than they were written in.
+func adoptConfiguredCase(records []libdns.Record, configured []*Record) []libdns.Record {
+	names := make(map[string]string)
+	for _, rec := range configured {
+		name := rec.Name
+		names[strings.ToLower(name)] = name
+		names[strings.ToLower(markerPrefix+name)] = markerPrefix + name
+	}
+
+	adopted := make([]libdns.Record, len(records))
+	for i, rec := range records {
+		adopted[i] = rec
+		rr := rec.RR()
+		name, ok := names[strings.ToLower(rr.Name)]
+		if !ok || name == rr.Name {
+			continue
+		}
+		rr.Name = name
+		if parsed, err := rr.Parse(); err == nil {
+			adopted[i] = parsed
+		} else {
+			adopted[i] = rr
+		}
+	}
+	return adopted
+}
@@ -0,0 +1,79 @@
+package dnsregister
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeFoldingProvider is a fakeProvider that stores names and hostname
+// values lowercased, like many DNS servers, but TXT data verbatim.
+// It keeps the records it was sent as they were sent.
+type fakeFoldingProvider struct {
+	fakeProvider
+	sent []libdns.Record
+}
+
+func (p *fakeFoldingProvider) fold(recs []libdns.Record) []libdns.Record {
+	p.sent = append(p.sent, recs...)
+	folded := make([]libdns.Record, len(recs))
+	for i, rec := range recs {
+		rr := rec.RR()
+		rr.Name = strings.ToLower(rr.Name)
+		if rr.Type != "TXT" {
+			rr.Data = strings.ToLower(rr.Data)
+		}
+		folded[i] = rr
+	}
+	return folded
+}
+
+func (p *fakeFoldingProvider) SetRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	return p.fakeProvider.SetRecords(ctx, zone, p.fold(recs))
+}
+
+func (p *fakeFoldingProvider) AppendRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	return p.fakeProvider.AppendRecords(ctx, zone, p.fold(recs))
+}
+
+func TestReconcilePreserveCase(t *testing.T) {
+	for _, preserve := range []bool{false, true} {
+		provider := &fakeFoldingProvider{}
+		app := newTestApp(t, provider)
+		app.PreserveCase = preserve
+		app.history = newReconcileHistory(0)
+		records := []*Record{
+			{Name: "Docs", Type: "CNAME", Value: "Pages.Example.NET."},
+			{Name: "_verify", Type: "TXT", Value: "Token=AbC123"},
+		}
+		for _, rec := range records {
+			app.foldRecordCase(rec)
+		}
+		app.Domains[0].Records = records
+
+		for i := 0; i < 2; i++ {
+			if err := app.reconcileDomain(app.Domains[0]); err != nil {
+				t.Fatalf("reconcileDomain failed: %v", err)
+			}
+		}
+
+		name, target := "docs", "pages.example.net."
+		if preserve {
+			name, target = "Docs", "Pages.Example.NET."
+		}
+		if !hasRecord(provider.sent, name, "CNAME", target) {
+			t.Errorf("preserve_case=%v: expected %s CNAME %s to be written, got %v", preserve, name, target, provider.sent)
+		}
+		if !hasRecord(provider.sent, "_verify", "TXT", "Token=AbC123") {
+			t.Errorf("preserve_case=%v: expected TXT to be written verbatim, got %v", preserve, provider.sent)
+		}
+
+		// The provider's lowercased hostname doesn't cause churn
+		last := app.history.last("example.com")
+		if len(last.Created) != 0 || len(last.Updated) != 0 || len(last.Deleted) != 0 {
+			t.Errorf("preserve_case=%v: expected no changes on second reconcile, got %+v", preserve, last)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package dnsregister
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// RecordCommentProvider is implemented by DNS providers that store a
+// comment with each record, such as Cloudflare or Technitium. Records
+// with a provider_comment have it written there, and are updated when
+// the stored comment differs.
+type RecordCommentProvider interface {
+	// GetRecordComments returns the comments of the zone's records
+	// that have one.
+	GetRecordComments(ctx context.Context, zone string) ([]RecordComment, error)
+
+	// SetRecordComments sets the comments of existing records of the
+	// zone. An empty comment removes the record's comment.
+	SetRecordComments(ctx context.Context, zone string, comments []RecordComment) error
+}
+
+// RecordComment is the comment stored with a record, identified by its
+// name relative to the zone, type and data as in libdns.RR.
+type RecordComment struct {
+	Name    string
+	Type    string
+	Data    string
+	Comment string
+}
+
+// commentKey returns the key of a record's comment in the map returned
+// by recordComments.
+func commentKey(rr libdns.RR) string {
+	return rr.Name + ":" + rr.Type + ":" + rr.Data
+}
+
+// recordComments returns the comments of the records of the domain's
+// zone, keyed by commentKey, or nil if the provider doesn't store
+// comments or they can't be read, in which case they are not compared.
+func (a *App) recordComments(domain *Domain) map[string]string {
+	p, ok := domain.provider.(RecordCommentProvider)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := a.readContext(domain)
+	comments, err := p.GetRecordComments(ctx, domain.fqdn())
+	cancel()
+	if err != nil {
+		a.logger.Warn("failed to get record comments, not comparing them",
+			zap.String("zone", domain.Zone),
+			zap.Error(err))
+		return nil
+	}
+	byKey := make(map[string]string, len(comments))
+	for _, c := range comments {
+		name := encodeRecordNames(domain.Zone, []libdns.Record{libdns.RR{Name: c.Name, Type: c.Type, Data: c.Data}})[0].RR().Name
+		byKey[commentKey(libdns.RR{Name: name, Type: c.Type, Data: c.Data})] = c.Comment
+	}
+	return byKey
+}
+
+// commentsChanged reports whether the comments stored with the existing
+// records of a set differ from the desired ones. The sets' values are
+// compared separately.
+func (a *App) commentsChanged(comments map[string]string, existing, desired []*Record) bool {
+	if comments == nil {
+		return false
+	}
+	want := make(map[string]string)
+	for i, rec := range a.toLibdnsRecords(desired) {
+		want[commentKey(rec.RR())] = desired[i].ProviderComment
+	}
+	for _, rec := range a.toLibdnsRecords(existing) {
+		if comments[commentKey(rec.RR())] != want[commentKey(rec.RR())] {
+			return true
+		}
+	}
+	return false
+}
+
+// writeComments writes the comments of the desired records of the sets
+// plan created or updated, recording failures in result. Sets without
+// comments are skipped unless their records had one before.
+func (a *App) writeComments(domain *Domain, plan *reconcilePlan, result *ReconcileResult) {
+	p, ok := domain.provider.(RecordCommentProvider)
+	if !ok || plan.comments == nil {
+		return
+	}
+
+	var keys []string
+	for _, key := range slices.Concat(plan.toCreate, plan.toUpdate) {
+		if slices.Contains(result.Created, key) || slices.Contains(result.Updated, key) {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		recs := plan.desired[key]
+		commented := false
+		for _, rec := range recs {
+			commented = commented || rec.ProviderComment != ""
+		}
+		for _, rec := range a.toLibdnsRecords(plan.owned[key]) {
+			commented = commented || plan.comments[commentKey(rec.RR())] != ""
+		}
+		if !commented {
+			continue
+		}
+
+		var comments []RecordComment
+		for i, rec := range a.toLibdnsRecords(recs) {
+			rr := rec.RR()
+			comments = append(comments, RecordComment{Name: rr.Name, Type: rr.Type, Data: rr.Data, Comment: recs[i].ProviderComment})
+		}
+		ctx, cancel := a.writeContext(domain)
+		err := p.SetRecordComments(ctx, domain.fqdn(), comments)
+		cancel()
+		if err != nil {
+			a.logger.Warn("failed to set record comments",
+				zap.String("zone", domain.Zone),
+				zap.String("record", key),
+				zap.Error(err))
+			result.Errors = append(result.Errors, fmt.Sprintf("comment %s: %v", key, err))
+			result.failed = append(result.failed, key)
+		}
+	}
+}
+
+// checkCommentSupport warns about records with a provider_comment in a
+// domain whose provider doesn't store comments, which is ignored.
+func (a *App) checkCommentSupport(domain *Domain) {
+	if _, ok := domain.provider.(RecordCommentProvider); ok {
+		return
+	}
+	var commented []string
+	for _, rec := range domain.Records {
+		if rec.ProviderComment != "" && !slices.Contains(commented, recordKey(rec)) {
+			commented = append(commented, recordKey(rec))
+		}
+	}
+	if len(commented) > 0 {
+		a.logger.Warn("DNS provider doesn't store record comments, ignoring provider_comment",
+			zap.String("zone", domain.Zone),
+			zap.String("provider", providerName(domain.provider)),
+			zap.Strings("records", commented))
+	}
+}
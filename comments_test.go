@@ -0,0 +1,91 @@
+package dnsregister
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeCommentProvider stores a comment with each record.
+type fakeCommentProvider struct {
+	fakeProvider
+	comments map[string]string
+}
+
+func (p *fakeCommentProvider) GetRecordComments(_ context.Context, _ string) ([]RecordComment, error) {
+	var comments []RecordComment
+	for _, rec := range p.records {
+		rr := rec.RR()
+		if comment := p.comments[commentKey(rr)]; comment != "" {
+			comments = append(comments, RecordComment{Name: rr.Name, Type: rr.Type, Data: rr.Data, Comment: comment})
+		}
+	}
+	return comments, nil
+}
+
+func (p *fakeCommentProvider) SetRecordComments(_ context.Context, _ string, comments []RecordComment) error {
+	for _, c := range comments {
+		p.comments[commentKey(libdns.RR{Name: c.Name, Type: c.Type, Data: c.Data})] = c.Comment
+	}
+	return nil
+}
+
+func TestReconcileProviderComment(t *testing.T) {
+	provider := &fakeCommentProvider{comments: make(map[string]string)}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1", ProviderComment: "web frontend"})
+	app.history = newReconcileHistory(0)
+	domain := app.Domains[0]
+
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	key := commentKey(libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"})
+	if got := provider.comments[key]; got != "web frontend" {
+		t.Errorf("expected the comment to be written with the record, got %q", got)
+	}
+
+	// An unchanged comment is left alone
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if result := app.history.last("example.com"); len(result.Updated) != 0 {
+		t.Errorf("expected no update, got %+v", result)
+	}
+
+	// A changed comment updates the record
+	domain.Records[0].ProviderComment = "web frontend (eu)"
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if result := app.history.last("example.com"); len(result.Updated) != 1 || result.Updated[0] != "www:A" {
+		t.Errorf("expected www:A to be updated, got %+v", result)
+	}
+	if got := provider.comments[key]; got != "web frontend (eu)" {
+		t.Errorf("expected the comment to be updated, got %q", got)
+	}
+
+	// So does a comment changed at the provider
+	provider.comments[key] = "edited"
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if got := provider.comments[key]; got != "web frontend (eu)" {
+		t.Errorf("expected the comment to be restored, got %q", got)
+	}
+}
+
+func TestReconcileProviderCommentUnsupported(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1", ProviderComment: "web frontend"})
+	app.history = newReconcileHistory(0)
+
+	for i := 0; i < 2; i++ {
+		if err := app.reconcileDomain(app.Domains[0]); err != nil {
+			t.Fatalf("reconcileDomain failed: %v", err)
+		}
+	}
+	if result := app.history.last("example.com"); len(result.Updated) != 0 || len(result.Errors) != 0 {
+		t.Errorf("expected the comment to be ignored, got %+v", result)
+	}
+}
@@ -0,0 +1,101 @@
+package dnsregister
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// keyName returns the record name of a set key.
+func keyName(key string) string {
+	return key[:strings.LastIndex(key, ":")]
+}
+
+// applyConcurrently calls apply for each of keys, with up to the
+// domain's provider_concurrency calls running at once. Sets sharing a
+// name, which may share an ownership marker, are applied one after
+// another in the order of keys. Each run records its outcome in its own
+// copy of result, merged back in the order of keys once all are done.
+// Without provider_concurrency the sets are applied in order.
+func (a *App) applyConcurrently(domain *Domain, keys []string, result *ReconcileResult, apply func(key string, result *ReconcileResult)) {
+	if domain.setSlots == nil || len(keys) < 2 {
+		for _, key := range keys {
+			apply(key, result)
+		}
+		return
+	}
+
+	var groups [][]string
+	index := make(map[string]int)
+	for _, key := range keys {
+		name := keyName(key)
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], key)
+	}
+
+	base := *result
+	forks := make([]ReconcileResult, len(groups))
+	var wg sync.WaitGroup
+	for i, group := range groups {
+		forks[i] = forkResult(base)
+		wg.Add(1)
+		go func(fork *ReconcileResult) {
+			defer wg.Done()
+			domain.setSlots <- struct{}{}
+			defer func() { <-domain.setSlots }()
+			for _, key := range group {
+				apply(key, fork)
+			}
+		}(&forks[i])
+	}
+	wg.Wait()
+
+	for _, fork := range forks {
+		result.Created = append(result.Created, fork.Created[len(base.Created):]...)
+		result.Updated = append(result.Updated, fork.Updated[len(base.Updated):]...)
+		result.Deleted = append(result.Deleted, fork.Deleted[len(base.Deleted):]...)
+		result.Released = append(result.Released, fork.Released[len(base.Released):]...)
+		result.Errors = append(result.Errors, fork.Errors[len(base.Errors):]...)
+		result.failed = append(result.failed, fork.failed[len(base.failed):]...)
+	}
+}
+
+// forkResult returns a copy of result whose changes don't affect it.
+func forkResult(result ReconcileResult) ReconcileResult {
+	result.Created = slices.Clone(result.Created)
+	result.Updated = slices.Clone(result.Updated)
+	result.Deleted = slices.Clone(result.Deleted)
+	result.Released = slices.Clone(result.Released)
+	result.Errors = slices.Clone(result.Errors)
+	result.failed = slices.Clone(result.failed)
+	return result
+}
+
+// createLevels splits the keys of plan's creates, in order, into levels
+// that only depend on sets of earlier levels, so the sets of a level
+// can be created at once.
+func (p *reconcilePlan) createLevels() [][]string {
+	deps := p.createDependencies()
+	level := make(map[string]int)
+	var levels [][]string
+	for _, key := range p.toCreate {
+		n := 0
+		for _, dep := range deps[key] {
+			// Dependencies come first; one that doesn't is in a cycle
+			if l, ok := level[dep]; ok && l >= n {
+				n = l + 1
+			}
+		}
+		level[key] = n
+		if n == len(levels) {
+			levels = append(levels, nil)
+		}
+		levels[n] = append(levels[n], key)
+	}
+	return levels
+}
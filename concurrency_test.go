@@ -0,0 +1,98 @@
+package dnsregister
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeParallelProvider rejects writes of more than one name, so changes are
+// applied one set at a time, and tracks how many writes run at once.
+type fakeParallelProvider struct {
+	fakeProvider
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	onWrite     func()
+}
+
+func (p *fakeParallelProvider) SetRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	for _, rec := range recs {
+		if name := rec.RR().Name; name != recs[0].RR().Name && name != markerPrefix+recs[0].RR().Name {
+			return nil, errors.New("batch rejected")
+		}
+	}
+	if p.onWrite != nil {
+		p.onWrite()
+	}
+	p.mu.Lock()
+	p.inFlight++
+	p.maxInFlight = max(p.maxInFlight, p.inFlight)
+	p.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	p.mu.Lock()
+	p.inFlight--
+	p.mu.Unlock()
+	return p.fakeProvider.SetRecords(ctx, zone, recs)
+}
+
+func TestReconcileProviderConcurrency(t *testing.T) {
+	records := func() []*Record {
+		var recs []*Record
+		for i := 0; i < 6; i++ {
+			recs = append(recs, &Record{Name: fmt.Sprintf("host%d", i), Type: "A", Value: fmt.Sprintf("10.0.0.%d", i)})
+		}
+		// Sets sharing a name are written one after another
+		return append(recs, &Record{Name: "host0", Type: "AAAA", Value: "2001:db8::1"})
+	}
+
+	for _, concurrency := range []int{0, 3} {
+		provider := &fakeParallelProvider{}
+		app := newTestApp(t, provider, records()...)
+		app.history = newReconcileHistory(0)
+		if concurrency > 0 {
+			app.Domains[0].setSlots = make(chan struct{}, concurrency)
+		}
+
+		if err := app.reconcileDomain(app.Domains[0]); err != nil {
+			t.Fatalf("concurrency=%d: reconcileDomain failed: %v", concurrency, err)
+		}
+		want := max(concurrency, 1)
+		if provider.maxInFlight != want {
+			t.Errorf("concurrency=%d: expected up to %d writes at once, got %d", concurrency, want, provider.maxInFlight)
+		}
+		result := app.history.last("example.com")
+		if len(result.Created) != 7 || len(result.Errors) != 0 {
+			t.Errorf("concurrency=%d: expected all sets created, got %+v", concurrency, result)
+		}
+		if owned := app.parseOwnedRecords("example.com", provider.records); len(owned) != 7 {
+			t.Errorf("concurrency=%d: expected 7 owned sets, got %d", concurrency, len(owned))
+		}
+	}
+}
+
+func TestCreateLevels(t *testing.T) {
+	plan := &reconcilePlan{
+		zone: "example.com",
+		desired: map[string][]*Record{
+			"db:A":  {{Name: "db", Type: "A", Value: "192.0.2.1"}},
+			"app:A": {{Name: "app", Type: "A", Value: "192.0.2.2", DependsOn: []string{"db"}}},
+			"web:A": {{Name: "web", Type: "A", Value: "192.0.2.3", DependsOn: []string{"app"}}},
+			"www:A": {{Name: "www", Type: "A", Value: "192.0.2.4"}},
+		},
+		toCreate: []string{"app:A", "db:A", "web:A", "www:A"},
+	}
+	plan.orderCreates()
+
+	levels := plan.createLevels()
+	want := [][]string{{"db:A", "www:A"}, {"app:A"}, {"web:A"}}
+	if !slices.EqualFunc(levels, want, slices.Equal) {
+		t.Errorf("expected levels %v, got %v", want, levels)
+	}
+}
@@ -0,0 +1,73 @@
+package dnsregister
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/caddyserver/caddy/v2"
+)
+
+// Condition compares two operands, either of which may contain Caddy
+// placeholders such as {env.ROLE}. It is evaluated each time it is
+// checked, so it follows changes to the environment.
+type Condition struct {
+	// Left is the left operand.
+	Left string `json:"left"`
+
+	// Op is the operator: "==" and "!=" compare the operands as
+	// strings, "=~" and "!~" match the left operand against the
+	// regular expression in Right.
+	Op string `json:"op"`
+
+	// Right is the right operand. Placeholders are not replaced in
+	// regular expressions, whose braces would be mistaken for them.
+	Right string `json:"right"`
+
+	re *regexp.Regexp
+}
+
+// String returns the condition as written in the Caddyfile.
+func (c *Condition) String() string {
+	return fmt.Sprintf("%s %s %s", c.Left, c.Op, c.Right)
+}
+
+// provision validates the operator and compiles the regular
+// expression, if any.
+func (c *Condition) provision() error {
+	switch c.Op {
+	case "==", "!=":
+	case "=~", "!~":
+		re, err := regexp.Compile(c.Right)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression %q: %v", c.Right, err)
+		}
+		c.re = re
+	default:
+		return fmt.Errorf("unsupported operator %q, must be one of ==, !=, =~, !~", c.Op)
+	}
+	return nil
+}
+
+// eval reports whether the condition holds. Unknown placeholders are
+// replaced with the empty string.
+func (c *Condition) eval() bool {
+	repl := caddy.NewReplacer()
+	left := repl.ReplaceAll(c.Left, "")
+	switch c.Op {
+	case "==":
+		return left == repl.ReplaceAll(c.Right, "")
+	case "!=":
+		return left != repl.ReplaceAll(c.Right, "")
+	case "=~":
+		return c.re.MatchString(left)
+	case "!~":
+		return !c.re.MatchString(left)
+	}
+	return false
+}
+
+// managed reports whether the domain is to be reconciled now: always,
+// unless its manage_when condition is false.
+func (d *Domain) managed() bool {
+	return d.ManageWhen == nil || d.ManageWhen.eval()
+}
@@ -0,0 +1,102 @@
+package dnsregister
+
+import (
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/libdns/libdns"
+)
+
+func TestConditionEval(t *testing.T) {
+	t.Setenv("CDR_TEST_ROLE", "primary")
+
+	for _, tc := range []struct {
+		cond Condition
+		want bool
+	}{
+		{Condition{Left: "{env.CDR_TEST_ROLE}", Op: "==", Right: "primary"}, true},
+		{Condition{Left: "{env.CDR_TEST_ROLE}", Op: "==", Right: "secondary"}, false},
+		{Condition{Left: "{env.CDR_TEST_ROLE}", Op: "!=", Right: "secondary"}, true},
+		{Condition{Left: "primary", Op: "==", Right: "{env.CDR_TEST_ROLE}"}, true},
+		{Condition{Left: "{env.CDR_TEST_UNSET}", Op: "==", Right: ""}, true},
+		{Condition{Left: "{env.CDR_TEST_ROLE}", Op: "=~", Right: "^prim"}, true},
+		{Condition{Left: "{env.CDR_TEST_ROLE}", Op: "!~", Right: "^prim"}, false},
+		{Condition{Left: "{env.CDR_TEST_ROLE}", Op: "=~", Right: "^p[a-z]{6}$"}, true},
+	} {
+		if err := tc.cond.provision(); err != nil {
+			t.Fatalf("%s: provision failed: %v", &tc.cond, err)
+		}
+		if got := tc.cond.eval(); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", &tc.cond, tc.want, got)
+		}
+	}
+
+	for _, cond := range []Condition{
+		{Left: "a", Op: "=", Right: "a"},
+		{Left: "a", Op: "=~", Right: "("},
+	} {
+		if err := cond.provision(); err == nil {
+			t.Errorf("%s: expected error", &cond)
+		}
+	}
+}
+
+func TestReconcileManageWhen(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.9"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+	cond := &Condition{Left: "{env.CDR_TEST_ROLE}", Op: "==", Right: "primary"}
+	if err := cond.provision(); err != nil {
+		t.Fatalf("provision failed: %v", err)
+	}
+	app.Domains[0].ManageWhen = cond
+
+	t.Setenv("CDR_TEST_ROLE", "secondary")
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.has("www", "A") || !provider.has("old", "A") {
+		t.Errorf("expected no changes while the condition is false, got %v", provider.records)
+	}
+	if last := app.history.last("example.com"); last != nil {
+		t.Errorf("expected skipped reconcile not to be recorded, got %+v", last)
+	}
+
+	t.Setenv("CDR_TEST_ROLE", "primary")
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !provider.has("www", "A") || provider.has("old", "A") {
+		t.Errorf("expected the domain to be reconciled once the condition holds, got %v", provider.records)
+	}
+}
+
+func TestUnmarshalCaddyfileManageWhen(t *testing.T) {
+	d := caddyfile.NewTestDispenser(`dns_register {
+		domain example.com {
+			manage_when {env.ROLE} == primary
+		}
+	}`)
+
+	app := &App{}
+	if err := app.UnmarshalCaddyfile(d); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	cond := app.Domains[0].ManageWhen
+	if cond == nil || cond.Left != "{env.ROLE}" || cond.Op != "==" || cond.Right != "primary" {
+		t.Errorf("unexpected condition: %+v", cond)
+	}
+
+	for _, input := range []string{
+		`manage_when {env.ROLE} primary`,
+		`manage_when {env.ROLE} <> primary`,
+	} {
+		d := caddyfile.NewTestDispenser("dns_register {\n\tdomain example.com {\n\t\t" + input + "\n\t}\n}")
+		if err := (&App{}).UnmarshalCaddyfile(d); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package dnsregister
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// lingeringDeletes re-reads the domain's zone and returns the keys of
+// the deleted record sets of plan whose records or marker are still
+// present, e.g. because the provider applies deletes lazily.
+func (a *App) lingeringDeletes(domain *Domain, plan *reconcilePlan, keys []string) ([]string, error) {
+	getter, ok := domain.provider.(libdns.RecordGetter)
+	if !ok {
+		return nil, nil
+	}
+	ctx, cancel := a.readContext(domain)
+	existing, err := providerGetRecords(ctx, domain, getter)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("re-reading zone to confirm deletes: %w", err)
+	}
+	existing = encodeRecordNames(domain.Zone, existing)
+
+	var lingering []string
+	for _, key := range keys {
+		if containsAnyRR(existing, a.deletionRecords(plan, key)) {
+			lingering = append(lingering, key)
+		}
+	}
+	return lingering, nil
+}
+
+// confirmDelete checks that the deleted record set key of plan is gone
+// from the zone, deleting it once more if it is not. It returns an
+// error if the set is still present after that.
+func (a *App) confirmDelete(domain *Domain, plan *reconcilePlan, key string) error {
+	lingering, err := a.lingeringDeletes(domain, plan, []string{key})
+	if err != nil || len(lingering) == 0 {
+		return err
+	}
+
+	a.logger.Warn("deleted record still present, deleting again",
+		zap.String("zone", domain.Zone),
+		zap.String("record", key))
+	deleter := domain.provider.(libdns.RecordDeleter)
+	ctx, cancel := a.writeContext(domain)
+	_, err = providerDeleteRecords(ctx, domain, deleter, a.deletionRecords(plan, key))
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	lingering, err = a.lingeringDeletes(domain, plan, []string{key})
+	if err != nil {
+		return err
+	}
+	if len(lingering) > 0 {
+		return fmt.Errorf("still present after deleting twice")
+	}
+	return nil
+}
+
+// containsAnyRR reports whether any of recs is in existing, comparing
+// names case-insensitively and data without quotes and hostname case.
+func containsAnyRR(existing, recs []libdns.Record) bool {
+	for _, rec := range recs {
+		want := rec.RR()
+		for _, ex := range existing {
+			got := ex.RR()
+			if strings.EqualFold(got.Name, want.Name) && got.Type == want.Type &&
+				foldHostnames(got.Type, strings.Trim(got.Data, "\"")) == foldHostnames(want.Type, strings.Trim(want.Data, "\"")) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package dnsregister
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeLazyDeleteProvider reports success for its first ignore deletes
+// without applying them, like a provider applying deletes lazily.
+type fakeLazyDeleteProvider struct {
+	fakeProvider
+	ignore  int
+	deletes int
+}
+
+func (p *fakeLazyDeleteProvider) DeleteRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	p.deletes++
+	if p.ignore > 0 {
+		p.ignore--
+		return recs, nil
+	}
+	return p.fakeProvider.DeleteRecords(ctx, zone, recs)
+}
+
+func TestConfirmDeletes(t *testing.T) {
+	tests := []struct {
+		name    string
+		confirm bool
+		ignore  int
+		deletes int
+		gone    bool
+		err     string
+	}{
+		{"unconfirmed", false, 1, 1, false, ""},
+		{"deleted again", true, 1, 2, true, ""},
+		// The batched delete, then once per set with a retry
+		{"still present", true, 3, 3, false, "still present after deleting twice"},
+	}
+	for _, tt := range tests {
+		provider := &fakeLazyDeleteProvider{ignore: tt.ignore, fakeProvider: fakeProvider{records: []libdns.Record{
+			libdns.RR{Name: "old", Type: "A", Data: "192.0.2.2"},
+			libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		}}}
+		app := newTestApp(t, provider)
+		app.history = newReconcileHistory(0)
+		app.ConfirmDeletes = tt.confirm
+
+		if err := app.reconcileDomain(app.Domains[0]); err != nil {
+			t.Fatalf("%s: reconcileDomain failed: %v", tt.name, err)
+		}
+		last := app.history.last("example.com")
+		if provider.deletes != tt.deletes {
+			t.Errorf("%s: expected %d deletes, got %d", tt.name, tt.deletes, provider.deletes)
+		}
+		if gone := !provider.has("old", "A") && !provider.has("_cdr.old", "TXT"); gone != tt.gone {
+			t.Errorf("%s: expected gone=%v, got records %v", tt.name, tt.gone, provider.records)
+		}
+		if tt.err == "" && (len(last.Errors) != 0 || !slices.Contains(last.Deleted, "old:A")) {
+			t.Errorf("%s: expected old:A to be deleted, got %+v", tt.name, last)
+		}
+		if tt.err != "" && (len(last.Errors) != 1 || !strings.Contains(last.Errors[0], tt.err) || len(last.Deleted) != 0) {
+			t.Errorf("%s: expected error %q, got %+v", tt.name, tt.err, last)
+		}
+	}
+}
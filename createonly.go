@@ -0,0 +1,26 @@
+package dnsregister
+
+import "github.com/libdns/libdns"
+
+// createOnlyKeys returns the keys of the domain's record sets configured
+// to be created only.
+func (a *App) createOnlyKeys(domain *Domain) map[string]bool {
+	createOnly := make(map[string]bool)
+	for _, rec := range a.patchedRecords(domain) {
+		if rec.CreateOnly {
+			createOnly[recordKey(rec)] = true
+		}
+	}
+	return createOnly
+}
+
+// presentKeys returns the keys of the record sets in records, owned or
+// not.
+func presentKeys(records []libdns.Record) map[string]bool {
+	present := make(map[string]bool)
+	for _, rec := range records {
+		rr := rec.RR()
+		present[rr.Name+":"+rr.Type] = true
+	}
+	return present
+}
@@ -0,0 +1,58 @@
+package dnsregister
+
+import (
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestReconcileCreateOnly(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider,
+		&Record{Name: "seed", Type: "TXT", Value: "initial", CreateOnly: true})
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !hasRecord(provider.records, "seed", "TXT", "initial") || !provider.has("_cdr.seed", "TXT") {
+		t.Fatalf("expected seed to be created with its marker, got %v", provider.records)
+	}
+
+	// A changed value doesn't update the record once it exists
+	app.Domains[0].Records[0].Value = "changed"
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !hasRecord(provider.records, "seed", "TXT", "initial") || hasRecord(provider.records, "seed", "TXT", "changed") {
+		t.Errorf("expected seed to keep its initial value, got %v", provider.records)
+	}
+	result := app.history.last("example.com")
+	if len(result.Created) != 0 || len(result.Updated) != 0 || len(result.Deleted) != 0 {
+		t.Errorf("expected no changes, got %+v", result)
+	}
+}
+
+func TestReconcileCreateOnlyExistingUnowned(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "seed", Type: "TXT", Data: "curated"},
+	}}
+	app := newTestApp(t, provider,
+		&Record{Name: "seed", Type: "TXT", Value: "initial", CreateOnly: true},
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !hasRecord(provider.records, "seed", "TXT", "curated") || hasRecord(provider.records, "seed", "TXT", "initial") {
+		t.Errorf("expected the existing seed to be left alone, got %v", provider.records)
+	}
+	if provider.has("_cdr.seed", "TXT") {
+		t.Errorf("expected seed not to be marked, got %v", provider.records)
+	}
+	result := app.history.last("example.com")
+	if len(result.Created) != 1 || result.Created[0] != "www:A" {
+		t.Errorf("expected only www:A to be created, got %+v", result)
+	}
+}
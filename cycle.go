@@ -0,0 +1,65 @@
+package dnsregister
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// runCycle triggers a reconcile of each of domains, sharing a records
+// cache cycle, and waits for them. The reconciles run concurrently,
+// bounded by the reconcile worker pool. With a cycle deadline, they run
+// within it, retries included: reconciles still running or waiting for
+// a worker at the deadline are cancelled. It returns the zones whose
+// reconcile was triggered.
+func (a *App) runCycle(domains []*Domain, reason string) []string {
+	a.cycle(domains, reason, func(ctx context.Context, domain *Domain) {
+		a.triggerReconcile(ctx, domain, reason)
+	})
+	triggered := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		triggered = append(triggered, domain.Zone)
+	}
+	return triggered
+}
+
+// cycle runs reconcile for each of domains concurrently, within the
+// cycle's context and records cache cycle, and waits for them.
+func (a *App) cycle(domains []*Domain, reason string, reconcile func(ctx context.Context, domain *Domain)) {
+	defer a.cache.startCycle()()
+
+	ctx, cancel := a.ctx, context.CancelFunc(func() {})
+	if a.CycleDeadline > 0 {
+		ctx, cancel = context.WithTimeout(a.ctx, time.Duration(a.CycleDeadline))
+	}
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		cancelled []string
+	)
+	for _, domain := range domains {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reconcile(ctx, domain)
+			if ctx.Err() != nil {
+				mu.Lock()
+				cancelled = append(cancelled, domain.Zone)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		a.logger.Warn("reconcile cycle deadline exceeded, cycle truncated",
+			zap.String("reason", reason),
+			zap.Duration("cycle_deadline", time.Duration(a.CycleDeadline)),
+			zap.Strings("cancelled_zones", cancelled))
+	}
+}
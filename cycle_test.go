@@ -0,0 +1,82 @@
+package dnsregister
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCycleDeadline(t *testing.T) {
+	app := newTestApp(t, &fakeHangingProvider{entered: make(chan struct{})}, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.Domains = append(app.Domains, &Domain{Zone: "other.example", provider: &fakeHangingProvider{entered: make(chan struct{})}})
+	app.history = newReconcileHistory(0)
+	app.running = newRunningReconciles()
+	app.workers = newReconcileWorkers(1)
+	app.CycleDeadline = caddy.Duration(50 * time.Millisecond)
+	core, logs := observer.New(zap.WarnLevel)
+	app.logger = zap.New(core)
+
+	// The hung reconcile is cancelled at the deadline, and so is the
+	// one waiting for the only worker
+	start := time.Now()
+	triggered := app.runCycle(app.Domains, "test")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected cycle to end at its deadline, took %v", elapsed)
+	}
+	if !slices.Equal(triggered, []string{"example.com", "other.example"}) {
+		t.Errorf("expected both zones to be triggered, got %v", triggered)
+	}
+	for _, zone := range triggered {
+		last := app.history.last(zone)
+		if last == nil || len(last.Errors) != 1 || !strings.Contains(last.Errors[0], "deadline exceeded") {
+			t.Errorf("%s: expected deadline error, got %+v", zone, last)
+		}
+	}
+
+	entries := logs.FilterMessageSnippet("cycle deadline exceeded").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 truncation warning, got %d", len(entries))
+	}
+	cancelled := entries[0].ContextMap()["cancelled_zones"].([]any)
+	slices.SortFunc(cancelled, func(a, b any) int { return strings.Compare(a.(string), b.(string)) })
+	if !slices.Equal(cancelled, []any{"example.com", "other.example"}) {
+		t.Errorf("expected both zones to be reported cancelled, got %v", cancelled)
+	}
+}
+
+func TestCycleConcurrent(t *testing.T) {
+	first := &fakeHangingProvider{entered: make(chan struct{})}
+	second := &fakeHangingProvider{entered: make(chan struct{})}
+	app := newTestApp(t, first, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.Domains = append(app.Domains, &Domain{Zone: "other.example", provider: second})
+	app.workers = newReconcileWorkers(2)
+	ctx, cancel := context.WithCancel(context.Background())
+	app.ctx = ctx
+
+	done := make(chan []string)
+	go func() { done <- app.runCycle(app.Domains, "test") }()
+
+	// Both zones are read at once, each on its own worker
+	for _, provider := range []*fakeHangingProvider{first, second} {
+		select {
+		case <-provider.entered:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the zones to be reconciled concurrently")
+		}
+	}
+	if active := app.workers.active.Load(); active != 2 {
+		t.Errorf("expected 2 active workers, got %d", active)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cycle did not return after the app stopped")
+	}
+}
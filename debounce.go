@@ -0,0 +1,80 @@
+package dnsregister
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// pendingReconciles holds the reconciles scheduled per owner and zone
+// while a debounce window is open. It is package-level so that triggers
+// coalesce across config reloads, which replace the App.
+var pendingReconciles = struct {
+	sync.Mutex
+	runs map[string]func()
+}{runs: make(map[string]func())}
+
+// triggerReconcile requests a reconcile of domain. Without a debounce
+// window the reconcile runs immediately, within ctx. Otherwise it runs
+// once the window has passed, and any further triggers for the zone
+// within the window are coalesced into it; such a reconcile is not
+// bound by ctx, which may have ended by then.
+func (a *App) triggerReconcile(ctx context.Context, domain *Domain, reason string) {
+	if a.ReconcileDebounce <= 0 {
+		a.runReconcile(ctx, domain)
+		return
+	}
+
+	key := a.OwnerID + "/" + domain.Zone
+	run := func() { a.runReconcile(a.ctx, domain) }
+
+	pendingReconciles.Lock()
+	defer pendingReconciles.Unlock()
+
+	if _, pending := pendingReconciles.runs[key]; pending {
+		// Run the latest App's reconcile when the window closes
+		pendingReconciles.runs[key] = run
+		a.logger.Debug("coalesced reconcile trigger",
+			zap.String("zone", domain.Zone),
+			zap.String("reason", reason))
+		return
+	}
+
+	pendingReconciles.runs[key] = run
+	time.AfterFunc(time.Duration(a.ReconcileDebounce), func() {
+		pendingReconciles.Lock()
+		run := pendingReconciles.runs[key]
+		delete(pendingReconciles.runs, key)
+		pendingReconciles.Unlock()
+
+		run()
+	})
+
+	a.logger.Debug("scheduled reconcile",
+		zap.String("zone", domain.Zone),
+		zap.String("reason", reason),
+		zap.Duration("debounce", time.Duration(a.ReconcileDebounce)))
+}
+
+// runReconcile reconciles domain within ctx, logs any error and returns
+// the result. It does nothing and returns nil if the App has been
+// stopped or reconciliation is paused.
+func (a *App) runReconcile(ctx context.Context, domain *Domain) *ReconcileResult {
+	if a.ctx.Err() != nil {
+		return nil
+	}
+	if a.paused != nil && a.paused.Load() {
+		a.logger.Info("reconciliation paused, skipping reconcile",
+			zap.String("zone", domain.Zone))
+		return nil
+	}
+	result, err := a.reconcile(ctx, domain, nil)
+	if err != nil {
+		a.logger.Error("failed to reconcile domain",
+			zap.String("zone", domain.Zone),
+			zap.Error(err))
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package dnsregister
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+)
+
+func TestTriggerReconcileDebounce(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.ReconcileDebounce = caddy.Duration(50 * time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		app.triggerReconcile(app.ctx, app.Domains[0], "test")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !provider.has("www", "A") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	provider.mu.Lock()
+	gets := provider.gets
+	provider.mu.Unlock()
+	if gets != 1 {
+		t.Errorf("expected triggers to coalesce into 1 reconcile, got %d", gets)
+	}
+	if !provider.has("www", "A") {
+		t.Error("expected www A to be created by the debounced reconcile")
+	}
+}
+
+func TestTriggerReconcileImmediate(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+
+	app.triggerReconcile(app.ctx, app.Domains[0], "test")
+	app.triggerReconcile(app.ctx, app.Domains[0], "test")
+
+	if provider.gets != 2 {
+		t.Errorf("expected 2 reconciles without debounce, got %d", provider.gets)
+	}
+}
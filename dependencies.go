@@ -0,0 +1,166 @@
+package dnsregister
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// dependencyMatches reports whether the depends_on reference ref, of
+// the form "<name>" or "<name>:<type>", refers to rec. Names and types
+// are compared case-insensitively.
+func dependencyMatches(ref string, rec *Record) bool {
+	name, typ, hasType := strings.Cut(ref, ":")
+	if encoded, err := encodeName(name); err == nil {
+		name = encoded
+	}
+	return strings.EqualFold(name, rec.Name) && (!hasType || strings.EqualFold(typ, rec.Type))
+}
+
+// dependencyGraph returns, for each record set key of records, the keys
+// of the sets it depends on, sorted. A reference matching no record is
+// reported as an error.
+func dependencyGraph(records []*Record) (map[string][]string, error) {
+	graph := make(map[string][]string)
+	for _, rec := range records {
+		key := recordKey(rec)
+		for _, ref := range rec.DependsOn {
+			found := false
+			for _, dep := range records {
+				if !dependencyMatches(ref, dep) {
+					continue
+				}
+				found = true
+				depKey := recordKey(dep)
+				if depKey == key {
+					return nil, fmt.Errorf("record %s: depends on itself", key)
+				}
+				if !slices.Contains(graph[key], depKey) {
+					graph[key] = append(graph[key], depKey)
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("record %s: depends_on %s: no such record", key, ref)
+			}
+		}
+	}
+	for _, deps := range graph {
+		sort.Strings(deps)
+	}
+	return graph, nil
+}
+
+// checkDependencies checks that the depends_on references of the
+// domain's records refer to records of the domain and form no cycle.
+func checkDependencies(domain *Domain) error {
+	graph, err := dependencyGraph(domain.Records)
+	if err != nil {
+		return err
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var visit func(key string, path []string) error
+	visit = func(key string, path []string) error {
+		switch state[key] {
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, key), " -> "))
+		case visited:
+			return nil
+		}
+		state[key] = visiting
+		for _, dep := range graph[key] {
+			if err := visit(dep, append(path, key)); err != nil {
+				return err
+			}
+		}
+		state[key] = visited
+		return nil
+	}
+
+	keys := make([]string, 0, len(graph))
+	for key := range graph {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := visit(key, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createDependencies returns, for each record set the plan creates, the
+// sets it depends on that the plan also creates. NS sets depend on
+// their glue sets without depends_on.
+func (p *reconcilePlan) createDependencies() map[string][]string {
+	var records []*Record
+	creating := make(map[string]bool)
+	for _, key := range p.toCreate {
+		records = append(records, p.desired[key]...)
+		creating[key] = true
+	}
+	// References to sets that already exist are satisfied, so unknown
+	// references are not an error here
+	deps := make(map[string][]string)
+	for _, rec := range records {
+		key := recordKey(rec)
+		for _, ref := range rec.DependsOn {
+			for _, dep := range records {
+				depKey := recordKey(dep)
+				if depKey != key && creating[depKey] && dependencyMatches(ref, dep) && !slices.Contains(deps[key], depKey) {
+					deps[key] = append(deps[key], depKey)
+				}
+			}
+		}
+	}
+	glue, _ := glueSets(p.zone, p.desired)
+	for key, glueKeys := range glue {
+		if !creating[key] {
+			continue
+		}
+		for _, glueKey := range glueKeys {
+			if creating[glueKey] && !slices.Contains(deps[key], glueKey) {
+				deps[key] = append(deps[key], glueKey)
+			}
+		}
+	}
+	for _, keys := range deps {
+		sort.Strings(keys)
+	}
+	return deps
+}
+
+// orderCreates orders the plan's creates so that each set is created
+// after the sets it depends on, keeping the existing order otherwise.
+func (p *reconcilePlan) orderCreates() {
+	deps := p.createDependencies()
+	if len(deps) == 0 {
+		return
+	}
+
+	ordered := make([]string, 0, len(p.toCreate))
+	done := make(map[string]bool)
+	var visit func(key string)
+	visit = func(key string) {
+		if done[key] {
+			return
+		}
+		// Marked before its dependencies, so a cycle (possible only
+		// through patches, which aren't checked) can't recurse forever
+		done[key] = true
+		for _, dep := range deps[key] {
+			visit(dep)
+		}
+		ordered = append(ordered, key)
+	}
+	for _, key := range p.toCreate {
+		visit(key)
+	}
+	p.toCreate = ordered
+}
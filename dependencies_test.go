@@ -0,0 +1,91 @@
+package dnsregister
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeOrderProvider records the names written by each SetRecords call.
+type fakeOrderProvider struct {
+	fakeCountingProvider
+	written []string
+}
+
+func (p *fakeOrderProvider) SetRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	set, err := p.fakeCountingProvider.SetRecords(ctx, zone, recs)
+	if err == nil {
+		for _, rec := range recs {
+			if name := rec.RR().Name; !strings.HasPrefix(name, markerPrefix) {
+				p.written = append(p.written, name)
+			}
+		}
+	}
+	return set, err
+}
+
+func TestCheckDependencies(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		records []*Record
+		err     string
+	}{
+		{"chain", []*Record{
+			{Name: "a", Type: "CNAME", Value: "b.example.com.", DependsOn: []string{"b"}},
+			{Name: "b", Type: "CNAME", Value: "c.example.com.", DependsOn: []string{"c:A"}},
+			{Name: "c", Type: "A", Value: "192.0.2.1"},
+		}, ""},
+		{"cycle", []*Record{
+			{Name: "a", Type: "CNAME", Value: "b.example.com.", DependsOn: []string{"b"}},
+			{Name: "b", Type: "CNAME", Value: "a.example.com.", DependsOn: []string{"a"}},
+		}, "dependency cycle: a:CNAME -> b:CNAME -> a:CNAME"},
+		{"self", []*Record{
+			{Name: "a", Type: "A", Value: "192.0.2.1", DependsOn: []string{"a"}},
+		}, "depends on itself"},
+		{"unknown", []*Record{
+			{Name: "a", Type: "CNAME", Value: "b.example.com.", DependsOn: []string{"b:AAAA"}},
+			{Name: "b", Type: "A", Value: "192.0.2.1"},
+		}, "no such record"},
+	} {
+		err := checkDependencies(&Domain{Zone: "example.com", Records: tc.records})
+		if tc.err == "" && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+		if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestReconcileDependencies(t *testing.T) {
+	provider := &fakeOrderProvider{fakeCountingProvider: fakeCountingProvider{failName: "web"}}
+	// "alias" sorts before "web", so only the dependency puts web first
+	app := newTestApp(t, provider,
+		&Record{Name: "alias", Type: "CNAME", Value: "web.example.com.", DependsOn: []string{"web"}},
+		&Record{Name: "web", Type: "A", Value: "192.0.2.1"},
+	)
+	app.history = newReconcileHistory(0)
+
+	// The CNAME is not created while its target can't be
+	_ = app.reconcileDomain(app.Domains[0])
+	last := app.history.last("example.com")
+	if len(last.Created) != 0 {
+		t.Errorf("expected nothing to be created, got %v", last.Created)
+	}
+	if !slices.ContainsFunc(last.Errors, func(e string) bool {
+		return strings.Contains(e, "creating web:A it depends on failed")
+	}) {
+		t.Errorf("expected dependency error, got %v", last.Errors)
+	}
+
+	provider.failName = ""
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !slices.Equal(provider.written, []string{"web", "alias"}) {
+		t.Errorf("expected web to be written before alias, got %v", provider.written)
+	}
+}
@@ -0,0 +1,110 @@
+package dnsregister
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// dedupeRecords returns records without exact duplicates, records with
+// the same name, type and data as an earlier one, and the duplicates
+// removed, keyed by set key.
+func dedupeRecords(records []libdns.Record) (unique []libdns.Record, duplicates map[string][]libdns.Record) {
+	duplicates = make(map[string][]libdns.Record)
+	seen := make(map[string]bool)
+	for _, rec := range records {
+		rr := rec.RR()
+		key := rr.Name + ":" + rr.Type
+		id := key + ":" + rr.Data
+		if seen[id] {
+			duplicates[key] = append(duplicates[key], rec)
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, rec)
+	}
+	return unique, duplicates
+}
+
+// dedupeValues returns the records of a desired set without those whose
+// value, compared as set members are, repeats an earlier one, and the
+// values of the records removed.
+func dedupeValues(recs []*Record) (unique []*Record, removed []string) {
+	seen := make(map[string]bool)
+	for _, rec := range recs {
+		value := canonicalValue(rec)
+		if seen[value] {
+			removed = append(removed, rec.Value)
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, rec)
+	}
+	return unique, removed
+}
+
+// planDuplicateRemoval adds the owned sets of plan that the provider
+// returned exact duplicates of and that stay as they are to the plan's
+// updates, with the duplicates to delete before the update rewrites
+// them. Sets that are created, updated or deleted anyway are only
+// rewritten. Only sets whose keys are in only are considered, or all
+// if only is nil. It requires a provider that can set records.
+func (a *App) planDuplicateRemoval(domain *Domain, plan *reconcilePlan, duplicates map[string][]libdns.Record, only map[string]bool) {
+	if _, ok := domain.provider.(libdns.RecordSetter); !ok {
+		return
+	}
+	for key, dups := range duplicates {
+		if only != nil && !only[key] {
+			continue
+		}
+		if _, owned := plan.owned[key]; !owned {
+			continue
+		}
+		if _, desired := plan.desired[key]; !desired {
+			continue
+		}
+		if plan.duplicates == nil {
+			plan.duplicates = make(map[string][]libdns.Record)
+		}
+		plan.duplicates[key] = dups
+		if !slices.Contains(plan.toUpdate, key) {
+			plan.toUpdate = append(plan.toUpdate, key)
+		}
+	}
+}
+
+// deleteDuplicates deletes the duplicate records of the plan. Providers
+// that delete by value may delete every copy; the sets are rewritten by
+// the updates that follow.
+func (a *App) deleteDuplicates(domain *Domain, plan *reconcilePlan, result *ReconcileResult) {
+	deleter, ok := domain.provider.(libdns.RecordDeleter)
+	if !ok || len(plan.duplicates) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(plan.duplicates))
+	for key := range plan.duplicates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		ctx, cancel := a.writeContext(domain)
+		_, err := providerDeleteRecords(ctx, domain, deleter, plan.duplicates[key])
+		cancel()
+		if err != nil {
+			a.logger.Warn("failed to remove duplicate records",
+				zap.String("zone", domain.Zone),
+				zap.String("record", key),
+				zap.Error(err))
+			result.Errors = append(result.Errors, fmt.Sprintf("remove duplicates of %s: %v", key, err))
+			continue
+		}
+		a.logger.Info("removed duplicate records",
+			zap.String("zone", domain.Zone),
+			zap.String("record", key),
+			zap.Int("count", len(plan.duplicates[key])))
+	}
+}
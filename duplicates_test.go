@@ -0,0 +1,101 @@
+package dnsregister
+
+import (
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+// countRecords returns the number of records in recs with the given
+// name, type and data.
+func countRecords(recs []libdns.Record, name, typ, data string) int {
+	n := 0
+	for _, rec := range recs {
+		if rr := rec.RR(); rr.Name == name && rr.Type == typ && rr.Data == data {
+			n++
+		}
+	}
+	return n
+}
+
+func TestReconcileDuplicateRecords(t *testing.T) {
+	for _, remove := range []bool{false, true} {
+		provider := &fakeProvider{records: []libdns.Record{
+			libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+			libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+			libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+			// Duplicates of records not owned are never touched
+			libdns.RR{Name: "manual", Type: "A", Data: "192.0.2.20"},
+			libdns.RR{Name: "manual", Type: "A", Data: "192.0.2.20"},
+		}}
+		app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+		app.RemoveDuplicates = remove
+		app.history = newReconcileHistory(0)
+
+		if err := app.reconcileDomain(app.Domains[0]); err != nil {
+			t.Fatalf("remove=%v: reconcileDomain failed: %v", remove, err)
+		}
+		result := app.history.last("example.com")
+		if len(result.Errors) != 0 || len(result.Created) != 0 || len(result.Deleted) != 0 {
+			t.Errorf("remove=%v: unexpected result %+v", remove, result)
+		}
+
+		want := 2
+		if remove {
+			want = 1
+		}
+		if n := countRecords(provider.records, "www", "A", "192.0.2.1"); n != want {
+			t.Errorf("remove=%v: expected %d copies of www, got %v", remove, want, provider.records)
+		}
+		if n := countRecords(provider.records, "manual", "A", "192.0.2.20"); n != 2 {
+			t.Errorf("remove=%v: expected duplicates of manual to be kept, got %v", remove, provider.records)
+		}
+
+		// Duplicates alone don't make the set look changed
+		if err := app.reconcileDomain(app.Domains[0]); err != nil {
+			t.Fatalf("remove=%v: reconcileDomain failed: %v", remove, err)
+		}
+		if result := app.history.last("example.com"); len(result.Updated) != 0 {
+			t.Errorf("remove=%v: expected no updates, got %v", remove, result.Updated)
+		}
+	}
+}
+
+func TestDedupeRecords(t *testing.T) {
+	unique, dups := dedupeRecords([]libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.2"},
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "www", Type: "TXT", Data: "192.0.2.1"},
+	})
+	if len(unique) != 3 {
+		t.Errorf("expected 3 unique records, got %v", unique)
+	}
+	if len(dups) != 1 || len(dups["www:A"]) != 1 {
+		t.Errorf("expected one duplicate of www:A, got %v", dups)
+	}
+}
+
+func TestReconcileDuplicateValues(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "www", Type: "A", Value: "192.0.2.2"},
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+
+	for i := 0; i < 2; i++ {
+		if err := app.reconcileDomain(app.Domains[0]); err != nil {
+			t.Fatalf("reconcileDomain failed: %v", err)
+		}
+	}
+	if n := countRecords(provider.records, "www", "A", "192.0.2.1"); n != 1 {
+		t.Errorf("expected a single copy of the repeated value, got %v", provider.records)
+	}
+	if !hasRecord(provider.records, "www", "A", "192.0.2.2") {
+		t.Errorf("expected the other value to be kept, got %v", provider.records)
+	}
+	if result := app.history.last("example.com"); len(result.Updated) != 0 || len(result.Created) != 0 {
+		t.Errorf("expected the set to be in sync, got %+v", result)
+	}
+}
@@ -0,0 +1,28 @@
+package dnsregister
+
+import "sort"
+
+// effectiveRecords returns the records the domain's zone is managed to
+// contain: the configured records after templates, value files and
+// patches are applied, with validity windows, SRV lookups, SPF merging
+// and default TTLs resolved, as a reconcile would see them. Sets whose
+// values could not be resolved are left out, as they are left as they
+// are by reconciles. Records are sorted by name and type.
+func (a *App) effectiveRecords(domain *Domain) ([]*Record, error) {
+	if err := a.ensureProvider(domain); err != nil {
+		return nil, err
+	}
+	desired, _ := a.desiredRecords(domain)
+
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	records := make([]*Record, 0, len(desired))
+	for _, key := range keys {
+		records = append(records, desired[key]...)
+	}
+	return records, nil
+}
@@ -0,0 +1,54 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestAdminConfig(t *testing.T) {
+	app := newTestApp(t, &fakeProvider{},
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "old", Type: "A", Value: "192.0.2.2", ValidUntil: "2000-01-01T00:00:00Z"},
+		&Record{Name: "@", Type: "TXT", Value: "include:_spf.google.com", SPF: true},
+		&Record{Name: "@", Type: "TXT", Value: "include:mailgun.org ~all", SPF: true},
+	)
+	app.patches = newZonePatches()
+	if err := app.addPatch("example.com", []*Record{{Name: "api", Type: "a", Value: "192.0.2.3"}}, nil); err != nil {
+		t.Fatalf("addPatch failed: %v", err)
+	}
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"config?zone=example.com", nil)
+	if err := api.handleAPIEndpoints(rec, req); err != nil {
+		t.Fatalf("GET config failed: %v", err)
+	}
+	var records []*Record
+	if err := json.Unmarshal(rec.Body.Bytes(), &records); err != nil {
+		t.Fatalf("decoding records: %v", err)
+	}
+
+	// Expired records are left out, SPF records merged and patches applied
+	want := []Record{
+		{Name: "@", Type: "TXT", Value: "v=spf1 include:_spf.google.com include:mailgun.org ~all"},
+		{Name: "api", Type: "A", Value: "192.0.2.3"},
+		{Name: "www", Type: "A", Value: "192.0.2.1"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("expected %d records, got %+v", len(want), records)
+	}
+	for i, w := range want {
+		if got := records[i]; got.Name != w.Name || got.Type != w.Type || got.Value != w.Value {
+			t.Errorf("record %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, adminEndpointBase+"config?zone=unknown.example", nil)
+	if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); err == nil {
+		t.Error("expected unknown zone to fail")
+	}
+}
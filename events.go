@@ -0,0 +1,49 @@
+package dnsregister
+
+import (
+	"errors"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyevents"
+)
+
+// recordsChangedEvent is emitted after a reconcile that changed records.
+const recordsChangedEvent = "dns_records_changed"
+
+// loadEventsApp looks up the events app for emitting events. Events are
+// optional: without a configured events app, emitting is a no-op.
+func (a *App) loadEventsApp(ctx caddy.Context) error {
+	app, err := ctx.AppIfConfigured("events")
+	if errors.Is(err, caddy.ErrNotConfigured) {
+		a.logger.Debug("events app not configured, not emitting events")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	a.events = app.(*caddyevents.App)
+	return nil
+}
+
+// emit emits an event through the events app, if one is configured.
+func (a *App) emit(eventName string, data map[string]any) {
+	if a.events == nil {
+		return
+	}
+	a.events.Emit(a.caddyCtx, eventName, data)
+}
+
+// emitRecordsChanged emits recordsChangedEvent for a reconcile result
+// with changes.
+func (a *App) emitRecordsChanged(result ReconcileResult) {
+	if len(result.Created) == 0 && len(result.Updated) == 0 && len(result.Deleted) == 0 {
+		return
+	}
+	a.emit(recordsChangedEvent, map[string]any{
+		"zone":    result.Zone,
+		"created": result.Created,
+		"updated": result.Updated,
+		"deleted": result.Deleted,
+		"errors":  result.Errors,
+	})
+}
@@ -0,0 +1,26 @@
+package dnsregister
+
+import (
+	"context"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+)
+
+func TestEventsWithoutEventsApp(t *testing.T) {
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+	defer cancel()
+
+	app := newTestApp(t, &fakeProvider{}, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	if err := app.loadEventsApp(ctx); err != nil {
+		t.Fatalf("expected a missing events app to be tolerated, got %v", err)
+	}
+	if app.events != nil {
+		t.Fatal("expected no events app")
+	}
+
+	// Reconciles with changes emit nothing, without failing
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+}
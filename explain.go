@@ -0,0 +1,165 @@
+package dnsregister
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// recordExplanation describes why a record set is in its current state
+// and what the next reconcile would do with it, as returned by the
+// explain endpoint.
+type recordExplanation struct {
+	Zone string `json:"zone"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	// Current lists the values of the set in the zone.
+	Current []string `json:"current,omitempty"`
+
+	// Markers lists the ownership markers at the name, of any owner.
+	Markers []string `json:"markers,omitempty"`
+
+	// Owned reports whether this instance owns the set.
+	Owned bool `json:"owned"`
+
+	// Configured reports whether the set is configured, before
+	// validity windows and patches are applied.
+	Configured bool `json:"configured"`
+
+	// Desired lists the values the set should have, if it is desired.
+	Desired []string `json:"desired,omitempty"`
+
+	// Action is the change the next reconcile would make: "create",
+	// "update", "delete" or "none".
+	Action string `json:"action"`
+
+	// Notes explain the action and anything that would hold it back.
+	Notes []string `json:"notes,omitempty"`
+}
+
+// explainRecord explains the state of the record set with the given
+// name and type in the domain's zone. It reads the zone but makes no
+// changes.
+func (a *App) explainRecord(domain *Domain, name, typ string) (*recordExplanation, error) {
+	name, err := recordName(name, domain.Zone)
+	if err != nil {
+		return nil, err
+	}
+	typ = strings.ToUpper(typ)
+	key := recordKey(&Record{Name: name, Type: typ})
+	ex := &recordExplanation{Zone: domain.Zone, Name: name, Type: typ, Action: "none"}
+
+	if err := a.ensureProvider(domain); err != nil {
+		return nil, err
+	}
+	getter, ok := domain.provider.(libdns.RecordGetter)
+	if !ok {
+		return nil, fmt.Errorf("provider does not implement RecordGetter")
+	}
+	ctx, cancel := a.readContext(domain)
+	existing, err := a.cache.getRecords(ctx, domain, getter)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("getting existing records: %w", err)
+	}
+	existing = a.normalizeRecordTypes(domain, encodeRecordNames(domain.Zone, existing))
+
+	for _, rec := range existing {
+		rr := rec.RR()
+		switch {
+		case rr.Name == name && rr.Type == typ:
+			ex.Current = append(ex.Current, a.extractValue(rec))
+		case rr.Name == a.markerName(name, typ) && rr.Type == a.markerType():
+			ex.Markers = append(ex.Markers, strings.Trim(rr.Data, "\""))
+		}
+	}
+
+	owned := a.parseOwnedRecords(domain.Zone, existing)
+	if a.isMarkerless(typ) {
+		tracked, err := a.loadState(domain.Zone)
+		if err != nil {
+			return nil, err
+		}
+		owned = a.trackedRecords(existing, tracked)
+	}
+	ownedRecs, isOwned := owned[key]
+	ex.Owned = isOwned
+
+	for _, rec := range domain.Records {
+		if recordKey(rec) == key {
+			ex.Configured = true
+		}
+	}
+
+	desired, failed := a.desiredRecords(domain)
+	desiredRecs, isDesired := desired[key]
+	for _, rec := range desiredRecs {
+		ex.Desired = append(ex.Desired, rec.Value)
+	}
+
+	switch {
+	case failed[key] != nil:
+		ex.Notes = append(ex.Notes, fmt.Sprintf("values could not be resolved, so the set is left as it is: %v", failed[key]))
+		return ex, nil
+	case ex.Configured && !isDesired:
+		ex.Notes = append(ex.Notes, "configured but not desired: outside its validity window or removed by a patch")
+	case !ex.Configured && isDesired:
+		ex.Notes = append(ex.Notes, "added by a patch")
+	}
+
+	if isDesired {
+		if cut, ok := a.crossZoneRecords(domain, desired, existing)[key]; ok {
+			if a.ZoneBoundary == zoneBoundarySkip {
+				ex.Notes = append(ex.Notes, fmt.Sprintf("below the zone cut at %s, so it is skipped", cut))
+				return ex, nil
+			}
+			ex.Notes = append(ex.Notes, fmt.Sprintf("below the zone cut at %s; it may be published in the wrong zone", cut))
+		}
+	}
+
+	switch {
+	case isOwned && !isDesired:
+		ex.Action = "delete"
+		ex.Notes = append(ex.Notes, "owned but not desired")
+	case !isOwned && isDesired:
+		if owner, ok := a.higherPriorityNames(existing)[name]; ok {
+			ex.Notes = append(ex.Notes, fmt.Sprintf("left to %s, which owns the name with a higher priority", owner))
+			return ex, nil
+		}
+		ex.Action = "create"
+		if len(ex.Current) > 0 {
+			ex.Notes = append(ex.Notes, "exists in the zone without this instance's marker and would be replaced")
+		}
+	case isOwned && isDesired:
+		if recordSetChanged(ownedRecs, desiredRecs) {
+			ex.Action = "update"
+			ex.Notes = append(ex.Notes, "current values or TTLs differ from the desired ones")
+		} else {
+			ex.Notes = append(ex.Notes, "in sync")
+		}
+	case a.authoritativeRecords(domain, existing)[key] != nil:
+		ex.Action = "delete"
+		ex.Notes = append(ex.Notes, "unmarked and not desired under an authoritative prefix")
+	default:
+		ex.Notes = append(ex.Notes, "neither owned nor desired, so not managed")
+	}
+
+	if ex.Action != "none" {
+		if until, frozen := a.frozen(); frozen {
+			ex.Notes = append(ex.Notes, fmt.Sprintf("held back by a change freeze until %s", until.Format(time.RFC3339)))
+		}
+		if a.paused != nil && a.paused.Load() {
+			ex.Notes = append(ex.Notes, "held back while reconciliation is paused")
+		}
+		if !domain.managed() {
+			ex.Notes = append(ex.Notes, fmt.Sprintf("held back while the manage_when condition %q is false", domain.ManageWhen))
+		}
+		if a.PlanDir != "" {
+			ex.Notes = append(ex.Notes, "plan mode: the change is written to a plan file instead of applied")
+		}
+	}
+	return ex, nil
+}
@@ -0,0 +1,69 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+func TestAdminExplain(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.2"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "api", Type: "A", Data: "192.0.2.3"},
+		libdns.RR{Name: "other", Type: "A", Data: "192.0.2.4"},
+	}}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.9"},
+		&Record{Name: "api", Type: "A", Value: "192.0.2.3"},
+	)
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	explain := func(name, typ string) recordExplanation {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"explain?zone=example.com&name="+name+"&type="+typ, nil)
+		if err := api.handleAPIEndpoints(rec, req); err != nil {
+			t.Fatalf("GET explain failed: %v", err)
+		}
+		var ex recordExplanation
+		if err := json.Unmarshal(rec.Body.Bytes(), &ex); err != nil {
+			t.Fatalf("decoding explanation: %v", err)
+		}
+		return ex
+	}
+
+	tests := []struct {
+		name, typ string
+		owned     bool
+		action    string
+	}{
+		{"www", "A", true, "update"},
+		{"old", "a", true, "delete"},
+		{"api", "A", false, "create"},
+		{"other", "A", false, "none"},
+	}
+	for _, tt := range tests {
+		ex := explain(tt.name, tt.typ)
+		if ex.Owned != tt.owned || ex.Action != tt.action {
+			t.Errorf("%s %s: expected owned=%v action=%s, got %+v", tt.name, tt.typ, tt.owned, tt.action, ex)
+		}
+	}
+
+	ex := explain("www", "A")
+	if len(ex.Current) != 1 || ex.Current[0] != "192.0.2.1" || len(ex.Desired) != 1 || ex.Desired[0] != "192.0.2.9" {
+		t.Errorf("expected current and desired values, got %+v", ex)
+	}
+	if len(ex.Markers) != 1 {
+		t.Errorf("expected the marker, got %v", ex.Markers)
+	}
+	if len(provider.records) != 6 {
+		t.Errorf("expected explain to make no changes, got %v", provider.records)
+	}
+}
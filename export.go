@@ -0,0 +1,120 @@
+package dnsregister
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// exportFormats are the formats the export endpoint renders owned
+// records in.
+var exportFormats = []string{"json", "bind"}
+
+// ownedRecords returns the record sets this instance owns in the
+// domain's zone, with their current values and TTLs, sorted by name and
+// type. It reads the zone but makes no changes.
+func (a *App) ownedRecords(domain *Domain) ([]*Record, error) {
+	if err := a.ensureProvider(domain); err != nil {
+		return nil, err
+	}
+	getter, ok := domain.provider.(libdns.RecordGetter)
+	if !ok {
+		return nil, fmt.Errorf("provider does not implement RecordGetter")
+	}
+	ctx, cancel := a.readContext(domain)
+	existing, err := a.cache.getRecords(ctx, domain, getter)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("getting existing records: %w", err)
+	}
+	existing = a.normalizeRecordTypes(domain, encodeRecordNames(domain.Zone, existing))
+	existing, _ = dedupeRecords(existing)
+
+	owned := a.parseOwnedRecords(domain.Zone, existing)
+	if len(a.MarkerlessTypes) > 0 {
+		tracked, err := a.loadState(domain.Zone)
+		if err != nil {
+			return nil, err
+		}
+		for key, recs := range a.trackedRecords(existing, tracked) {
+			owned[key] = recs
+		}
+	}
+
+	keys := make([]string, 0, len(owned))
+	for key := range owned {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	records := make([]*Record, 0, len(owned))
+	for _, key := range keys {
+		records = append(records, owned[key]...)
+	}
+	return records, nil
+}
+
+// renderZoneFile renders records of zone as a BIND zone file, with
+// $ORIGIN and $TTL headers. Records without a TTL, as reported by
+// providers that don't report TTLs, take the $TTL of defaultTTL
+// seconds.
+func renderZoneFile(zone string, defaultTTL int, records []*Record) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "$ORIGIN %s.\n", strings.TrimSuffix(zone, "."))
+	fmt.Fprintf(&b, "$TTL %d\n", defaultTTL)
+	for _, rec := range records {
+		b.WriteString(rec.Name)
+		if rec.TTL > 0 {
+			fmt.Fprintf(&b, "\t%d", rec.TTL)
+		}
+		fmt.Fprintf(&b, "\tIN\t%s\t%s\n", rec.Type, zoneFileData(rec.Type, rec.Value))
+	}
+	return b.String()
+}
+
+// zoneFileData renders a record value as zone file data. TXT content is
+// quoted, in strings of at most 255 bytes, and hostnames are made
+// fully qualified: values hold them in absolute form, with or without
+// the trailing dot, which a zone file would read as relative to
+// $ORIGIN.
+func zoneFileData(typ, value string) string {
+	switch typ {
+	case "TXT", "SPF":
+		var quoted []string
+		for len(value) > 255 {
+			quoted = append(quoted, quoteZoneString(value[:255]))
+			value = value[255:]
+		}
+		return strings.Join(append(quoted, quoteZoneString(value)), " ")
+	case "CNAME", "NS", "PTR", "DNAME":
+		return qualifyHostname(value)
+	case "MX", "SRV":
+		// The target is the last field
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			return value
+		}
+		fields[len(fields)-1] = qualifyHostname(fields[len(fields)-1])
+		return strings.Join(fields, " ")
+	default:
+		return value
+	}
+}
+
+// quoteZoneString quotes s as a zone file character string.
+func quoteZoneString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// qualifyHostname adds the trailing dot to an absolute hostname that
+// lacks it. The root, ".", is left as it is.
+func qualifyHostname(host string) string {
+	if strings.HasSuffix(host, ".") {
+		return host
+	}
+	return host + "."
+}
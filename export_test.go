@@ -0,0 +1,78 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+func TestRenderZoneFile(t *testing.T) {
+	got := renderZoneFile("example.com", 300, []*Record{
+		{Name: "@", Type: "MX", Value: "10 mx.example.com", TTL: 3600},
+		{Name: "@", Type: "TXT", Value: `v=spf1 include:"x" -all`, TTL: 300},
+		{Name: "long", Type: "TXT", Value: strings.Repeat("a", 300)},
+		{Name: "www", Type: "A", Value: "192.0.2.1", TTL: 60},
+		{Name: "www", Type: "AAAA", Value: "2001:db8::1", TTL: 60},
+		{Name: "alias", Type: "CNAME", Value: "lb.example.net.", TTL: 300},
+		{Name: "_sip._tcp", Type: "SRV", Value: "10 5 5060 sip.example.com", TTL: 300},
+	})
+	want := "$ORIGIN example.com.\n" +
+		"$TTL 300\n" +
+		"@\t3600\tIN\tMX\t10 mx.example.com.\n" +
+		"@\t300\tIN\tTXT\t\"v=spf1 include:\\\"x\\\" -all\"\n" +
+		"long\tIN\tTXT\t\"" + strings.Repeat("a", 255) + "\" \"" + strings.Repeat("a", 45) + "\"\n" +
+		"www\t60\tIN\tA\t192.0.2.1\n" +
+		"www\t60\tIN\tAAAA\t2001:db8::1\n" +
+		"alias\t300\tIN\tCNAME\tlb.example.net.\n" +
+		"_sip._tcp\t300\tIN\tSRV\t10 5 5060 sip.example.com.\n"
+	if got != want {
+		t.Errorf("unexpected zone file:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestAdminExport(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: 60 * time.Second},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register", TTL: 300 * time.Second},
+		libdns.RR{Name: "manual", Type: "A", Data: "192.0.2.20", TTL: 300 * time.Second},
+	}}
+	app := newTestApp(t, provider)
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	get := func(target string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, adminEndpointBase+target, nil)
+		if err := api.handleAPIEndpoints(rec, req); err != nil {
+			t.Fatalf("GET %s failed: %v", target, err)
+		}
+		return rec
+	}
+
+	var records []*Record
+	if err := json.Unmarshal(get("export?zone=example.com").Body.Bytes(), &records); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(records) != 1 || records[0].Name != "www" || records[0].Value != "192.0.2.1" || records[0].TTL != 60 {
+		t.Errorf("expected only the owned www record, got %+v", records)
+	}
+
+	rec := get("export?zone=example.com&format=bind")
+	if ct := rec.Header().Get("Content-Type"); ct != "text/dns" {
+		t.Errorf("expected a text/dns response, got %q", ct)
+	}
+	if got, want := rec.Body.String(), "$ORIGIN example.com.\n$TTL 300\nwww\t60\tIN\tA\t192.0.2.1\n"; got != want {
+		t.Errorf("expected zone file %q, got %q", want, got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"export?zone=example.com&format=yaml", nil)
+	if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); err == nil {
+		t.Error("expected an unsupported format to be rejected")
+	}
+}
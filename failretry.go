@@ -0,0 +1,105 @@
+package dnsregister
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// failedRetryAttempts is the number of times record sets that failed to
+// sync are retried before waiting for the next full reconcile.
+const failedRetryAttempts = 3
+
+// defaultFailedRetryDelay is the delay before record sets that failed
+// to sync are retried.
+const defaultFailedRetryDelay = 10 * time.Second
+
+// failedRetryDelay is the delay before record sets that failed to sync
+// are retried.
+var failedRetryDelay = defaultFailedRetryDelay
+
+// failedRecords tracks, per zone, the record sets that failed to sync in
+// the last reconcile and how often they have been retried since.
+type failedRecords struct {
+	mu    sync.Mutex
+	zones map[string]*failedZone
+}
+
+type failedZone struct {
+	keys      map[string]bool
+	attempts  int
+	scheduled bool
+}
+
+// retryFailed records the keys that failed in a reconcile of domain and
+// schedules a retry of just those keys if attempts remain. A full
+// reconcile resets the attempts; keys that succeeded are cleared.
+func (a *App) retryFailed(domain *Domain, keys []string, retry bool) {
+	if a.failures == nil {
+		return
+	}
+	a.failures.mu.Lock()
+	defer a.failures.mu.Unlock()
+
+	zone := a.failures.zones[domain.Zone]
+	if zone == nil {
+		zone = new(failedZone)
+		a.failures.zones[domain.Zone] = zone
+	}
+	zone.keys = make(map[string]bool)
+	for _, key := range keys {
+		zone.keys[key] = true
+	}
+	if retry {
+		zone.attempts++
+	} else {
+		zone.attempts = 0
+	}
+
+	if len(zone.keys) == 0 || zone.attempts >= failedRetryAttempts || zone.scheduled {
+		return
+	}
+	zone.scheduled = true
+	time.AfterFunc(failedRetryDelay, func() { a.runFailedRetry(domain) })
+
+	a.logger.Info("scheduled retry of failed records",
+		zap.String("zone", domain.Zone),
+		zap.Strings("records", keys),
+		zap.Int("attempt", zone.attempts+1),
+		zap.Duration("delay", failedRetryDelay))
+}
+
+// runFailedRetry reconciles the record sets of domain that failed in
+// the last reconcile. It does nothing if the App has been stopped or
+// reconciliation is paused.
+func (a *App) runFailedRetry(domain *Domain) {
+	a.failures.mu.Lock()
+	zone := a.failures.zones[domain.Zone]
+	zone.scheduled = false
+	keys := zone.keys
+	a.failures.mu.Unlock()
+
+	if len(keys) == 0 || a.ctx.Err() != nil {
+		return
+	}
+	if a.paused != nil && a.paused.Load() {
+		return
+	}
+	if err := a.reconcileRecords(a.ctx, domain, keys); err != nil {
+		a.logger.Error("failed to retry failed records",
+			zap.String("zone", domain.Zone),
+			zap.Error(err))
+	}
+}
+
+// filterKeys returns the keys that are in only.
+func filterKeys(keys []string, only map[string]bool) []string {
+	var filtered []string
+	for _, key := range keys {
+		if only[key] {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,100 @@
+package dnsregister
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeFlakyProvider is a fakeProvider whose first sets that include a
+// given name fail.
+type fakeFlakyProvider struct {
+	fakeProvider
+	failName string
+	failures int
+
+	setMu sync.Mutex
+	sets  map[string]int
+}
+
+func (p *fakeFlakyProvider) SetRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	p.setMu.Lock()
+	fail := false
+	for _, rec := range recs {
+		name := rec.RR().Name
+		if name == p.failName && !fail {
+			p.sets[name]++
+			fail = p.sets[name] <= p.failures
+		}
+		if name == "www" {
+			p.sets[name]++
+		}
+	}
+	p.setMu.Unlock()
+
+	if fail {
+		return nil, errors.New("transient error")
+	}
+	return p.fakeProvider.SetRecords(ctx, zone, recs)
+}
+
+func (p *fakeFlakyProvider) setCount(name string) int {
+	p.setMu.Lock()
+	defer p.setMu.Unlock()
+	return p.sets[name]
+}
+
+func TestReconcileRetriesFailedRecords(t *testing.T) {
+	failedRetryDelay = 10 * time.Millisecond
+	t.Cleanup(func() { failedRetryDelay = defaultFailedRetryDelay })
+
+	// The batched write and the per-set fallback both fail
+	provider := &fakeFlakyProvider{failName: "api", failures: 2, sets: make(map[string]int)}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "api", Type: "A", Value: "192.0.2.2"},
+	)
+	app.failures = &failedRecords{zones: make(map[string]*failedZone)}
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.has("api", "A") {
+		t.Fatal("expected first create of api to fail")
+	}
+	wwwSets := provider.setCount("www")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !provider.has("api", "A") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !provider.has("api", "A") {
+		t.Fatal("expected failed record to be retried")
+	}
+	if got := provider.setCount("www"); got != wwwSets {
+		t.Errorf("expected only the failed record to be retried, www was set %d more times", got-wwwSets)
+	}
+}
+
+func TestReconcileRetriesFailedRecordsBounded(t *testing.T) {
+	failedRetryDelay = time.Millisecond
+	t.Cleanup(func() { failedRetryDelay = defaultFailedRetryDelay })
+
+	provider := &fakeFlakyProvider{failName: "api", failures: 100, sets: make(map[string]int)}
+	app := newTestApp(t, provider, &Record{Name: "api", Type: "A", Value: "192.0.2.2"})
+	app.failures = &failedRecords{zones: make(map[string]*failedZone)}
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	if got := len(app.history.get("example.com")); got != 1+failedRetryAttempts {
+		t.Errorf("expected 1 reconcile plus %d retries, got %d", failedRetryAttempts, got)
+	}
+}
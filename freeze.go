@@ -0,0 +1,30 @@
+package dnsregister
+
+import (
+	"sync"
+	"time"
+)
+
+// freezeWindow holds the end of the current change freeze, if any.
+type freezeWindow struct {
+	mu    sync.RWMutex
+	until time.Time
+}
+
+// frozen reports whether a change freeze is in effect, and until when.
+func (a *App) frozen() (time.Time, bool) {
+	if a.freeze == nil {
+		return time.Time{}, false
+	}
+	a.freeze.mu.RLock()
+	defer a.freeze.mu.RUnlock()
+	return a.freeze.until, time.Now().Before(a.freeze.until)
+}
+
+// setFreeze freezes changes until the given time. A zero time clears
+// the freeze.
+func (a *App) setFreeze(until time.Time) {
+	a.freeze.mu.Lock()
+	defer a.freeze.mu.Unlock()
+	a.freeze.until = until
+}
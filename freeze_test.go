@@ -0,0 +1,38 @@
+package dnsregister
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconcileFreeze(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+	app.freeze = new(freezeWindow)
+
+	app.setFreeze(time.Now().Add(time.Hour))
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if len(provider.records) != 0 {
+		t.Fatalf("expected no changes during freeze, got %d records", len(provider.records))
+	}
+
+	history := app.history.get("example.com")
+	if len(history) != 1 || !history[0].Frozen {
+		t.Fatalf("expected a frozen result, got %+v", history)
+	}
+	if len(history[0].Pending) != 1 || history[0].Pending[0] != "create www:A" {
+		t.Errorf("expected pending create of www:A, got %v", history[0].Pending)
+	}
+
+	// An expired freeze no longer blocks changes
+	app.setFreeze(time.Now().Add(-time.Minute))
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !provider.has("www", "A") {
+		t.Error("expected www A to be created after the freeze")
+	}
+}
@@ -0,0 +1,77 @@
+package dnsregister
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// glueSets returns, for each NS set of sets below the zone apex, the
+// keys of the A and AAAA sets of sets that are glue for it: the
+// addresses of its nameservers within the delegated subzone, which
+// resolvers can only find in the parent zone. It also returns the
+// in-bailiwick nameservers that have no glue set, as "<ns key> <host>".
+func glueSets(zone string, sets map[string][]*Record) (glue map[string][]string, missing []string) {
+	glue = make(map[string][]string)
+	for key, recs := range sets {
+		cut := recs[0].Name
+		if recs[0].Type != "NS" || cut == "@" {
+			continue
+		}
+		for _, ns := range recs {
+			host, ok := inBailiwick(ns.Value, cut, zone)
+			if !ok {
+				continue
+			}
+			found := false
+			for _, typ := range []string{"A", "AAAA"} {
+				glueKey := host + ":" + typ
+				if _, ok := sets[glueKey]; !ok {
+					continue
+				}
+				found = true
+				if !slices.Contains(glue[key], glueKey) {
+					glue[key] = append(glue[key], glueKey)
+				}
+			}
+			if !found {
+				missing = append(missing, key+" "+host)
+			}
+		}
+		sort.Strings(glue[key])
+	}
+	sort.Strings(missing)
+	return glue, missing
+}
+
+// inBailiwick returns the name, relative to zone, of the nameserver
+// host, and reports whether it lies at or below the delegation point
+// cut.
+func inBailiwick(host, cut, zone string) (string, bool) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	name, ok := strings.CutSuffix(host, "."+zone)
+	if !ok {
+		return "", false
+	}
+	return name, name == cut || strings.HasSuffix(name, "."+cut)
+}
+
+// warnMissingGlue logs a warning for each configured delegation to a
+// nameserver within the delegated subzone that has no configured glue
+// record, which makes the subzone unresolvable.
+func (a *App) warnMissingGlue(domain *Domain) {
+	sets := make(map[string][]*Record)
+	for _, rec := range domain.Records {
+		sets[recordKey(rec)] = append(sets[recordKey(rec)], rec)
+	}
+	_, missing := glueSets(domain.Zone, sets)
+	for _, m := range missing {
+		key, host, _ := strings.Cut(m, " ")
+		a.logger.Warn("delegation to in-bailiwick nameserver has no glue record",
+			zap.String("zone", domain.Zone),
+			zap.String("record", key),
+			zap.String("nameserver", host))
+	}
+}
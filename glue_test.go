@@ -0,0 +1,104 @@
+package dnsregister
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestGlueSets(t *testing.T) {
+	sets := map[string][]*Record{
+		"dev:NS": {
+			{Name: "dev", Type: "NS", Value: "ns1.dev.example.com."},
+			{Name: "dev", Type: "NS", Value: "ns2.dev.example.com."},
+			{Name: "dev", Type: "NS", Value: "ns.example.net."},
+		},
+		"@:NS":         {{Name: "@", Type: "NS", Value: "ns.example.com."}},
+		"ns1.dev:A":    {{Name: "ns1.dev", Type: "A", Value: "192.0.2.53"}},
+		"ns1.dev:AAAA": {{Name: "ns1.dev", Type: "AAAA", Value: "2001:db8::53"}},
+		"ns:A":         {{Name: "ns", Type: "A", Value: "192.0.2.1"}},
+	}
+
+	glue, missing := glueSets("example.com", sets)
+	if got := strings.Join(glue["dev:NS"], ","); got != "ns1.dev:A,ns1.dev:AAAA" {
+		t.Errorf("expected glue ns1.dev:A,ns1.dev:AAAA for dev:NS, got %q", got)
+	}
+	if len(glue["@:NS"]) != 0 {
+		t.Errorf("expected no glue for the apex, got %v", glue["@:NS"])
+	}
+	if len(missing) != 1 || missing[0] != "dev:NS ns2.dev" {
+		t.Errorf("expected ns2.dev to be missing glue, got %v", missing)
+	}
+}
+
+func TestReconcileDelegationGlue(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		// An existing delegation makes dev a zone cut
+		libdns.RR{Name: "dev", Type: "NS", Data: "ns1.dev.example.com."},
+		libdns.RR{Name: "_cdr.dev", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider,
+		&Record{Name: "dev", Type: "NS", Value: "ns1.dev.example.com."},
+		&Record{Name: "ns1.dev", Type: "A", Value: "192.0.2.53"},
+		&Record{Name: "ns1.dev", Type: "AAAA", Value: "2001:db8::53"},
+		&Record{Name: "www.dev", Type: "A", Value: "192.0.2.80"})
+	app.ZoneBoundary = zoneBoundarySkip
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !provider.has("ns1.dev", "A") || !provider.has("ns1.dev", "AAAA") {
+		t.Errorf("expected glue below the zone cut to be created, got %v", provider.records)
+	}
+	if provider.has("www.dev", "A") {
+		t.Errorf("expected other records below the zone cut to be skipped, got %v", provider.records)
+	}
+}
+
+func TestReconcileGlueBeforeDelegation(t *testing.T) {
+	provider := &fakeCountingProvider{failName: "ns.dev"}
+	app := newTestApp(t, provider,
+		&Record{Name: "dev", Type: "NS", Value: "ns.dev.example.com."},
+		&Record{Name: "ns.dev", Type: "A", Value: "192.0.2.53"})
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.has("dev", "NS") {
+		t.Errorf("expected delegation not to be created without its glue, got %v", provider.records)
+	}
+	result := app.history.last("example.com")
+	found := false
+	for _, e := range result.Errors {
+		found = found || strings.Contains(e, "create dev:NS: not created, creating ns.dev:A it depends on failed")
+	}
+	if !found {
+		t.Errorf("expected delegation to be reported as not created, got %v", result.Errors)
+	}
+
+	// Once the glue can be written, both are created
+	provider.failName = ""
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !provider.has("ns.dev", "A") || !provider.has("dev", "NS") {
+		t.Errorf("expected glue and delegation to be created, got %v", provider.records)
+	}
+}
+
+func TestWarnMissingGlue(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	app := newTestApp(t, &fakeProvider{},
+		&Record{Name: "dev", Type: "NS", Value: "ns.dev.example.com."})
+	app.logger = zap.New(core)
+
+	app.warnMissingGlue(app.Domains[0])
+	entries := logs.FilterMessage("delegation to in-bailiwick nameserver has no glue record").All()
+	if len(entries) != 1 || entries[0].ContextMap()["nameserver"] != "ns.dev" {
+		t.Errorf("expected a missing glue warning for ns.dev, got %v", logs.All())
+	}
+}
@@ -0,0 +1,118 @@
+package dnsregister
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultHistorySize is the number of reconcile results kept per zone
+// when HistorySize is not set.
+const defaultHistorySize = 20
+
+// ReconcileResult describes the outcome of a single reconcile of a zone.
+type ReconcileResult struct {
+	Zone     string    `json:"zone"`
+	Time     time.Time `json:"time"`
+	Duration string    `json:"duration"`
+	Created  []string  `json:"created,omitempty"`
+	Updated  []string  `json:"updated,omitempty"`
+	Deleted  []string  `json:"deleted,omitempty"`
+	Released []string  `json:"released,omitempty"`
+	Errors   []string  `json:"errors,omitempty"`
+
+	// ZoneRecords is the number of records in the zone, owned or not,
+	// when the reconcile fetched them.
+	ZoneRecords int `json:"zone_records,omitempty"`
+
+	// Frozen is set when changes were not applied because of a change
+	// freeze. Pending then lists the changes that would have been made.
+	Frozen  bool     `json:"frozen,omitempty"`
+	Pending []string `json:"pending,omitempty"`
+
+	// Unchanged is set when the zone was not read because its desired
+	// records were unchanged since it was last verified in sync, less
+	// than verify_interval ago. ZoneRecords is then the count as of
+	// that verification.
+	Unchanged bool `json:"unchanged,omitempty"`
+
+	// failed lists the keys of record sets that failed to sync.
+	failed []string
+}
+
+// reconcileHistory keeps the most recent reconcile results per zone
+// in memory, bounded to size entries per zone.
+type reconcileHistory struct {
+	mu    sync.Mutex
+	size  int
+	zones map[string][]ReconcileResult
+}
+
+func newReconcileHistory(size int) *reconcileHistory {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+	return &reconcileHistory{
+		size:  size,
+		zones: make(map[string][]ReconcileResult),
+	}
+}
+
+// add records a result, evicting the oldest entry for the zone if full.
+func (h *reconcileHistory) add(result ReconcileResult) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries := append(h.zones[result.Zone], result)
+	if len(entries) > h.size {
+		entries = entries[len(entries)-h.size:]
+	}
+	h.zones[result.Zone] = entries
+}
+
+// get returns the results for a zone, oldest first. If zone is empty,
+// results for all zones are returned, also oldest first, with results
+// at the same time ordered by zone.
+func (h *reconcileHistory) get(zone string) []ReconcileResult {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if zone != "" {
+		return append([]ReconcileResult(nil), h.zones[zone]...)
+	}
+
+	var all []ReconcileResult
+	for _, entries := range h.zones {
+		all = append(all, entries...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if !all[i].Time.Equal(all[j].Time) {
+			return all[i].Time.Before(all[j].Time)
+		}
+		return all[i].Zone < all[j].Zone
+	})
+	return all
+}
+
+// last returns the most recent result for a zone, or nil if there is
+// none.
+func (h *reconcileHistory) last(zone string) *ReconcileResult {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries := h.zones[zone]
+	if len(entries) == 0 {
+		return nil
+	}
+	result := entries[len(entries)-1]
+	return &result
+}
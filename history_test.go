@@ -0,0 +1,51 @@
+package dnsregister
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReconcileHistory(t *testing.T) {
+	h := newReconcileHistory(2)
+
+	for i := 0; i < 3; i++ {
+		h.add(ReconcileResult{Zone: "example.com", Created: []string{fmt.Sprintf("r%d:A", i)}})
+	}
+	h.add(ReconcileResult{Zone: "example.org"})
+
+	got := h.get("example.com")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(got))
+	}
+	if got[0].Created[0] != "r1:A" || got[1].Created[0] != "r2:A" {
+		t.Errorf("expected oldest entry to be evicted, got %v, %v", got[0].Created, got[1].Created)
+	}
+
+	if all := h.get(""); len(all) != 3 {
+		t.Errorf("expected 3 results across zones, got %d", len(all))
+	}
+}
+
+func TestReconcileHistoryAllZonesOrdered(t *testing.T) {
+	h := newReconcileHistory(0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	h.add(ReconcileResult{Zone: "example.org", Time: start})
+	h.add(ReconcileResult{Zone: "example.com", Time: start.Add(time.Minute)})
+	h.add(ReconcileResult{Zone: "example.net", Time: start.Add(time.Minute)})
+	h.add(ReconcileResult{Zone: "example.org", Time: start.Add(2 * time.Minute)})
+
+	want := []string{"example.org", "example.com", "example.net", "example.org"}
+	for i := 0; i < 10; i++ {
+		all := h.get("")
+		if len(all) != len(want) {
+			t.Fatalf("expected %d results, got %d", len(want), len(all))
+		}
+		for j, result := range all {
+			if result.Zone != want[j] {
+				t.Fatalf("expected results ordered by time then zone %v, got %v at %d", want, result.Zone, j)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package dnsregister
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// defaultHTTPTimeout bounds outbound HTTP requests if http_timeout is
+// not set.
+const defaultHTTPTimeout = 30 * time.Second
+
+// newHTTPClient returns the client shared by all features that make
+// outbound HTTP requests. Requests are bounded by timeout, honor the
+// standard proxy environment variables, and, if caCertPath is set, also
+// trust the PEM certificates in that file, e.g. for internal endpoints.
+func newHTTPClient(timeout time.Duration, caCertPath string) (*http.Client, error) {
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if caCertPath != "" {
+		pem, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading ca_cert: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca_cert %s: no PEM certificates found", caCertPath)
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}, nil
+}
@@ -0,0 +1,50 @@
+package dnsregister
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientCACert(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+
+	// Without the server's CA the request fails verification
+	client, err := newHTTPClient(0, "")
+	if err != nil {
+		t.Fatalf("newHTTPClient failed: %v", err)
+	}
+	if client.Timeout != defaultHTTPTimeout {
+		t.Errorf("expected default timeout, got %v", client.Timeout)
+	}
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Error("expected request to untrusted server to fail")
+	}
+
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caCert, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, err = newHTTPClient(5*time.Second, caCert)
+	if err != nil {
+		t.Fatalf("newHTTPClient failed: %v", err)
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected request with ca_cert to succeed: %v", err)
+	}
+	resp.Body.Close()
+
+	if err := os.WriteFile(caCert, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newHTTPClient(0, caCert); err == nil {
+		t.Error("expected ca_cert without certificates to be rejected")
+	}
+}
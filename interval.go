@@ -0,0 +1,31 @@
+package dnsregister
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// reconcileLoop runs a reconcile cycle of all domains every
+// ReconcileInterval, counted from the end of the previous cycle, until
+// the App is stopped. Each cycle is skipped while reconciliation is
+// paused.
+func (a *App) reconcileLoop() {
+	interval := time.Duration(a.ReconcileInterval)
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		a.logger.Info("next periodic reconcile scheduled",
+			zap.Time("next_run", time.Now().Add(interval)),
+			zap.Duration("reconcile_interval", interval))
+
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-timer.C:
+		}
+		a.runCycle(a.Domains, "interval")
+		timer.Reset(interval)
+	}
+}
@@ -0,0 +1,50 @@
+package dnsregister
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/libdns/libdns"
+)
+
+func TestReconcileLoop(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.ReconcileInterval = caddy.Duration(10 * time.Millisecond)
+	app.ctx, app.cancel = context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		app.reconcileLoop()
+		close(done)
+	}()
+
+	// A record removed out of band is put back by the next cycle
+	deadline := time.Now().Add(5 * time.Second)
+	for !provider.has("www", "A") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	provider.mu.Lock()
+	provider.records = slices.DeleteFunc(provider.records, func(rec libdns.Record) bool {
+		return rec.RR().Name == "www"
+	})
+	provider.mu.Unlock()
+	for !provider.has("www", "A") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !provider.has("www", "A") {
+		t.Errorf("expected www to be restored by the loop, got %v", provider.records)
+	}
+
+	if err := app.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the loop to end when the app is stopped")
+	}
+}
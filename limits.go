@@ -0,0 +1,71 @@
+package dnsregister
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// projectedZoneSize returns the number of records the zone would hold
+// after applying plan, ownership markers included, and the keys of the
+// sets the plan creates or grows. Markers of deleted sets are counted
+// as kept, so the projection errs on the high side.
+func (a *App) projectedZoneSize(plan *reconcilePlan, existing []libdns.Record) (int, []string) {
+	markers := make(map[string]bool)
+	for _, rec := range existing {
+		if rr := rec.RR(); a.isMarkerRecord(rr) {
+			markers[rr.Name] = true
+		}
+	}
+
+	projected := len(existing)
+	var growing []string
+	for _, key := range plan.toCreate {
+		recs := plan.desired[key]
+		projected += len(recs)
+		if name := a.markerName(recs[0].Name, recs[0].Type); !a.isMarkerless(recs[0].Type) && !markers[name] {
+			markers[name] = true
+			projected++
+		}
+		growing = append(growing, key)
+	}
+	for _, key := range plan.toUpdate {
+		n := len(plan.desired[key]) - len(plan.owned[key])
+		projected += n
+		if n > 0 {
+			growing = append(growing, key)
+		}
+	}
+	for _, key := range plan.toDelete {
+		projected -= len(plan.owned[key])
+	}
+	return projected, growing
+}
+
+// enforceRecordLimit drops the creates and the updates adding records
+// from the plan if applying it would leave the zone with more records
+// than MaxRecordsPerZone, and reports them as an error. Other changes,
+// which don't add records, are still applied.
+func (a *App) enforceRecordLimit(domain *Domain, plan *reconcilePlan, existing []libdns.Record, result *ReconcileResult) {
+	if a.MaxRecordsPerZone <= 0 {
+		return
+	}
+	projected, growing := a.projectedZoneSize(plan, existing)
+	if projected <= a.MaxRecordsPerZone || len(growing) == 0 {
+		return
+	}
+
+	a.logger.Error("zone would exceed max_records_per_zone, not adding records",
+		zap.String("zone", domain.Zone),
+		zap.Int("projected", projected),
+		zap.Int("allowed", a.MaxRecordsPerZone),
+		zap.Strings("skipped", growing))
+	result.Errors = append(result.Errors, fmt.Sprintf("zone would have %d records, more than max_records_per_zone %d: not applying %v",
+		projected, a.MaxRecordsPerZone, growing))
+
+	skip := func(key string) bool { return slices.Contains(growing, key) }
+	plan.toCreate = slices.DeleteFunc(plan.toCreate, skip)
+	plan.toUpdate = slices.DeleteFunc(plan.toUpdate, skip)
+}
@@ -0,0 +1,47 @@
+package dnsregister
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestReconcileMaxRecordsPerZone(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.9"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "manual", Type: "A", Data: "192.0.2.20"},
+	}}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "www", Type: "AAAA", Value: "2001:db8::1"})
+	app.MaxRecordsPerZone = 4
+	app.history = newReconcileHistory(0)
+
+	// 3 records, minus old, plus www A, www AAAA and their marker is 5
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.has("www", "A") || provider.has("www", "AAAA") {
+		t.Errorf("expected creates to be skipped, got %v", provider.records)
+	}
+	if provider.has("old", "A") {
+		t.Errorf("expected deletes to be applied, got %v", provider.records)
+	}
+	errs := app.history.last("example.com").Errors
+	if len(errs) != 1 || !strings.Contains(errs[0], "zone would have 5 records, more than max_records_per_zone 4") {
+		t.Errorf("expected a record limit error, got %v", errs)
+	}
+
+	app.MaxRecordsPerZone = 5
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !provider.has("www", "A") || !provider.has("www", "AAAA") {
+		t.Errorf("expected creates within the limit to be applied, got %v", provider.records)
+	}
+	if errs := app.history.last("example.com").Errors; len(errs) != 0 {
+		t.Errorf("expected no errors within the limit, got %v", errs)
+	}
+}
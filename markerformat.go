@@ -0,0 +1,96 @@
+package dnsregister
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// markerPlaceholder matches a placeholder in a marker format.
+var markerPlaceholder = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// markerFields lists the placeholders a marker format may use.
+var markerFields = []string{"owner", "heritage", "priority"}
+
+// compileMarkerFormat returns the pattern matching marker data written
+// with format, capturing each placeholder's value as a named group.
+// The format must include the owner and heritage placeholders.
+func compileMarkerFormat(format string) (*regexp.Regexp, error) {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	seen := make(map[string]bool)
+	last := 0
+	for _, loc := range markerPlaceholder.FindAllStringSubmatchIndex(format, -1) {
+		field := format[loc[2]:loc[3]]
+		if !slices.Contains(markerFields, field) {
+			return nil, fmt.Errorf("unknown placeholder {%s}", field)
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("placeholder {%s} used more than once", field)
+		}
+		seen[field] = true
+		pattern.WriteString(regexp.QuoteMeta(format[last:loc[0]]))
+		pattern.WriteString("(?P<" + field + ">.*?)")
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(format[last:]))
+	pattern.WriteString("$")
+
+	if !seen["owner"] || !seen["heritage"] {
+		return nil, fmt.Errorf("must include {owner} and {heritage}")
+	}
+	return regexp.Compile(pattern.String())
+}
+
+// formatMarker returns marker data written with format.
+func formatMarker(format, owner string, priority int) string {
+	return markerPlaceholder.ReplaceAllStringFunc(format, func(placeholder string) string {
+		switch placeholder {
+		case "{owner}":
+			return owner
+		case "{heritage}":
+			return markerHeritage
+		case "{priority}":
+			return strconv.Itoa(priority)
+		}
+		return placeholder
+	})
+}
+
+// markerFieldsOf parses the fields of an ownership marker. Markers
+// written with the configured marker format are parsed by it; anything
+// else, including markers written before the format was changed, is
+// parsed as the default comma-separated key=value form. With the
+// external-dns registry format, external-dns registry entries are
+// parsed as markers of their external-dns owner.
+func (a *App) markerFieldsOf(data string) map[string]string {
+	if a.externalDNS() {
+		if fields, ok := externalDNSFields(data); ok {
+			return fields
+		}
+	}
+	if a.MarkerFormat == "" {
+		return parseMarker(data)
+	}
+	pattern := a.markerRE
+	if pattern == nil {
+		var err error
+		if pattern, err = compileMarkerFormat(a.MarkerFormat); err != nil {
+			return parseMarker(data)
+		}
+	}
+
+	match := pattern.FindStringSubmatch(strings.Trim(data, "\""))
+	if match == nil {
+		return parseMarker(data)
+	}
+	fields := make(map[string]string)
+	for i, name := range pattern.SubexpNames() {
+		if name != "" {
+			fields[name] = match[i]
+		}
+	}
+	return fields
+}
@@ -0,0 +1,54 @@
+package dnsregister
+
+import (
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestCompileMarkerFormat(t *testing.T) {
+	for _, format := range []string{
+		"owner={owner}",
+		"owner={owner};heritage={heritage};zone={zone}",
+		"{owner}{heritage}{owner}",
+	} {
+		if _, err := compileMarkerFormat(format); err == nil {
+			t.Errorf("expected format %q to be rejected", format)
+		}
+	}
+
+	pattern, err := compileMarkerFormat("v=cdr1 owner:{owner} by:{heritage} prio:{priority}")
+	if err != nil {
+		t.Fatalf("compileMarkerFormat failed: %v", err)
+	}
+	app := &App{MarkerFormat: "v=cdr1 owner:{owner} by:{heritage} prio:{priority}", markerRE: pattern}
+	fields := app.markerFieldsOf(`"v=cdr1 owner:edge-1 by:caddy-dns-register prio:5"`)
+	if fields["owner"] != "edge-1" || fields["heritage"] != markerHeritage || markerPriority(fields) != 5 {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
+
+func TestReconcileMarkerFormat(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		// Marked in the default format before the format was changed
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.2"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.MarkerFormat = "owner={owner};heritage={heritage}"
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !hasRecord(provider.records, "_cdr.www", "TXT", "owner=test-caddy;heritage=caddy-dns-register") {
+		t.Errorf("expected marker in the configured format, got %v", provider.records)
+	}
+	if hasRecord(provider.records, "old", "A", "192.0.2.2") {
+		t.Errorf("expected record marked in the default format to still be owned and deleted, got %v", provider.records)
+	}
+
+	owned := app.parseOwnedRecords("example.com", provider.records)
+	if _, ok := owned["www:A"]; !ok {
+		t.Errorf("expected www to be owned, got %v", owned)
+	}
+}
@@ -0,0 +1,109 @@
+package dnsregister
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// refreshMarkers rewrites this instance's ownership markers whose data
+// or TTL no longer match what it would write now, e.g. after a change
+// of marker_format or instance_priority, or a manual edit. Only markers
+// of names that keep desired records and weren't just created (which
+// rewrites their marker) are refreshed, and only on an actual mismatch.
+// Other owners' markers at the same name are kept.
+func (a *App) refreshMarkers(domain *Domain, existing []libdns.Record, plan *reconcilePlan, result *ReconcileResult) {
+	if _, frozen := a.frozen(); frozen {
+		return
+	}
+
+	// Marker names of the desired sets, mapped to the record name
+	desired := make(map[string]string)
+	for _, recs := range plan.desired {
+		desired[a.markerName(recs[0].Name, recs[0].Type)] = recs[0].Name
+	}
+	for _, key := range plan.toCreate {
+		recs := plan.desired[key]
+		delete(desired, a.markerName(recs[0].Name, recs[0].Type))
+	}
+
+	stale := make(map[string]libdns.RR)
+	others := make(map[string][]libdns.Record)
+	for _, rec := range existing {
+		rr := rec.RR()
+		if rr.Type != a.markerType() {
+			continue
+		}
+		if _, ok := desired[rr.Name]; !ok {
+			continue
+		}
+		if !a.isOwnMarker(rr.Data) {
+			others[rr.Name] = append(others[rr.Name], rec)
+			continue
+		}
+		if strings.Trim(rr.Data, "\"") != a.markerText() || markerTTLChanged(domain, rr.TTL) {
+			stale[rr.Name] = rr
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+	defer a.cache.invalidate(domain)
+
+	for marker, old := range stale {
+		name := desired[marker]
+		if err := a.rewriteMarker(domain, old, others[marker]); err != nil {
+			a.logger.Warn("failed to refresh ownership marker",
+				zap.String("zone", domain.Zone),
+				zap.String("name", name),
+				zap.Error(err))
+			result.Errors = append(result.Errors, fmt.Sprintf("refresh marker %s: %v", name, err))
+			continue
+		}
+		a.logger.Info("refreshed ownership marker",
+			zap.String("zone", domain.Zone),
+			zap.String("name", name))
+	}
+}
+
+// markerTTLChanged reports whether a marker's TTL as reported by the
+// provider differs from the one it was written with. Providers that
+// don't report TTLs, or raise them to the zone's configured minimum, are
+// not considered changed.
+func markerTTLChanged(domain *Domain, ttl time.Duration) bool {
+	if ttl == 0 {
+		return false
+	}
+	want := markerTTL
+	if minTTL := time.Duration(domain.MinTTL) * time.Second; minTTL > want {
+		want = minTTL
+	}
+	return ttl != markerTTL && ttl != want
+}
+
+// rewriteMarker replaces this instance's marker old with a current one,
+// keeping the other markers at its name.
+func (a *App) rewriteMarker(domain *Domain, old libdns.RR, others []libdns.Record) error {
+	marker := a.markerAt(old.Name)
+	if setter, ok := domain.provider.(libdns.RecordSetter); ok {
+		ctx, cancel := a.writeContext(domain)
+		defer cancel()
+		_, err := providerSetRecords(ctx, domain, setter, append(others, marker))
+		return err
+	}
+
+	deleter, ok := domain.provider.(libdns.RecordDeleter)
+	if !ok {
+		return fmt.Errorf("provider does not implement RecordDeleter")
+	}
+	if err := a.appendWithRetry(domain, domain.provider.(libdns.RecordAppender), []libdns.Record{marker}); err != nil {
+		return err
+	}
+	ctx, cancel := a.writeContext(domain)
+	defer cancel()
+	_, err := providerDeleteRecords(ctx, domain, deleter, []libdns.Record{old})
+	return err
+}
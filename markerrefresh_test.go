@@ -0,0 +1,46 @@
+package dnsregister
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestReconcileRefreshMarkers(t *testing.T) {
+	provider := &fakeCountingProvider{fakeProvider: fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		// Written before the priority was set, and with an edited TTL
+		libdns.RR{Name: "_cdr.www", Type: "TXT", TTL: time.Hour, Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=other,heritage=caddy-dns-register"},
+		libdns.RR{Name: "api", Type: "A", Data: "192.0.2.2"},
+		libdns.RR{Name: "_cdr.api", Type: "TXT", TTL: markerTTL, Data: "owner=test-caddy,heritage=caddy-dns-register,priority=5"},
+	}}}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "api", Type: "A", Value: "192.0.2.2"},
+	)
+	app.InstancePriority = 5
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !hasRecord(provider.records, "_cdr.www", "TXT", "owner=test-caddy,heritage=caddy-dns-register,priority=5") {
+		t.Errorf("expected stale marker to be rewritten, got %v", provider.records)
+	}
+	if hasRecord(provider.records, "_cdr.www", "TXT", "owner=test-caddy,heritage=caddy-dns-register") {
+		t.Errorf("expected stale marker to be replaced, got %v", provider.records)
+	}
+	if !hasRecord(provider.records, "_cdr.www", "TXT", "owner=other,heritage=caddy-dns-register") {
+		t.Errorf("expected other owner's marker to be kept, got %v", provider.records)
+	}
+
+	// Current markers aren't rewritten
+	provider.calls = 0
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.calls != 1 {
+		t.Errorf("expected only a get once markers are current, got %d calls", provider.calls)
+	}
+}
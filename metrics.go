@@ -0,0 +1,128 @@
+package dnsregister
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var dnsRegisterMetrics = struct {
+	once         sync.Once
+	recordInSync *prometheus.GaugeVec
+	zoneRecords  *prometheus.GaugeVec
+	workers      prometheus.Gauge
+	markerFails  *prometheus.CounterVec
+}{}
+
+// initMetrics creates the dns_register metrics and registers them with
+// registry. Registration is skipped if registry is nil.
+func initMetrics(registry *prometheus.Registry) {
+	dnsRegisterMetrics.once.Do(func() {
+		dnsRegisterMetrics.recordInSync = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dns_register_record_in_sync",
+			Help: "Whether a managed record set matched its config at the end of the last reconcile (1) or not (0).",
+		}, []string{"zone", "name", "type"})
+		dnsRegisterMetrics.zoneRecords = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dns_register_zone_records",
+			Help: "Number of records in the zone, owned or not, as of the last reconcile.",
+		}, []string{"zone"})
+		dnsRegisterMetrics.workers = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "dns_register_reconcile_workers_active",
+			Help: "Number of reconciles currently running.",
+		})
+		dnsRegisterMetrics.markerFails = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dns_register_marker_write_failures_total",
+			Help: "Number of record sets whose records were written but whose ownership marker failed to write.",
+		}, []string{"zone"})
+	})
+
+	if registry == nil {
+		return
+	}
+
+	// Each config load has a fresh registry, but the same app may be
+	// provisioned more than once against it
+	for _, collector := range []prometheus.Collector{
+		dnsRegisterMetrics.recordInSync,
+		dnsRegisterMetrics.zoneRecords,
+		dnsRegisterMetrics.workers,
+		dnsRegisterMetrics.markerFails,
+	} {
+		if err := registry.Register(collector); err != nil &&
+			!errors.Is(err, prometheus.AlreadyRegisteredError{
+				ExistingCollector: collector,
+				NewCollector:      collector,
+			}) {
+			panic(err)
+		}
+	}
+}
+
+// updateZoneRecordsMetric sets the record count gauge of the domain's
+// zone.
+func updateZoneRecordsMetric(domain *Domain, count int) {
+	if dnsRegisterMetrics.zoneRecords == nil {
+		return
+	}
+	dnsRegisterMetrics.zoneRecords.WithLabelValues(domain.Zone).Set(float64(count))
+}
+
+// updateActiveWorkersMetric adds delta to the active reconcile workers
+// gauge.
+func updateActiveWorkersMetric(delta int) {
+	if dnsRegisterMetrics.workers == nil {
+		return
+	}
+	dnsRegisterMetrics.workers.Add(float64(delta))
+}
+
+// countMarkerWriteFailure counts a record set of the domain's zone
+// written without its ownership marker.
+func countMarkerWriteFailure(domain *Domain) {
+	if dnsRegisterMetrics.markerFails == nil {
+		return
+	}
+	dnsRegisterMetrics.markerFails.WithLabelValues(domain.Zone).Inc()
+}
+
+// updateRecordMetrics sets the in-sync gauge of every record set managed
+// in the domain's zone from the outcome of a reconcile, replacing the
+// gauges from the previous reconcile of the zone. A set is in sync if it
+// needed no change or its change was applied.
+func (a *App) updateRecordMetrics(domain *Domain, plan *reconcilePlan, failed map[string]error, result ReconcileResult) {
+	if a.DisableRecordMetrics || dnsRegisterMetrics.recordInSync == nil {
+		return
+	}
+	gauge := dnsRegisterMetrics.recordInSync
+	gauge.DeletePartialMatch(prometheus.Labels{"zone": domain.Zone})
+
+	changed := make(map[string]bool)
+	for _, key := range plan.toCreate {
+		changed[key] = true
+	}
+	for _, key := range plan.toUpdate {
+		changed[key] = true
+	}
+	for _, key := range result.Created {
+		delete(changed, key)
+	}
+	for _, key := range result.Updated {
+		delete(changed, key)
+	}
+
+	for key, recs := range plan.desired {
+		inSync := 1.0
+		if changed[key] {
+			inSync = 0
+		}
+		gauge.WithLabelValues(domain.Zone, recs[0].Name, recs[0].Type).Set(inSync)
+	}
+
+	// Sets whose values could not be resolved are not in sync
+	for _, rec := range domain.Records {
+		if _, ok := failed[recordKey(rec)]; ok {
+			gauge.WithLabelValues(domain.Zone, rec.Name, rec.Type).Set(0)
+		}
+	}
+}
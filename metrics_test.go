@@ -0,0 +1,102 @@
+package dnsregister
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestUpdateRecordMetrics(t *testing.T) {
+	initMetrics(prometheus.NewRegistry())
+	gauge := dnsRegisterMetrics.recordInSync
+
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "api", Type: "A", Value: "192.0.2.2"},
+	)
+	// Other tests reconcile example.com with the same global gauge
+	zone := "metrics.example"
+	app.Domains[0].Zone = zone
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	for _, name := range []string{"www", "api"} {
+		if got := gaugeValue(t, gauge.WithLabelValues(zone, name, "A")); got != 1 {
+			t.Errorf("expected %s to be in sync after create, got %v", name, got)
+		}
+	}
+
+	// A change that can't be applied leaves the record out of sync
+	app.Domains[0].Records = app.Domains[0].Records[:1]
+	app.Domains[0].Records[0].Value = "192.0.2.9"
+	app.freeze = &freezeWindow{}
+	app.setFreeze(time.Now().Add(time.Hour))
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if got := gaugeValue(t, gauge.WithLabelValues(zone, "www", "A")); got != 0 {
+		t.Errorf("expected www to be out of sync during freeze, got %v", got)
+	}
+	if gauge.DeleteLabelValues(zone, "api", "A") {
+		t.Error("expected gauge of removed record to be dropped")
+	}
+
+	// Per-record metrics can be disabled
+	gauge.DeletePartialMatch(prometheus.Labels{"zone": zone})
+	app.DisableRecordMetrics = true
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if n := gauge.DeletePartialMatch(prometheus.Labels{"zone": zone}); n != 0 {
+		t.Errorf("expected no per-record metrics when disabled, got %d series", n)
+	}
+}
+
+func TestZoneRecordsMetric(t *testing.T) {
+	initMetrics(prometheus.NewRegistry())
+
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "mail", Type: "MX", Data: "10 mx.example.net."},
+		libdns.RR{Name: "other", Type: "A", Data: "192.0.2.7"},
+	}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	zone := "zone-records.example"
+	app.Domains[0].Zone = zone
+
+	// Unowned records count too
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if got := gaugeValue(t, dnsRegisterMetrics.zoneRecords.WithLabelValues(zone)); got != 2 {
+		t.Errorf("expected 2 zone records, got %v", got)
+	}
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if got := gaugeValue(t, dnsRegisterMetrics.zoneRecords.WithLabelValues(zone)); got != 4 {
+		t.Errorf("expected 4 zone records after create, got %v", got)
+	}
+}
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := g.Write(&m); err != nil {
+		t.Fatalf("reading gauge: %v", err)
+	}
+	return m.GetGauge().GetValue()
+}
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatalf("reading counter: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
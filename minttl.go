@@ -0,0 +1,74 @@
+package dnsregister
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// MinTTLProvider is implemented by DNS providers that enforce a minimum
+// TTL for a zone. Records below it are reported at provision time
+// rather than failing with a provider error on apply.
+type MinTTLProvider interface {
+	MinTTL(ctx context.Context, zone string) (time.Duration, error)
+}
+
+// minTTL returns the minimum TTL in seconds for the domain's zone: the
+// configured min_ttl if set, otherwise the provider's, or 0 if neither
+// is known.
+func (a *App) minTTL(domain *Domain) int {
+	if domain.MinTTL > 0 {
+		return domain.MinTTL
+	}
+	p, ok := domain.provider.(MinTTLProvider)
+	if !ok {
+		return 0
+	}
+	ctx, cancel := a.readContext(domain)
+	ttl, err := p.MinTTL(ctx, domain.fqdn())
+	cancel()
+	if err != nil {
+		a.logger.Warn("failed to get provider minimum TTL",
+			zap.String("zone", domain.Zone),
+			zap.Error(err))
+		return 0
+	}
+	return int(ttl.Seconds())
+}
+
+// checkMinTTL warns about records whose TTL is below the zone's minimum.
+// If the domain has ClampTTL set, their TTL is raised to the minimum.
+// Records without a TTL are left to the zone default.
+func (a *App) checkMinTTL(domain *Domain) {
+	minTTL := a.minTTL(domain)
+	if minTTL <= 0 {
+		return
+	}
+
+	var below []string
+	for _, rec := range domain.Records {
+		if rec.TTL > 0 && rec.TTL < minTTL {
+			below = append(below, fmt.Sprintf("%s (%d)", recordKey(rec), rec.TTL))
+			if domain.ClampTTL {
+				rec.TTL = minTTL
+			}
+		}
+	}
+	if len(below) == 0 {
+		return
+	}
+
+	if domain.ClampTTL {
+		a.logger.Warn("raised record TTLs below the zone minimum",
+			zap.String("zone", domain.Zone),
+			zap.Int("min_ttl", minTTL),
+			zap.Strings("records", below))
+		return
+	}
+	a.logger.Warn("record TTLs are below the zone minimum and may be rejected by the provider",
+		zap.String("zone", domain.Zone),
+		zap.Int("min_ttl", minTTL),
+		zap.Strings("records", below))
+}
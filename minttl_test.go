@@ -0,0 +1,66 @@
+package dnsregister
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeMinTTLProvider is a fakeProvider that enforces a minimum TTL.
+type fakeMinTTLProvider struct {
+	fakeProvider
+	min time.Duration
+}
+
+func (p *fakeMinTTLProvider) MinTTL(_ context.Context, _ string) (time.Duration, error) {
+	return p.min, nil
+}
+
+func TestCheckMinTTL(t *testing.T) {
+	newApp := func(provider any) (*App, *observer.ObservedLogs) {
+		core, logs := observer.New(zap.WarnLevel)
+		app := newTestApp(t, &fakeProvider{},
+			&Record{Name: "www", Type: "A", Value: "192.0.2.1", TTL: 30},
+			&Record{Name: "api", Type: "A", Value: "192.0.2.2", TTL: 3600},
+			&Record{Name: "mail", Type: "A", Value: "192.0.2.3"},
+		)
+		app.logger = zap.New(core)
+		app.Domains[0].provider = provider
+		return app, logs
+	}
+
+	// Advisory by default
+	app, logs := newApp(&fakeMinTTLProvider{min: time.Minute})
+	app.checkMinTTL(app.Domains[0])
+	if logs.Len() != 1 {
+		t.Fatalf("expected one warning, got %d", logs.Len())
+	}
+	if records := logs.All()[0].ContextMap()["records"]; len(records.([]any)) != 1 {
+		t.Errorf("expected only www to be reported, got %v", records)
+	}
+	if ttl := app.Domains[0].Records[0].TTL; ttl != 30 {
+		t.Errorf("expected TTL to be left as configured, got %d", ttl)
+	}
+
+	// Clamped when enabled; configured minimum overrides the provider's
+	app, _ = newApp(&fakeMinTTLProvider{min: time.Minute})
+	app.Domains[0].MinTTL = 120
+	app.Domains[0].ClampTTL = true
+	app.checkMinTTL(app.Domains[0])
+	for _, rec := range app.Domains[0].Records {
+		want := map[string]int{"www": 120, "api": 3600, "mail": 0}[rec.Name]
+		if rec.TTL != want {
+			t.Errorf("%s: expected TTL %d, got %d", rec.Name, want, rec.TTL)
+		}
+	}
+
+	// Nothing is known about the minimum
+	app, logs = newApp(&fakeProvider{})
+	app.checkMinTTL(app.Domains[0])
+	if logs.Len() != 0 {
+		t.Errorf("expected no warnings without a minimum, got %d", logs.Len())
+	}
+}
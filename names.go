@@ -0,0 +1,203 @@
+package dnsregister
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/libdns/libdns"
+	"golang.org/x/net/idna"
+)
+
+const (
+	maxLabelLength = 63
+	maxNameLength  = 253
+)
+
+// encodeName returns name with internationalized labels encoded as
+// punycode (IDNA2008), the form providers use. ASCII labels are left
+// as they are, so service labels such as "_dmarc" are not subject to
+// IDNA rules.
+func encodeName(name string) (string, error) {
+	if isASCII(name) {
+		return name, nil
+	}
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if isASCII(label) {
+			continue
+		}
+		encoded, err := idna.Lookup.ToASCII(label)
+		if err != nil {
+			return "", fmt.Errorf("invalid internationalized label %q: %v", label, err)
+		}
+		labels[i] = encoded
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// normalizeZone returns zone in the form it is kept in: lowercased,
+// without a trailing dot and with internationalized labels encoded
+// like encodeName. Zones may be configured with or without the dot.
+func normalizeZone(zone string) (string, error) {
+	zone = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(zone), "."))
+	if zone == "" {
+		return "", fmt.Errorf("zone is required")
+	}
+	return encodeName(zone)
+}
+
+// fqdn returns the domain's zone as a fully-qualified name with a
+// trailing dot, the form passed to providers.
+func (d *Domain) fqdn() string {
+	return strings.TrimSuffix(d.Zone, ".") + "."
+}
+
+// recordName returns a configured record name in the form it is
+// managed in: encoded like encodeName and relative to zone. A name
+// ending in a dot is absolute and must lie within zone; other names
+// are relative to it, except the zone's own name, which is taken to
+// mean the apex rather than a name below it.
+func recordName(name, zone string) (string, error) {
+	encoded, err := encodeName(name)
+	if err != nil {
+		return "", err
+	}
+	if isZoneName(encoded, zone) {
+		return "@", nil
+	}
+	relative := relativeName(encoded, zone)
+	if strings.HasSuffix(relative, ".") {
+		return "", fmt.Errorf("absolute name %s is not within zone %s", name, strings.TrimSuffix(zone, "."))
+	}
+	return relative, nil
+}
+
+// isZoneName reports whether a relative name is zone's own name, as
+// written by mistake for the apex.
+func isZoneName(name, zone string) bool {
+	return !strings.HasSuffix(name, ".") && strings.EqualFold(name, strings.TrimSuffix(zone, "."))
+}
+
+// relativeName returns name relative to zone if it is a fully-qualified
+// name (with a trailing dot) within the zone, and name unchanged
+// otherwise. The zone apex is returned as "@".
+func relativeName(name, zone string) string {
+	if !strings.HasSuffix(name, ".") {
+		return name
+	}
+	zone = strings.TrimSuffix(zone, ".")
+	fqdn := strings.TrimSuffix(name, ".")
+	if strings.EqualFold(fqdn, zone) {
+		return "@"
+	}
+	if len(fqdn) > len(zone) && strings.EqualFold(fqdn[len(fqdn)-len(zone):], zone) && fqdn[len(fqdn)-len(zone)-1] == '.' {
+		return fqdn[:len(fqdn)-len(zone)-1]
+	}
+	return name
+}
+
+// unescapeWildcard returns name with labels of the wildcard "*" escaped
+// in zone file form ("\052"), as some providers return them, unescaped.
+func unescapeWildcard(name string) string {
+	if !strings.Contains(name, `\052`) {
+		return name
+	}
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if label == `\052` {
+			labels[i] = "*"
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// encodeRecordNames returns records with fully-qualified names within
+// zone made relative to it, and any internationalized names encoded
+// like encodeName, so that provider records compare equal to configured
+// ones however the provider returns names. Wildcard labels escaped as
+// "\052" are unescaped to "*". Records with relative ASCII names
+// without escapes are returned unchanged.
+func encodeRecordNames(zone string, records []libdns.Record) []libdns.Record {
+	encoded := make([]libdns.Record, len(records))
+	for i, rec := range records {
+		encoded[i] = rec
+		rr := rec.RR()
+		name := unescapeWildcard(relativeName(rr.Name, zone))
+		if isASCII(name) && name == rr.Name {
+			continue
+		}
+		name, err := encodeName(name)
+		if err != nil {
+			continue
+		}
+		rr.Name = name
+		if parsed, err := rr.Parse(); err == nil {
+			encoded[i] = parsed
+		} else {
+			encoded[i] = rr
+		}
+	}
+	return encoded
+}
+
+// validateName checks that a record name relative to the zone consists
+// of legal DNS labels. Labels may contain letters, digits, hyphens and
+// underscores, and a "*" label is allowed first for wildcards. Names of
+// host records (A, AAAA, CNAME) may not have labels that start or end
+// with a hyphen. Names of SRV records must start with the
+// _<service>._<transport> labels.
+func validateName(name, recordType string) error {
+	if recordType == "SRV" && !isSRVName(name) {
+		return fmt.Errorf("SRV name %q must start with _<service>._<transport> labels", name)
+	}
+	if name == "@" {
+		return nil
+	}
+	if len(name) > maxNameLength {
+		return fmt.Errorf("name is longer than %d characters", maxNameLength)
+	}
+
+	host := recordType == "A" || recordType == "AAAA" || recordType == "CNAME"
+	for i, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "*" && i == 0 {
+			continue
+		}
+		if label == "" {
+			return fmt.Errorf("name has an empty label")
+		}
+		if len(label) > maxLabelLength {
+			return fmt.Errorf("label %q is longer than %d characters", label, maxLabelLength)
+		}
+		for _, r := range label {
+			if !isLabelChar(r) {
+				return fmt.Errorf("label %q has invalid character %q", label, r)
+			}
+		}
+		if host && (strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-")) {
+			return fmt.Errorf("label %q starts or ends with a hyphen", label)
+		}
+	}
+	return nil
+}
+
+// isSRVName reports whether name starts with the _<service>._<transport>
+// labels of an SRV record.
+func isSRVName(name string) bool {
+	labels := strings.SplitN(name, ".", 3)
+	return len(labels) >= 2 && len(labels[0]) > 1 && len(labels[1]) > 1 &&
+		strings.HasPrefix(labels[0], "_") && strings.HasPrefix(labels[1], "_")
+}
+
+func isLabelChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_'
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,295 @@
+package dnsregister
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/libdns/libdns"
+	"golang.org/x/net/idna"
+)
+
+func TestValidateName(t *testing.T) {
+	for _, tc := range []struct {
+		name, recordType string
+		valid            bool
+	}{
+		{"www", "A", true},
+		{"@", "MX", true},
+		{"*.apps", "A", true},
+		{"_dmarc", "TXT", true},
+		{"_acme-challenge.www", "CNAME", true},
+		{"xn--bcher-kva", "A", true},
+		{"web_1", "A", true},
+		{"-web", "TXT", true},
+		{"-web", "A", false},
+		{"web-", "CNAME", false},
+		{"ww w", "A", false},
+		{"www!", "TXT", false},
+		{"a..b", "A", false},
+		{"apps.*", "A", false},
+		{"a234567890123456789012345678901234567890123456789012345678901234", "A", false},
+		{"_sip._tcp", "SRV", true},
+		{"_sip._tcp.voice", "SRV", true},
+		{"sip", "SRV", false},
+		{"_sip", "SRV", false},
+		{"sip.tcp", "SRV", false},
+		{"@", "SRV", false},
+	} {
+		err := validateName(tc.name, tc.recordType)
+		if (err == nil) != tc.valid {
+			t.Errorf("validateName(%q, %s): valid=%v, got err=%v", tc.name, tc.recordType, tc.valid, err)
+		}
+	}
+}
+
+func TestProvisionSRVName(t *testing.T) {
+	// SRV records without service labels are rejected, naming the record
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: t.Context()})
+	defer cancel()
+	app := &App{LazyProviders: true, Domains: []*Domain{{
+		Zone:           "example.com",
+		DNSProviderRaw: json.RawMessage(`{"name": "fake"}`),
+		Records:        []*Record{{Name: "sip", Type: "SRV", Value: "10 5 5060 sip.example.com."}},
+	}}}
+	err := app.Provision(ctx)
+	if err == nil || !strings.Contains(err.Error(), `record sip: SRV name "sip" must start with _<service>._<transport> labels`) {
+		t.Errorf("expected SRV name error, got %v", err)
+	}
+}
+
+func TestEncodeName(t *testing.T) {
+	got, err := encodeName("bücher.shop")
+	if err != nil {
+		t.Fatalf("encodeName failed: %v", err)
+	}
+	if got != "xn--bcher-kva.shop" {
+		t.Errorf("expected punycode name, got %q", got)
+	}
+
+	if got, _ := encodeName("_dmarc"); got != "_dmarc" {
+		t.Errorf("expected ASCII name to be unchanged, got %q", got)
+	}
+}
+
+func TestRecordName(t *testing.T) {
+	for _, tc := range []struct {
+		name, want string
+		valid      bool
+	}{
+		{"www", "www", true},
+		{"www.example.com.", "www", true},
+		{"WWW.Example.COM.", "WWW", true},
+		{"example.com.", "@", true},
+		{"@", "@", true},
+		// The zone's own name is taken to mean the apex
+		{"example.com", "@", true},
+		{"Example.COM", "@", true},
+		// Relative names are never taken as absolute
+		{"www.example.com", "www.example.com", true},
+		{"bücher.example.com.", "xn--bcher-kva", true},
+		{"www.example.org.", "", false},
+		{"wwwexample.com.", "", false},
+	} {
+		got, err := recordName(tc.name, "example.com")
+		if (err == nil) != tc.valid || got != tc.want {
+			t.Errorf("recordName(%q) = %q, %v; want %q, valid=%v", tc.name, got, err, tc.want, tc.valid)
+		}
+	}
+}
+
+func TestRecordNameForms(t *testing.T) {
+	// A relative and an absolute name of the same record manage the
+	// same record and marker
+	var zones [][]libdns.Record
+	for _, name := range []string{"www", "www.example.com."} {
+		ctx, cancel := caddy.NewContext(caddy.Context{Context: t.Context()})
+		defer cancel()
+		provider := &fakeProvider{}
+		app := &App{
+			OwnerID:       "test-caddy",
+			LazyProviders: true,
+			Domains: []*Domain{{
+				Zone:           "example.com",
+				DNSProviderRaw: json.RawMessage(`{"name": "fake"}`),
+				Records:        []*Record{{Name: name, Type: "A", Value: "192.0.2.1"}},
+			}},
+		}
+		if err := app.Provision(ctx); err != nil {
+			t.Fatalf("%s: Provision failed: %v", name, err)
+		}
+		app.dataDir = t.TempDir()
+		domain := app.Domains[0]
+		domain.lazy = &lazyProvider{load: func() (any, error) { return provider, nil }}
+		for i := 0; i < 2; i++ {
+			if err := app.reconcileDomain(domain); err != nil {
+				t.Fatalf("%s: reconcileDomain failed: %v", name, err)
+			}
+		}
+		zones = append(zones, provider.records)
+	}
+	for i, records := range zones {
+		if len(records) != 2 || !hasRecord(records, "www", "A", "192.0.2.1") || !hasRecord(records, "_cdr.www", "TXT", "owner=test-caddy,heritage=caddy-dns-register") {
+			t.Errorf("form %d: expected www with its marker, got %v", i, records)
+		}
+	}
+
+	// Absolute names outside the zone are rejected
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: t.Context()})
+	defer cancel()
+	app := &App{LazyProviders: true, Domains: []*Domain{{
+		Zone:           "example.com",
+		DNSProviderRaw: json.RawMessage(`{"name": "fake"}`),
+		Records:        []*Record{{Name: "www.example.org.", Type: "A", Value: "192.0.2.1"}},
+	}}}
+	if err := app.Provision(ctx); err == nil || !strings.Contains(err.Error(), "not within zone example.com") {
+		t.Errorf("expected out-of-zone name error, got %v", err)
+	}
+}
+
+func TestRecordNameZoneApex(t *testing.T) {
+	// Records named "@" and after the zone make up one apex set
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: t.Context()})
+	defer cancel()
+	provider := &fakeProvider{}
+	app := &App{
+		OwnerID:       "test-caddy",
+		LazyProviders: true,
+		Domains: []*Domain{{
+			Zone:           "example.com",
+			DNSProviderRaw: json.RawMessage(`{"name": "fake"}`),
+			Records: []*Record{
+				{Name: "@", Type: "TXT", Value: "first"},
+				{Name: "example.com", Type: "TXT", Value: "second"},
+			},
+		}},
+	}
+	if err := app.Provision(ctx); err != nil {
+		t.Fatalf("Provision failed: %v", err)
+	}
+	app.dataDir = t.TempDir()
+	domain := app.Domains[0]
+	domain.lazy = &lazyProvider{load: func() (any, error) { return provider, nil }}
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	owned := app.parseOwnedRecords("example.com", provider.records)
+	if len(owned) != 1 || len(owned["@:TXT"]) != 2 {
+		t.Errorf("expected one apex TXT set of two records, got %v", provider.records)
+	}
+	if provider.has("example.com", "TXT") {
+		t.Errorf("expected no record below the apex, got %v", provider.records)
+	}
+}
+
+func TestReconcileUnicodeNames(t *testing.T) {
+	zone, err := encodeName("bücher.example")
+	if err != nil {
+		t.Fatalf("encodeName failed: %v", err)
+	}
+	if zone != "xn--bcher-kva.example" {
+		t.Fatalf("expected punycode zone, got %q", zone)
+	}
+	if decoded, _ := idna.Lookup.ToUnicode(zone); decoded != "bücher.example" {
+		t.Errorf("expected zone to round-trip, got %q", decoded)
+	}
+
+	name, _ := encodeName("wörter")
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: name, Type: "A", Value: "192.0.2.1"})
+	app.Domains[0].Zone = zone
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	// A provider returning names in Unicode form matches the config
+	for i, rec := range provider.records {
+		rr := rec.RR()
+		rr.Name = strings.Replace(rr.Name, name, "wörter", 1)
+		provider.records[i] = rr
+	}
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.get(zone)[1]
+	if len(result.Created) != 0 || len(result.Updated) != 0 || len(result.Deleted) != 0 {
+		t.Errorf("expected no churn for Unicode provider names, got %+v", result)
+	}
+}
+
+// fakeFQDNProvider returns record names fully qualified and records the
+// zone names it is called with.
+type fakeFQDNProvider struct {
+	fakeProvider
+	zones []string
+}
+
+func (p *fakeFQDNProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	p.zones = append(p.zones, zone)
+	recs, err := p.fakeProvider.GetRecords(ctx, zone)
+	absolute := make([]libdns.Record, len(recs))
+	for i, rec := range recs {
+		rr := rec.RR()
+		rr.Name = libdns.AbsoluteName(rr.Name, zone)
+		absolute[i] = rr
+	}
+	return absolute, err
+}
+
+func TestZoneTrailingDot(t *testing.T) {
+	for _, zone := range []string{"example.com", "Example.com."} {
+		ctx, cancel := caddy.NewContext(caddy.Context{Context: t.Context()})
+		defer cancel()
+		provider := &fakeFQDNProvider{}
+		app := &App{
+			OwnerID:       "test-caddy",
+			LazyProviders: true,
+			Domains: []*Domain{{
+				Zone:           zone,
+				DNSProviderRaw: json.RawMessage(`{"name": "fake"}`),
+				Records: []*Record{
+					{Name: "www", Type: "A", Value: "192.0.2.1"},
+					{Name: "api.example.com.", Type: "A", Value: "192.0.2.2"},
+				},
+			}},
+		}
+		if err := app.Provision(ctx); err != nil {
+			t.Fatalf("%s: Provision failed: %v", zone, err)
+		}
+		app.dataDir = t.TempDir()
+		domain := app.Domains[0]
+		domain.lazy = &lazyProvider{load: func() (any, error) { return provider, nil }}
+
+		if domain.Zone != "example.com" {
+			t.Errorf("%s: expected normalized zone, got %q", zone, domain.Zone)
+		}
+		// Records read back with absolute names are recognized as owned
+		for i := 0; i < 2; i++ {
+			if err := app.reconcileDomain(domain); err != nil {
+				t.Fatalf("%s: reconcileDomain failed: %v", zone, err)
+			}
+		}
+		if len(provider.records) != 4 || !hasRecord(provider.records, "www", "A", "192.0.2.1") || !hasRecord(provider.records, "api", "A", "192.0.2.2") {
+			t.Errorf("%s: expected www and api with markers, got %v", zone, provider.records)
+		}
+		if len(provider.zones) == 0 || provider.zones[0] != "example.com." {
+			t.Errorf("%s: expected provider to be called with example.com., got %v", zone, provider.zones)
+		}
+	}
+
+	// Both forms of a zone are the same zone
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: t.Context()})
+	defer cancel()
+	app := &App{LazyProviders: true, Domains: []*Domain{
+		{Zone: "example.com", DNSProviderRaw: json.RawMessage(`{"name": "fake"}`)},
+		{Zone: "example.com.", DNSProviderRaw: json.RawMessage(`{"name": "fake"}`)},
+	}}
+	if err := app.Provision(ctx); err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Errorf("expected duplicate zone error, got %v", err)
+	}
+}
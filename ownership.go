@@ -0,0 +1,21 @@
+package dnsregister
+
+import "github.com/libdns/libdns"
+
+// OwnershipFunc decides whether an existing record of a zone is owned
+// by this instance, e.g. by looking it up in an external ownership
+// database. zone is the configured zone name, rec the record with its
+// name relative to the zone ("@" for the apex), and marked whether the
+// ownership markers (or external-dns registry entries) make it owned.
+// Returning marked keeps the marker-based decision.
+//
+// Owned records are updated to match config and deleted when no longer
+// configured; records that aren't owned are never touched. Markers
+// themselves, registry entries and records of markerless_types are not
+// passed to the function. Records owned without a marker are not given
+// one until their set is created again.
+//
+// The function is called for every other record each time a zone's
+// records are read, from the goroutines reconciling zones concurrently,
+// so it must be safe for concurrent use and should be fast.
+type OwnershipFunc func(zone string, rec libdns.RR, marked bool) bool
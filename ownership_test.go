@@ -0,0 +1,81 @@
+package dnsregister
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestReconcileOwnershipFunc(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.9"},
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.8"},
+		libdns.RR{Name: "manual", Type: "A", Data: "192.0.2.7"},
+		libdns.RR{Name: "api", Type: "A", Data: "192.0.2.6"},
+		libdns.TXT{Name: "_cdr.api", Text: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+
+	// An external database owning www and old, and releasing api
+	// despite its marker
+	database := map[string]bool{"www": true, "old": true}
+	var mu sync.Mutex
+	var zones []string
+	app.Ownership = func(zone string, rec libdns.RR, marked bool) bool {
+		mu.Lock()
+		zones = append(zones, zone)
+		mu.Unlock()
+		return database[rec.Name]
+	}
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.last("example.com")
+	if len(result.Updated) != 1 || result.Updated[0] != "www:A" {
+		t.Errorf("expected www:A to be updated, got %+v", result)
+	}
+	if !hasRecord(provider.records, "www", "A", "192.0.2.1") {
+		t.Errorf("expected www to be updated, got %v", provider.records)
+	}
+	if hasRecord(provider.records, "old", "A", "192.0.2.8") {
+		t.Error("expected the owned old record to be deleted")
+	}
+	if !hasRecord(provider.records, "manual", "A", "192.0.2.7") || !hasRecord(provider.records, "api", "A", "192.0.2.6") {
+		t.Errorf("expected records the function doesn't own to be left alone, got %v", provider.records)
+	}
+	for _, zone := range zones {
+		if zone != "example.com" {
+			t.Errorf("expected the function to be called with zone example.com, got %q", zone)
+		}
+	}
+}
+
+func TestParseOwnedRecordsOwnershipFuncMarked(t *testing.T) {
+	app := &App{OwnerID: "test-caddy"}
+	records := []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.TXT{Name: "_cdr.www", Text: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "api", Type: "A", Data: "192.0.2.2"},
+	}
+
+	// Records are passed with the marker-based decision, markers
+	// themselves aren't passed
+	var seen []string
+	app.Ownership = func(_ string, rec libdns.RR, marked bool) bool {
+		seen = append(seen, rec.Name)
+		if rec.Name == "www" && !marked {
+			t.Error("expected www to be passed as marked")
+		}
+		return marked
+	}
+	owned := app.parseOwnedRecords("example.com", records)
+	if len(owned) != 1 || owned["www:A"] == nil {
+		t.Errorf("expected only www:A to be owned, got %v", owned)
+	}
+	if len(seen) != 2 {
+		t.Errorf("expected the function to be called for the two records, got %v", seen)
+	}
+}
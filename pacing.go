@@ -0,0 +1,58 @@
+package dnsregister
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// writePacer spaces a domain's provider write calls at least delay
+// apart: a call starts no sooner than delay after the previous one
+// ended, and calls made concurrently with provider_concurrency start at
+// least delay after each other.
+type writePacer struct {
+	delay time.Duration
+	mu    sync.Mutex
+	next  time.Time
+}
+
+// wait blocks until the next write call may start, or until ctx is
+// done. The start time is reserved before waiting, so concurrent
+// callers are let through one delay apart.
+func (p *writePacer) wait(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	now := time.Now()
+	start := now
+	if p.next.After(now) {
+		start = p.next
+	}
+	p.next = start.Add(p.delay)
+	p.mu.Unlock()
+
+	remaining := start.Sub(now)
+	if remaining <= 0 {
+		return
+	}
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// done records that a write call ended, so the next one starts no
+// sooner than delay later.
+func (p *writePacer) done() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if next := time.Now().Add(p.delay); next.After(p.next) {
+		p.next = next
+	}
+}
@@ -0,0 +1,101 @@
+package dnsregister
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeTimedProvider records when each write call starts.
+type fakeTimedProvider struct {
+	fakeProvider
+	writes []time.Time
+}
+
+func (p *fakeTimedProvider) SetRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	p.writes = append(p.writes, time.Now())
+	return p.fakeProvider.SetRecords(ctx, zone, recs)
+}
+
+func (p *fakeTimedProvider) DeleteRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	p.writes = append(p.writes, time.Now())
+	return p.fakeProvider.DeleteRecords(ctx, zone, recs)
+}
+
+func TestReconcileInterCallDelay(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	provider := &fakeTimedProvider{fakeProvider: fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.5"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.Domains[0].pacer = &writePacer{delay: delay}
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if len(provider.writes) < 2 {
+		t.Fatalf("expected a delete and a create call, got %d calls", len(provider.writes))
+	}
+	for i := 1; i < len(provider.writes); i++ {
+		if gap := provider.writes[i].Sub(provider.writes[i-1]); gap < delay {
+			t.Errorf("expected write calls at least %v apart, got %v", delay, gap)
+		}
+	}
+	if !provider.has("www", "A") || provider.has("old", "A") {
+		t.Errorf("expected old replaced by www, got %v", provider.records)
+	}
+}
+
+func TestWritePacerCancel(t *testing.T) {
+	pacer := &writePacer{delay: time.Hour}
+	pacer.done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	pacer.wait(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the wait to end with its context, took %v", elapsed)
+	}
+}
+
+func TestReconcileInterCallDelayConcurrent(t *testing.T) {
+	const delay = 40 * time.Millisecond
+	provider := &fakeParallelProvider{}
+	var recs []*Record
+	for i := 0; i < 4; i++ {
+		recs = append(recs, &Record{Name: fmt.Sprintf("host%d", i), Type: "A", Value: fmt.Sprintf("10.0.0.%d", i)})
+	}
+	app := newTestApp(t, provider, recs...)
+	domain := app.Domains[0]
+	domain.setSlots = make(chan struct{}, 4)
+	domain.pacer = &writePacer{delay: delay}
+
+	var mu sync.Mutex
+	var starts []time.Time
+	provider.onWrite = func() {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+	}
+
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if len(starts) < 4 {
+		t.Fatalf("expected a write call per set, got %d", len(starts))
+	}
+	// Allowing for scheduling jitter in when the calls are observed
+	slices.SortFunc(starts, func(a, b time.Time) int { return a.Compare(b) })
+	for i := 1; i < len(starts); i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < delay-10*time.Millisecond {
+			t.Errorf("expected concurrent write calls to start at least %v apart, got %v", delay, gap)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package dnsregister
+
+import "errors"
+
+// PartialResultError is an error a provider's GetRecords can return
+// together with the records it did get, e.g. when some pages of a
+// paginated listing failed. Reconciles then proceed with the partial
+// set instead of aborting, but delete nothing, as records missing from
+// it may still exist.
+type PartialResultError interface {
+	error
+
+	// PartialResult reports whether the records returned with the
+	// error are a usable partial result.
+	PartialResult() bool
+}
+
+// isPartialResult reports whether err, or an error it wraps, is a
+// PartialResultError with a usable partial result.
+func isPartialResult(err error) bool {
+	var partial PartialResultError
+	return errors.As(err, &partial) && partial.PartialResult()
+}
@@ -0,0 +1,97 @@
+package dnsregister
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+// fakePartialError is a PartialResultError.
+type fakePartialError struct {
+	usable bool
+}
+
+func (e fakePartialError) Error() string       { return "page 2 of 2 failed" }
+func (e fakePartialError) PartialResult() bool { return e.usable }
+
+// fakePartialProvider returns the records of its fakeProvider without
+// those named in hidden, together with err.
+type fakePartialProvider struct {
+	*fakeProvider
+	hidden map[string]bool
+	err    error
+}
+
+func (p *fakePartialProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	recs, _ := p.fakeProvider.GetRecords(ctx, zone)
+	var partial []libdns.Record
+	for _, rec := range recs {
+		if !p.hidden[rec.RR().Name] {
+			partial = append(partial, rec)
+		}
+	}
+	return partial, p.err
+}
+
+func TestReconcilePartialResult(t *testing.T) {
+	backing := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.9"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	provider := &fakePartialProvider{
+		fakeProvider: backing,
+		hidden:       map[string]bool{"www": true, "_cdr.www": true},
+		err:          fmt.Errorf("listing records: %w", fakePartialError{usable: true}),
+	}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "api", Type: "A", Value: "192.0.2.2"})
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !backing.has("api", "A") {
+		t.Errorf("expected api to be created from the partial result, got %v", backing.records)
+	}
+	if !backing.has("old", "A") || !backing.has("_cdr.old", "TXT") {
+		t.Errorf("expected deletions to be suppressed after a partial result, got %v", backing.records)
+	}
+
+	result := app.history.last("example.com")
+	if result == nil || len(result.Deleted) != 0 {
+		t.Fatalf("expected a result without deletions, got %+v", result)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "partial result") {
+		t.Errorf("expected partial result to be reported, got %v", result.Errors)
+	}
+
+	// Once the provider returns everything again, deletions resume
+	provider.hidden, provider.err = nil, nil
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if backing.has("old", "A") {
+		t.Errorf("expected old to be deleted after a complete result, got %v", backing.records)
+	}
+}
+
+func TestReconcileUnusablePartialResult(t *testing.T) {
+	provider := &fakePartialProvider{
+		fakeProvider: &fakeProvider{},
+		err:          fakePartialError{usable: false},
+	}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+
+	if err := app.reconcileDomain(app.Domains[0]); err == nil {
+		t.Fatal("expected reconcile to fail on an unusable partial result")
+	}
+	if provider.has("www", "A") {
+		t.Errorf("expected no changes, got %v", provider.records)
+	}
+}
@@ -0,0 +1,181 @@
+package dnsregister
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Patch precedence values, for records that are both configured and
+// patched.
+const (
+	patchPrecedenceConfig = "config"
+	patchPrecedencePatch  = "patch"
+)
+
+// zonePatch holds the record changes made to a zone via the admin API
+// on top of its configured records. It is persisted so that reconciles
+// and config reloads don't revert it.
+type zonePatch struct {
+	// Add lists records published in addition to the configured ones.
+	Add []*Record `json:"add,omitempty"`
+
+	// Remove lists the keys (name:type) of record sets that are not
+	// published even if configured.
+	Remove []string `json:"remove,omitempty"`
+}
+
+// patchRemoval identifies a record set to remove in a patch request.
+type patchRemoval struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// zonePatches holds the patches of all managed zones in memory.
+type zonePatches struct {
+	mu    sync.Mutex
+	zones map[string]*zonePatch
+}
+
+func newZonePatches() *zonePatches {
+	return &zonePatches{zones: make(map[string]*zonePatch)}
+}
+
+// patchPath returns the path of the patch file for a zone.
+func (a *App) patchPath(zone string) string {
+	return filepath.Join(a.dataDir, "patches", a.OwnerID, strings.TrimSuffix(zone, ".")+".json")
+}
+
+// loadPatch reads the persisted patch of a zone, if any.
+func (a *App) loadPatch(zone string) error {
+	var patch zonePatch
+	found, err := readJSONFile(a.patchPath(zone), &patch)
+	if err != nil {
+		return fmt.Errorf("reading patch: %w", err)
+	}
+	if !found {
+		return nil
+	}
+
+	a.patches.mu.Lock()
+	defer a.patches.mu.Unlock()
+	a.patches.zones[zone] = &patch
+	return nil
+}
+
+// addPatch merges record additions and removals into the patch of a
+// zone and persists it. A later operation on a record set overrides an
+// earlier one: adding a record undoes the removal of its set, and
+// removing a set drops the records added to it.
+func (a *App) addPatch(zone string, add []*Record, remove []patchRemoval) error {
+	for _, rec := range add {
+		name, err := recordName(rec.Name, zone)
+		if err != nil {
+			return fmt.Errorf("record %s: %v", rec.Name, err)
+		}
+		rec.Name = name
+		rec.Type = strings.ToUpper(rec.Type)
+		a.foldRecordCase(rec)
+		if err := validateRecord(rec); err != nil {
+			return fmt.Errorf("record %s: %v", rec.Name, err)
+		}
+	}
+	removeKeys := make([]string, 0, len(remove))
+	for _, r := range remove {
+		name, err := recordName(r.Name, zone)
+		if err != nil {
+			return fmt.Errorf("record %s: %v", r.Name, err)
+		}
+		rec := &Record{Name: name, Type: strings.ToUpper(r.Type)}
+		a.foldRecordCase(rec)
+		removeKeys = append(removeKeys, recordKey(rec))
+	}
+
+	a.patches.mu.Lock()
+	defer a.patches.mu.Unlock()
+
+	patch := &zonePatch{}
+	if existing, ok := a.patches.zones[zone]; ok {
+		patch.Add = append(patch.Add, existing.Add...)
+		patch.Remove = append(patch.Remove, existing.Remove...)
+	}
+
+	for _, key := range removeKeys {
+		patch.Add = withoutKey(patch.Add, key)
+		if !slices.Contains(patch.Remove, key) {
+			patch.Remove = append(patch.Remove, key)
+		}
+	}
+	for _, rec := range add {
+		key := recordKey(rec)
+		patch.Remove = slices.DeleteFunc(patch.Remove, func(k string) bool { return k == key })
+		duplicate := false
+		for _, existing := range patch.Add {
+			if recordKey(existing) == key && existing.Value == rec.Value {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			patch.Add = append(patch.Add, rec)
+		}
+	}
+
+	if err := writeJSONFile(a.patchPath(zone), patch); err != nil {
+		return fmt.Errorf("writing patch: %w", err)
+	}
+	a.patches.zones[zone] = patch
+	return nil
+}
+
+// patchedRecords returns the records of a domain with its zone's patch
+// applied. Where the patch and the config both define a record set, the
+// config wins unless the patch precedence is "patch".
+func (a *App) patchedRecords(domain *Domain) []*Record {
+	if a.patches == nil {
+		return domain.Records
+	}
+	a.patches.mu.Lock()
+	patch, ok := a.patches.zones[domain.Zone]
+	a.patches.mu.Unlock()
+	if !ok {
+		return domain.Records
+	}
+
+	patchWins := a.PatchPrecedence == patchPrecedencePatch
+	added := make(map[string]bool)
+	for _, rec := range patch.Add {
+		added[recordKey(rec)] = true
+	}
+
+	var records []*Record
+	configured := make(map[string]bool)
+	for _, rec := range domain.Records {
+		key := recordKey(rec)
+		configured[key] = true
+		if patchWins && (added[key] || slices.Contains(patch.Remove, key)) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	for _, rec := range patch.Add {
+		if !patchWins && configured[recordKey(rec)] {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// withoutKey returns recs without the records of the set key.
+func withoutKey(recs []*Record, key string) []*Record {
+	var kept []*Record
+	for _, rec := range recs {
+		if recordKey(rec) != key {
+			kept = append(kept, rec)
+		}
+	}
+	return kept
+}
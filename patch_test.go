@@ -0,0 +1,132 @@
+package dnsregister
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+func TestAdminPatch(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.patches = newZonePatches()
+	app.history = newReconcileHistory(0)
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	patch := func(body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, adminEndpointBase+"patch?zone=example.com", strings.NewReader(body))
+		if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); err != nil {
+			t.Fatalf("POST patch failed: %v", err)
+		}
+	}
+
+	patch(`{"add": [{"name": "tmp", "type": "a", "value": "192.0.2.9"}]}`)
+	if !hasRecord(provider.records, "tmp", "A", "192.0.2.9") {
+		t.Errorf("expected patched record to be created immediately, got %v", provider.records)
+	}
+
+	// The patch survives a reload and isn't reverted by reconciles
+	reloaded := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	reloaded.dataDir = app.dataDir
+	reloaded.patches = newZonePatches()
+	if err := reloaded.loadPatch("example.com"); err != nil {
+		t.Fatalf("loadPatch failed: %v", err)
+	}
+	if err := reloaded.reconcileDomain(reloaded.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !hasRecord(provider.records, "tmp", "A", "192.0.2.9") {
+		t.Errorf("expected patched record to survive reload, got %v", provider.records)
+	}
+
+	// Removing the set drops the earlier addition
+	patch(`{"remove": [{"name": "tmp", "type": "A"}]}`)
+	if hasRecord(provider.records, "tmp", "A", "192.0.2.9") {
+		t.Errorf("expected patched record to be removed, got %v", provider.records)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, adminEndpointBase+"patch?zone=example.com",
+		strings.NewReader(`{"add": [{"name": "bad name", "type": "A", "value": "192.0.2.1"}]}`))
+	if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); err == nil {
+		t.Error("expected invalid record to be rejected")
+	}
+}
+
+func TestAdminPatchPaused(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.patches = newZonePatches()
+	app.history = newReconcileHistory(0)
+	app.paused = new(atomic.Bool)
+	app.paused.Store(true)
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	// While paused the patch is persisted but not applied
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, adminEndpointBase+"patch?zone=example.com",
+		strings.NewReader(`{"add": [{"name": "tmp", "type": "A", "value": "192.0.2.9"}]}`))
+	if err := api.handleAPIEndpoints(rec, req); err != nil {
+		t.Fatalf("POST patch failed: %v", err)
+	}
+	if provider.gets != 0 || provider.has("tmp", "A") {
+		t.Errorf("expected no reconcile while paused, got %d fetches and %v", provider.gets, provider.records)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != "null" {
+		t.Errorf("expected no result while paused, got %s", body)
+	}
+
+	// Once resumed, the next reconcile applies it
+	app.paused.Store(false)
+	app.triggerReconcile(app.ctx, app.Domains[0], "test")
+	if !hasRecord(provider.records, "tmp", "A", "192.0.2.9") {
+		t.Errorf("expected patched record to be created after resuming, got %v", provider.records)
+	}
+}
+
+func TestPatchedRecordsPrecedence(t *testing.T) {
+	configured := []*Record{
+		{Name: "www", Type: "A", Value: "192.0.2.1"},
+		{Name: "api", Type: "A", Value: "192.0.2.2"},
+	}
+	app := newTestApp(t, &fakeProvider{}, configured...)
+	app.patches = newZonePatches()
+	err := app.addPatch("example.com",
+		[]*Record{{Name: "www", Type: "A", Value: "192.0.2.9"}, {Name: "new", Type: "A", Value: "192.0.2.3"}},
+		[]patchRemoval{{Name: "api", Type: "A"}})
+	if err != nil {
+		t.Fatalf("addPatch failed: %v", err)
+	}
+
+	tests := []struct {
+		precedence string
+		want       []string
+	}{
+		{"", []string{"www:192.0.2.1", "api:192.0.2.2", "new:192.0.2.3"}},
+		{patchPrecedencePatch, []string{"www:192.0.2.9", "new:192.0.2.3"}},
+	}
+	for _, tt := range tests {
+		app.PatchPrecedence = tt.precedence
+		var got []string
+		for _, rec := range app.patchedRecords(app.Domains[0]) {
+			got = append(got, rec.Name+":"+rec.Value)
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("precedence %q: expected %v, got %v", tt.precedence, tt.want, got)
+		}
+	}
+}
+
+func hasRecord(recs []libdns.Record, name, typ, data string) bool {
+	for _, rec := range recs {
+		if rr := rec.RR(); rr.Name == name && rr.Type == typ && rr.Data == data {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package dnsregister
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// planFilePath returns the path of the plan file for a zone in plan mode.
+func (a *App) planFilePath(zone string) string {
+	return filepath.Join(a.PlanDir, strings.TrimSuffix(zone, ".")+".json")
+}
+
+// writePlanFile writes plan for a zone to the plan directory for later
+// approval. An empty plan removes any previously written plan, as there
+// is nothing left to approve.
+func (a *App) writePlanFile(zone string, plan *reconcilePlan) error {
+	path := a.planFilePath(zone)
+	if plan.empty() {
+		err := os.Remove(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("removing plan file: %w", err)
+		}
+		return nil
+	}
+
+	if err := writeJSONFile(path, newPendingPlan(zone, plan)); err != nil {
+		return fmt.Errorf("writing plan file: %w", err)
+	}
+	a.logger.Info("wrote reconcile plan for approval",
+		zap.String("zone", zone),
+		zap.String("path", path))
+	return nil
+}
+
+// applyPlanFile applies the plan previously written for the domain's
+// zone and removes the plan file. It returns the outcome, which is also
+// recorded in the reconcile history. It returns fs.ErrNotExist if there
+// is no plan for the zone. Like a reconcile, it can be cancelled via the
+// admin API.
+func (a *App) applyPlanFile(domain *Domain) (result ReconcileResult, err error) {
+	defer a.running.start(a.ctx, domain.Zone)()
+
+	result = ReconcileResult{Zone: domain.Zone, Time: time.Now()}
+
+	var written pendingPlan
+	found, err := readJSONFile(a.planFilePath(domain.Zone), &written)
+	if err != nil {
+		return result, fmt.Errorf("reading plan file: %w", err)
+	}
+	if !found {
+		return result, fs.ErrNotExist
+	}
+
+	var plan *reconcilePlan
+	defer func() {
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		result.Duration = time.Since(result.Time).String()
+		forgetVerified(domain.Zone)
+		a.ready.record(result, err)
+		a.history.add(result)
+		a.emitRecordsChanged(result)
+		a.updateConditions(result)
+		if plan != nil {
+			a.auditChanges(plan, result)
+			a.printChanges(plan, result)
+		}
+	}()
+
+	unlock, err := a.zoneLocks.lock(a.running.context(a.ctx, domain.Zone), domain.Zone)
+	if err != nil {
+		return result, fmt.Errorf("waiting for another reconcile of the zone: %w", err)
+	}
+	defer unlock()
+
+	if err := a.ensureProvider(domain); err != nil {
+		return result, err
+	}
+
+	plan = written.reconcilePlan()
+	a.logger.Info("applying approved reconcile plan",
+		zap.String("zone", domain.Zone),
+		zap.Time("planned_at", written.Time),
+		zap.Strings("changes", plan.pending()))
+
+	if err := a.applyPlan(domain, plan, &result); err != nil {
+		return result, err
+	}
+
+	if len(a.MarkerlessTypes) > 0 {
+		tracked, err := a.loadState(domain.Zone)
+		if err != nil {
+			return result, err
+		}
+		if a.trackChanges(tracked, plan, result) {
+			if err := a.saveState(domain.Zone, tracked); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if err := os.Remove(a.planFilePath(domain.Zone)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return result, fmt.Errorf("removing plan file: %w", err)
+	}
+	return result, nil
+}
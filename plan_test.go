@@ -0,0 +1,145 @@
+package dnsregister
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+func TestReconcilePlanMode(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.9"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.PlanDir = t.TempDir()
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.has("www", "A") || !provider.has("old", "A") {
+		t.Fatal("expected no changes to be applied in plan mode")
+	}
+	if _, err := os.Stat(app.planFilePath("example.com")); err != nil {
+		t.Fatalf("expected plan file to be written: %v", err)
+	}
+
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+	applyPlan := func() error {
+		req := httptest.NewRequest(http.MethodPost, adminEndpointBase+"apply-plan?zone=example.com", nil)
+		return api.handleAPIEndpoints(httptest.NewRecorder(), req)
+	}
+
+	if err := applyPlan(); err != nil {
+		t.Fatalf("apply-plan failed: %v", err)
+	}
+	if !provider.has("www", "A") || !provider.has("_cdr.www", "TXT") {
+		t.Error("expected planned create to be applied")
+	}
+	if provider.has("old", "A") || provider.has("_cdr.old", "TXT") {
+		t.Error("expected planned delete to be applied")
+	}
+	if _, err := os.Stat(app.planFilePath("example.com")); !os.IsNotExist(err) {
+		t.Error("expected plan file to be removed once applied")
+	}
+	if err := applyPlan(); err == nil {
+		t.Error("expected apply-plan without a plan to fail")
+	}
+
+	// Once in sync, reconciling leaves no plan behind
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if _, err := os.Stat(app.planFilePath("example.com")); !os.IsNotExist(err) {
+		t.Error("expected no plan file for an empty plan")
+	}
+}
+
+// fakeHangingWriteProvider is a fakeProvider whose writes block until
+// their context is done.
+type fakeHangingWriteProvider struct {
+	fakeProvider
+	entered chan struct{}
+	once    sync.Once
+}
+
+func (p *fakeHangingWriteProvider) hang(ctx context.Context) ([]libdns.Record, error) {
+	p.once.Do(func() { close(p.entered) })
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (p *fakeHangingWriteProvider) AppendRecords(ctx context.Context, _ string, _ []libdns.Record) ([]libdns.Record, error) {
+	return p.hang(ctx)
+}
+
+func (p *fakeHangingWriteProvider) SetRecords(ctx context.Context, _ string, _ []libdns.Record) ([]libdns.Record, error) {
+	return p.hang(ctx)
+}
+
+func TestApplyPlanPausedAndCancelled(t *testing.T) {
+	provider := &fakeHangingWriteProvider{entered: make(chan struct{})}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.PlanDir = t.TempDir()
+	app.running = newRunningReconciles()
+	app.history = newReconcileHistory(0)
+	app.paused = new(atomic.Bool)
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	post := func(path string) (*httptest.ResponseRecorder, error) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, adminEndpointBase+path, nil)
+		return rec, api.handleAPIEndpoints(rec, req)
+	}
+
+	// While paused the plan is not applied
+	app.paused.Store(true)
+	_, err := post("apply-plan?zone=example.com")
+	var apiErr caddy.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusConflict {
+		t.Fatalf("expected apply-plan to conflict while paused, got %v", err)
+	}
+	if _, err := os.Stat(app.planFilePath("example.com")); err != nil {
+		t.Errorf("expected the plan to be kept while paused: %v", err)
+	}
+
+	// A hung apply can be cancelled
+	app.paused.Store(false)
+	done := make(chan error)
+	go func() {
+		_, err := post("apply-plan?zone=example.com")
+		done <- err
+	}()
+	<-provider.entered
+	rec, err := post("cancel?zone=example.com")
+	if err != nil {
+		t.Fatalf("POST cancel failed: %v", err)
+	}
+	var resp map[string]bool
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || !resp["cancelled"] {
+		t.Errorf("expected the apply to be cancelled, got %s", rec.Body.String())
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("apply-plan did not return after cancel")
+	}
+	if last := app.history.last("example.com"); last == nil || len(last.Errors) == 0 {
+		t.Errorf("expected the cancelled apply to record an error, got %+v", last)
+	}
+}
@@ -0,0 +1,92 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"sync"
+)
+
+// changeLine is a record change as printed with PrintChanges, one JSON
+// object per line:
+//
+//	op      string  "create", "update", "delete" or "release"
+//	zone    string  the zone, without a trailing dot
+//	name    string  the record name relative to the zone
+//	type    string  the record type
+//	value   string  the record's value; omitted with redact_values
+//	result  string  "ok" if the change was applied, "failed" if not
+type changeLine struct {
+	Op     string `json:"op"`
+	Zone   string `json:"zone"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Value  string `json:"value,omitempty"`
+	Result string `json:"result"`
+}
+
+// changePrinter writes change lines to an output shared by all
+// reconciles, a line at a time.
+type changePrinter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// print writes lines to the output, ignoring write errors: nobody may
+// be reading it.
+func (p *changePrinter) print(lines []changeLine) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	enc := json.NewEncoder(p.out)
+	for _, line := range lines {
+		_ = enc.Encode(line)
+	}
+}
+
+// printChanges prints a line per record changed, or failed to change,
+// by a reconcile, if PrintChanges is set.
+func (a *App) printChanges(plan *reconcilePlan, result ReconcileResult) {
+	if a.printer == nil {
+		return
+	}
+
+	var lines []changeLine
+	add := func(op string, recs []*Record, res string) {
+		for _, rec := range recs {
+			line := changeLine{Op: op, Zone: result.Zone, Name: rec.Name, Type: rec.Type, Result: res}
+			if !a.RedactValues {
+				line.Value = rec.Value
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	for _, key := range result.Created {
+		add("create", plan.desired[key], "ok")
+	}
+	for _, key := range result.Updated {
+		add("update", plan.desired[key], "ok")
+	}
+	for _, key := range result.Deleted {
+		add("delete", plan.owned[key], "ok")
+	}
+	for _, key := range result.Released {
+		add("release", plan.owned[key], "ok")
+	}
+	for _, key := range result.failed {
+		switch {
+		case slices.Contains(plan.toCreate, key):
+			add("create", plan.desired[key], "failed")
+		case slices.Contains(plan.toUpdate, key):
+			add("update", plan.desired[key], "failed")
+		case slices.Contains(plan.toDelete, key):
+			add("delete", plan.owned[key], "failed")
+		case slices.Contains(plan.toRelease, key):
+			add("release", plan.owned[key], "failed")
+		}
+	}
+
+	if len(lines) > 0 {
+		a.printer.print(lines)
+	}
+}
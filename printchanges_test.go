@@ -0,0 +1,80 @@
+package dnsregister
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestPrintChanges(t *testing.T) {
+	provider := &fakeCountingProvider{fakeProvider: fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.9"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}, failName: "bad"}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "www", Type: "A", Value: "192.0.2.2"},
+		&Record{Name: "bad", Type: "A", Value: "192.0.2.3"})
+	var out bytes.Buffer
+	app.printer = &changePrinter{out: &out}
+
+	_ = app.reconcileDomain(app.Domains[0])
+
+	var lines []changeLine
+	for _, text := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var line changeLine
+		if err := json.Unmarshal([]byte(text), &line); err != nil {
+			t.Fatalf("decoding line %q: %v", text, err)
+		}
+		lines = append(lines, line)
+	}
+	want := []changeLine{
+		{Op: "create", Zone: "example.com", Name: "www", Type: "A", Value: "192.0.2.1", Result: "ok"},
+		{Op: "create", Zone: "example.com", Name: "www", Type: "A", Value: "192.0.2.2", Result: "ok"},
+		{Op: "delete", Zone: "example.com", Name: "old", Type: "A", Value: "192.0.2.9", Result: "ok"},
+		{Op: "create", Zone: "example.com", Name: "bad", Type: "A", Value: "192.0.2.3", Result: "failed"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %+v", len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d: expected %+v, got %+v", i, want[i], lines[i])
+		}
+	}
+
+	// Values are left out when redacted, and nothing is printed
+	// without changes
+	out.Reset()
+	app.RedactValues = true
+	provider.failName = ""
+	_ = app.reconcileDomain(app.Domains[0])
+	if got := strings.TrimSpace(out.String()); got != `{"op":"create","zone":"example.com","name":"bad","type":"A","result":"ok"}` {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+func TestPrintChangesAppliedPlan(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.PlanDir = t.TempDir()
+	var out bytes.Buffer
+	app.printer = &changePrinter{out: &out}
+
+	// Nothing is printed for a written plan, only once it is applied
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected no output for a written plan, got %q", out.String())
+	}
+	if _, err := app.applyPlanFile(app.Domains[0]); err != nil {
+		t.Fatalf("applyPlanFile failed: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != `{"op":"create","zone":"example.com","name":"www","type":"A","value":"192.0.2.1","result":"ok"}` {
+		t.Errorf("unexpected output %q", got)
+	}
+}
@@ -0,0 +1,39 @@
+package dnsregister
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// markerPriority returns the instance priority recorded in marker
+// fields, or 0 if there is none.
+func markerPriority(fields map[string]string) int {
+	priority, err := strconv.Atoi(fields["priority"])
+	if err != nil {
+		return 0
+	}
+	return priority
+}
+
+// higherPriorityNames returns the record names marked by another
+// instance with a higher instance priority than this one, mapped to
+// that instance's owner ID. This instance stands by for those names.
+func (a *App) higherPriorityNames(records []libdns.Record) map[string]string {
+	names := make(map[string]string)
+	for _, rec := range records {
+		rr := rec.RR()
+		if !a.isMarkerRecord(rr) {
+			continue
+		}
+		fields := a.markerFieldsOf(rr.Data)
+		if fields["heritage"] != markerHeritage || fields["owner"] == a.OwnerID {
+			continue
+		}
+		if markerPriority(fields) > a.InstancePriority {
+			names[strings.TrimPrefix(rr.Name, markerPrefix)] = fields["owner"]
+		}
+	}
+	return names
+}
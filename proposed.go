@@ -0,0 +1,97 @@
+package dnsregister
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// errInvalidProposal marks errors in a proposed config, as opposed to
+// errors reading the zones it is compared with.
+var errInvalidProposal = errors.New("invalid proposed config")
+
+// proposedDiff is what applying a proposed config would change in a
+// zone: the changes, in the form of a plan file, grouped by name, and
+// the problems found while planning them.
+type proposedDiff struct {
+	pendingPlan
+	Changes []string `json:"changes,omitempty"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// diffProposed plans, for each zone of the proposed config that is
+// managed here, the changes that would bring it in line with the
+// proposed records and record templates, without applying them. Only
+// the records are taken from the proposal: the zones' providers and
+// other settings, as well as their patches, stay as loaded. Zones are
+// limited to zone unless it is empty.
+func (a *App) diffProposed(proposal *App, zone string) ([]*proposedDiff, error) {
+	var diffs []*proposedDiff
+	for _, pd := range proposal.Domains {
+		name, err := normalizeZone(pd.Zone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: domain %s: %v", errInvalidProposal, pd.Zone, err)
+		}
+		if zone != "" && name != zone {
+			continue
+		}
+		var domain *Domain
+		for _, d := range a.Domains {
+			if d.Zone == name {
+				domain = d
+				break
+			}
+		}
+		if domain == nil {
+			return nil, fmt.Errorf("%w: domain %s: zone is not managed", errInvalidProposal, pd.Zone)
+		}
+
+		records := pd.Records
+		for _, tmpl := range pd.RecordTemplates {
+			records = append(records, tmpl.expand()...)
+		}
+		for _, rec := range records {
+			if err := a.prepareRecord(name, rec); err != nil {
+				return nil, fmt.Errorf("%w: domain %s: record %s: %v", errInvalidProposal, name, rec.Name, err)
+			}
+		}
+
+		diff, err := a.diffDomain(domain, records)
+		if err != nil {
+			return nil, fmt.Errorf("domain %s: %w", name, err)
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// diffDomain plans the changes that would bring the domain's zone in
+// line with records if they replaced its configured records.
+func (a *App) diffDomain(domain *Domain, records []*Record) (*proposedDiff, error) {
+	if err := a.ensureProvider(domain); err != nil {
+		return nil, err
+	}
+	getter, ok := domain.provider.(libdns.RecordGetter)
+	if !ok {
+		return nil, fmt.Errorf("provider does not implement RecordGetter")
+	}
+
+	proposed := *domain
+	proposed.Records = records
+	if err := checkDependencies(&proposed); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidProposal, err)
+	}
+
+	desired, failed := a.desiredRecords(&proposed)
+	result := ReconcileResult{Zone: domain.Zone}
+	diff, err := a.diffZone(&proposed, getter, desired, failed, nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &proposedDiff{
+		pendingPlan: newPendingPlan(domain.Zone, diff.plan),
+		Changes:     diff.plan.changesByName(),
+		Errors:      result.Errors,
+	}, nil
+}
@@ -0,0 +1,82 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+func TestAdminDiff(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.5"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "old", Type: "A", Value: "192.0.2.5"})
+	app.history = newReconcileHistory(0)
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+	before := slices.Clone(provider.records)
+
+	body := `{"domains": [{"zone": "Example.com.", "records": [
+		{"name": "www.example.com.", "type": "A", "value": "192.0.2.9"},
+		{"name": "api", "type": "A", "value": "192.0.2.2"}
+	]}]}`
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, adminEndpointBase+"diff", strings.NewReader(body))
+	if err := api.handleAPIEndpoints(rec, req); err != nil {
+		t.Fatalf("POST diff failed: %v", err)
+	}
+	var diffs []proposedDiff
+	if err := json.Unmarshal(rec.Body.Bytes(), &diffs); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("expected a diff of one zone, got %+v", diffs)
+	}
+	diff := diffs[0]
+	if diff.Zone != "example.com" ||
+		len(diff.Create) != 1 || diff.Create[0].Name != "api" ||
+		len(diff.Update) != 1 || diff.Update[0].Value != "192.0.2.9" ||
+		len(diff.Delete) != 1 || diff.Delete[0].Name != "old" {
+		t.Errorf("expected api created, www updated and old deleted, got %+v", diff)
+	}
+	want := []string{"api: A created", "old: A deleted", "www: A updated"}
+	if !slices.Equal(diff.Changes, want) {
+		t.Errorf("expected changes %v, got %v", want, diff.Changes)
+	}
+
+	// Nothing is applied, and the loaded config is untouched
+	if !slices.Equal(provider.records, before) {
+		t.Errorf("expected the zone to be unchanged, got %v", provider.records)
+	}
+	if len(app.Domains[0].Records) != 2 || app.Domains[0].Records[0].Value != "192.0.2.1" {
+		t.Errorf("expected the configured records to be unchanged, got %v", app.Domains[0].Records)
+	}
+	if len(app.history.get("")) != 0 {
+		t.Errorf("expected no reconcile to be recorded, got %v", app.history.get(""))
+	}
+
+	// Zones that aren't managed here, or invalid records, are rejected
+	for _, body := range []string{
+		`{"domains": [{"zone": "example.org", "records": []}]}`,
+		`{"domains": [{"zone": "example.com", "records": [{"name": "www.example.org.", "type": "A", "value": "192.0.2.1"}]}]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, adminEndpointBase+"diff", strings.NewReader(body))
+		err := api.handleAPIEndpoints(httptest.NewRecorder(), req)
+		var apiErr caddy.APIError
+		if !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusBadRequest {
+			t.Errorf("%s: expected a bad request, got %v", body, err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package dnsregister
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// lazyProvider defers the loading of a domain's provider to its first
+// use.
+type lazyProvider struct {
+	once sync.Once
+	load func() (any, error)
+	err  error
+}
+
+// setProvider loads the domain's DNS provider module with load and
+// checks the domain's record types against those the provider supports,
+// its record TTLs against the zone's minimum and its record comments
+// against the provider's support for them.
+func (a *App) setProvider(domain *Domain, load func() (any, error)) error {
+	val, err := load()
+	if err != nil {
+		return fmt.Errorf("loading DNS provider: %v", err)
+	}
+	if val == nil {
+		return fmt.Errorf("loading DNS provider: no provider module loaded")
+	}
+	domain.provider = val
+	domain.providerZone = a.providerZone(domain)
+
+	a.logger.Debug("loaded DNS provider",
+		append(domain.zoneFields(), zap.String("provider", providerName(val)))...)
+	if domain.providerZone != "" {
+		a.logger.Info("DNS provider uses a different name for the zone",
+			domain.zoneFields()...)
+	}
+
+	if err := a.checkSupportedTypes(domain); err != nil {
+		return err
+	}
+	a.checkMinTTL(domain)
+	a.checkCommentSupport(domain)
+	return nil
+}
+
+// ensureProvider loads the domain's provider if loading was deferred
+// with lazy_providers and hasn't happened yet. A loading error is
+// returned by every call, so it is reported by every reconcile.
+func (a *App) ensureProvider(domain *Domain) error {
+	if domain.lazy == nil {
+		return nil
+	}
+	domain.lazy.once.Do(func() {
+		domain.lazy.err = a.setProvider(domain, domain.lazy.load)
+	})
+	return domain.lazy.err
+}
+
+// providerConfigHash returns a hash of a domain's raw provider config,
+// identifying the provider account without keeping its credentials.
+// It must be taken before the provider is loaded, which clears the raw
+// config.
+func providerConfigHash(raw json.RawMessage) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// configuredProviderName returns the module ID of the domain's provider
+// as configured, for domains whose provider isn't loaded yet.
+func configuredProviderName(domain *Domain) string {
+	var raw struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(domain.DNSProviderRaw, &raw); err != nil || raw.Name == "" {
+		return ""
+	}
+	return "dns.providers." + raw.Name
+}
@@ -0,0 +1,51 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLazyProviders(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, nil, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+	app.Domains = append(app.Domains, &Domain{Zone: "broken.example"})
+
+	loads := 0
+	app.Domains[0].DNSProviderRaw = json.RawMessage(`{"name": "fake"}`)
+	app.Domains[0].lazy = &lazyProvider{load: func() (any, error) {
+		loads++
+		return provider, nil
+	}}
+	app.Domains[1].lazy = &lazyProvider{load: func() (any, error) {
+		return nil, errors.New("unknown module")
+	}}
+
+	if got := configuredProviderName(app.Domains[0]); got != "dns.providers.fake" {
+		t.Errorf("expected configured module ID before loading, got %q", got)
+	}
+	for i := 0; i < 2; i++ {
+		if err := app.reconcileDomain(app.Domains[0]); err != nil {
+			t.Fatalf("reconcileDomain failed: %v", err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("expected provider to be loaded once, got %d", loads)
+	}
+	if !hasRecord(provider.records, "www", "A", "192.0.2.1") {
+		t.Errorf("expected www to be created, got %v", provider.records)
+	}
+
+	// Loading errors are reported by every reconcile
+	for i := 0; i < 2; i++ {
+		err := app.reconcileDomain(app.Domains[1])
+		if err == nil || !strings.Contains(err.Error(), "loading DNS provider") {
+			t.Errorf("expected provider loading error, got %v", err)
+		}
+	}
+	if last := app.history.last("broken.example"); last == nil || len(last.Errors) != 1 {
+		t.Errorf("expected loading error in history, got %+v", last)
+	}
+}
@@ -0,0 +1,53 @@
+package dnsregister
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// CanonicalZoneProvider is implemented by DNS providers that map the
+// zone they are given to a different canonical name, e.g. the zone
+// that actually holds its records at the DNS host. The name is only
+// reported, in logs and the status endpoint, so that records appearing
+// under an unexpected zone at the provider can be explained.
+type CanonicalZoneProvider interface {
+	CanonicalZone(ctx context.Context, zone string) (string, error)
+}
+
+// providerZone returns the zone name the domain's provider uses for its
+// zone, if the provider reports one that differs from the configured
+// zone, and an empty string otherwise.
+func (a *App) providerZone(domain *Domain) string {
+	p, ok := domain.provider.(CanonicalZoneProvider)
+	if !ok {
+		return ""
+	}
+	ctx, cancel := a.readContext(domain)
+	zone, err := p.CanonicalZone(ctx, domain.fqdn())
+	cancel()
+	if err != nil {
+		a.logger.Warn("failed to get provider's canonical zone name",
+			zap.String("zone", domain.Zone),
+			zap.Error(err))
+		return ""
+	}
+	if zone == "" {
+		return ""
+	}
+	if normalized, err := normalizeZone(zone); err == nil && normalized == domain.Zone {
+		return ""
+	}
+	return strings.TrimSuffix(zone, ".")
+}
+
+// zoneFields returns the log fields naming the domain's zone: the
+// configured one, and the provider's name for it if that differs.
+func (d *Domain) zoneFields() []zap.Field {
+	fields := []zap.Field{zap.String("zone", d.Zone)}
+	if d.providerZone != "" {
+		fields = append(fields, zap.String("provider_zone", d.providerZone))
+	}
+	return fields
+}
@@ -0,0 +1,62 @@
+package dnsregister
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// fakeCanonicalZoneProvider reports a canonical name for every zone.
+type fakeCanonicalZoneProvider struct {
+	fakeProvider
+	zone string
+}
+
+func (p *fakeCanonicalZoneProvider) CanonicalZone(_ context.Context, _ string) (string, error) {
+	return p.zone, nil
+}
+
+func TestProviderZone(t *testing.T) {
+	for _, tc := range []struct {
+		canonical string
+		want      string
+	}{
+		{canonical: "example.com.", want: ""},
+		{canonical: "EXAMPLE.com", want: ""},
+		{canonical: "", want: ""},
+		{canonical: "example.com.cdn.example.net.", want: "example.com.cdn.example.net"},
+	} {
+		app := newTestApp(t, &fakeProvider{})
+		domain := app.Domains[0]
+		provider := &fakeCanonicalZoneProvider{zone: tc.canonical}
+		if err := app.setProvider(domain, func() (any, error) { return provider, nil }); err != nil {
+			t.Fatalf("setProvider failed: %v", err)
+		}
+		if domain.providerZone != tc.want {
+			t.Errorf("canonical zone %q: expected provider zone %q, got %q", tc.canonical, tc.want, domain.providerZone)
+		}
+	}
+}
+
+func TestAdminStatusProviderZone(t *testing.T) {
+	app := newTestApp(t, &fakeProvider{})
+	app.Domains[0].providerZone = "example.com.cdn.example.net"
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"status", nil)
+	if err := api.handleAPIEndpoints(rec, req); err != nil {
+		t.Fatalf("GET status failed: %v", err)
+	}
+	var statuses []zoneStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("decoding status: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].Zone != "example.com" || statuses[0].ProviderZone != "example.com.cdn.example.net" {
+		t.Errorf("expected configured and provider zone in status, got %+v", statuses)
+	}
+}
@@ -0,0 +1,50 @@
+package dnsregister
+
+import "sync"
+
+// readyZones records the zones whose records a reconcile has put in
+// place since the app started.
+type readyZones struct {
+	mu    sync.Mutex
+	zones map[string]bool
+}
+
+func newReadyZones() *readyZones {
+	return &readyZones{zones: make(map[string]bool)}
+}
+
+// record records the outcome of a reconcile of all of a zone's
+// records. The zone is ready once one succeeded, with no errors and no
+// changes left pending by a freeze or plan mode, and stays ready if
+// later ones fail.
+func (r *readyZones) record(result ReconcileResult, err error) {
+	if r == nil || err != nil || len(result.Errors) > 0 || len(result.Pending) > 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.zones[result.Zone] = true
+}
+
+// ready reports whether zone is ready.
+func (r *readyZones) ready(zone string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.zones[zone]
+}
+
+// readiness reports whether every managed zone is ready, and lists the
+// zones that are not. Zones whose manage_when condition is false are
+// not waited for.
+func (a *App) readiness() (bool, []string) {
+	waiting := []string{}
+	for _, domain := range a.Domains {
+		if domain.managed() && !a.ready.ready(domain.Zone) {
+			waiting = append(waiting, domain.Zone)
+		}
+	}
+	return len(waiting) == 0, waiting
+}
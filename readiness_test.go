@@ -0,0 +1,57 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestAdminReady(t *testing.T) {
+	app := newTestApp(t, &fakeProvider{}, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	other := &Domain{Zone: "example.org", provider: struct{}{}}
+	app.Domains = append(app.Domains, other)
+	app.ready = newReadyZones()
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	check := func(wantStatus int, wantWaiting ...string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"ready", nil)
+		if err := api.handleAPIEndpoints(rec, req); err != nil {
+			t.Fatalf("GET ready failed: %v", err)
+		}
+		var resp readyResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if rec.Code != wantStatus || resp.Ready != (len(wantWaiting) == 0) || !slices.Equal(resp.Waiting, wantWaiting) {
+			t.Errorf("expected status %d waiting for %v, got %d: %+v", wantStatus, wantWaiting, rec.Code, resp)
+		}
+	}
+
+	check(http.StatusServiceUnavailable, "example.com", "example.org")
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	// A failed reconcile doesn't make a zone ready
+	if err := app.reconcileDomain(other); err == nil {
+		t.Fatal("expected reconcile with a provider without RecordGetter to fail")
+	}
+	check(http.StatusServiceUnavailable, "example.org")
+
+	other.provider = &fakeProvider{}
+	if err := app.reconcileDomain(other); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	check(http.StatusOK)
+
+	// A zone stays ready after a later failure
+	other.provider = struct{}{}
+	_ = app.reconcileDomain(other)
+	check(http.StatusOK)
+}
@@ -0,0 +1,219 @@
+package dnsregister
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// pendingPlan is a reconcile plan persisted while it is being applied,
+// so that an apply interrupted by a crash can be verified on startup.
+// It is also the format of plan files written in plan mode. Created and
+// updated records are the desired records; deleted records are the
+// records present in the zone.
+type pendingPlan struct {
+	Zone    string    `json:"zone"`
+	Time    time.Time `json:"time"`
+	Create  []*Record `json:"create,omitempty"`
+	Update  []*Record `json:"update,omitempty"`
+	Delete  []*Record `json:"delete,omitempty"`
+	Release []*Record `json:"release,omitempty"`
+}
+
+// planPath returns the path of the pending plan file for a zone.
+func (a *App) planPath(zone string) string {
+	return filepath.Join(a.dataDir, "plans", a.OwnerID, strings.TrimSuffix(zone, ".")+".json")
+}
+
+// newPendingPlan returns the persisted form of plan for a zone.
+func newPendingPlan(zone string, plan *reconcilePlan) pendingPlan {
+	pending := pendingPlan{Zone: zone, Time: time.Now()}
+	for _, key := range plan.toCreate {
+		pending.Create = append(pending.Create, plan.desired[key]...)
+	}
+	for _, key := range plan.toUpdate {
+		pending.Update = append(pending.Update, plan.desired[key]...)
+	}
+	for _, key := range plan.toDelete {
+		pending.Delete = append(pending.Delete, plan.owned[key]...)
+	}
+	for _, key := range plan.toRelease {
+		pending.Release = append(pending.Release, plan.owned[key]...)
+	}
+	return pending
+}
+
+// reconcilePlan rebuilds the reconcile plan a pending plan was made from.
+func (p *pendingPlan) reconcilePlan() *reconcilePlan {
+	plan := &reconcilePlan{
+		zone:    p.Zone,
+		owned:   make(map[string][]*Record),
+		desired: make(map[string][]*Record),
+	}
+	add := func(recs []*Record, sets map[string][]*Record, keys *[]string) {
+		for _, rec := range recs {
+			key := recordKey(rec)
+			if _, seen := sets[key]; !seen {
+				*keys = append(*keys, key)
+			}
+			sets[key] = append(sets[key], rec)
+		}
+	}
+	add(p.Create, plan.desired, &plan.toCreate)
+	add(p.Update, plan.desired, &plan.toUpdate)
+	add(p.Delete, plan.owned, &plan.toDelete)
+	add(p.Release, plan.owned, &plan.toRelease)
+	return plan
+}
+
+// savePendingPlan persists plan for a zone before it is applied.
+func (a *App) savePendingPlan(zone string, plan *reconcilePlan) error {
+	if err := writeJSONFile(a.planPath(zone), newPendingPlan(zone, plan)); err != nil {
+		return fmt.Errorf("writing pending plan: %w", err)
+	}
+	return nil
+}
+
+// loadPendingPlan returns the pending plan for a zone, or nil if there
+// is none.
+func (a *App) loadPendingPlan(zone string) (*pendingPlan, error) {
+	var pending pendingPlan
+	found, err := readJSONFile(a.planPath(zone), &pending)
+	if err != nil {
+		return nil, fmt.Errorf("reading pending plan: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &pending, nil
+}
+
+// clearPendingPlan removes the pending plan for a zone.
+func (a *App) clearPendingPlan(zone string) error {
+	err := os.Remove(a.planPath(zone))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing pending plan: %w", err)
+	}
+	return nil
+}
+
+// resumePendingPlan verifies a plan left behind by an interrupted
+// reconcile. Records the plan created without their ownership marker
+// are claimed, and markers left behind by deletions are removed. The
+// regular reconcile takes care of anything the plan did not get to.
+func (a *App) resumePendingPlan(domain *Domain) error {
+	pending, err := a.loadPendingPlan(domain.Zone)
+	if err != nil || pending == nil {
+		return err
+	}
+	if err := a.ensureProvider(domain); err != nil {
+		return err
+	}
+
+	a.logger.Warn("found plan from interrupted reconcile",
+		zap.String("zone", domain.Zone),
+		zap.Time("planned_at", pending.Time),
+		zap.Int("create", len(pending.Create)),
+		zap.Int("update", len(pending.Update)),
+		zap.Int("delete", len(pending.Delete)))
+
+	getter, ok := domain.provider.(libdns.RecordGetter)
+	if !ok {
+		return fmt.Errorf("provider does not implement RecordGetter")
+	}
+	ctx, cancel := a.readContext(domain)
+	existing, err := providerGetRecords(ctx, domain, getter)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("getting existing records: %w", err)
+	}
+	existing = a.normalizeRecordTypes(domain, encodeRecordNames(domain.Zone, existing))
+	owned := a.parseOwnedRecords(domain.Zone, existing)
+
+	present := make(map[string]bool)
+	ownedMarkers := make(map[string]bool)
+	for _, rec := range existing {
+		rr := rec.RR()
+		present[rr.Name+":"+rr.Type+":"+a.extractValue(rec)] = true
+	}
+	for _, recs := range owned {
+		ownedMarkers[a.markerName(recs[0].Name, recs[0].Type)] = true
+	}
+
+	// Claim records that were created without their marker
+	var tracked map[string]bool
+	var markers []libdns.Record
+	claimed := make(map[string]bool)
+	for _, rec := range pending.Create {
+		key := recordKey(rec)
+		if _, isOwned := owned[key]; isOwned || claimed[key] || !present[key+":"+rec.Value] {
+			continue
+		}
+		claimed[key] = true
+
+		if a.isMarkerless(rec.Type) {
+			if tracked == nil {
+				if tracked, err = a.loadState(domain.Zone); err != nil {
+					return err
+				}
+			}
+			tracked[key] = true
+		} else if marker := a.markerName(rec.Name, rec.Type); !ownedMarkers[marker] {
+			markers = append(markers, a.markerAt(marker))
+			ownedMarkers[marker] = true
+		}
+
+		a.logger.Info("claimed record created by interrupted reconcile",
+			zap.String("zone", domain.Zone),
+			zap.String("record", key))
+	}
+
+	if len(markers) > 0 {
+		ctx, cancel := a.writeContext(domain)
+		if setter, ok := domain.provider.(libdns.RecordSetter); ok {
+			_, err = providerSetRecords(ctx, domain, setter, markers)
+		} else if appender, ok := domain.provider.(libdns.RecordAppender); ok {
+			_, err = providerAppendRecords(ctx, domain, appender, markers)
+		}
+		cancel()
+		if err != nil {
+			return fmt.Errorf("writing ownership markers: %w", err)
+		}
+	}
+	if tracked != nil {
+		if err := a.saveState(domain.Zone, tracked); err != nil {
+			return err
+		}
+	}
+
+	// Remove markers orphaned by deletions that removed only the record
+	if deleter, ok := domain.provider.(libdns.RecordDeleter); ok {
+		var orphaned []libdns.Record
+		seen := make(map[string]bool)
+		for _, rec := range pending.Delete {
+			marker := a.markerName(rec.Name, rec.Type)
+			if a.isMarkerless(rec.Type) || ownedMarkers[marker] || seen[marker] {
+				continue
+			}
+			seen[marker] = true
+			orphaned = append(orphaned, a.markerAt(marker))
+		}
+		if len(orphaned) > 0 {
+			ctx, cancel := a.writeContext(domain)
+			_, err := providerDeleteRecords(ctx, domain, deleter, orphaned)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("deleting orphaned markers: %w", err)
+			}
+		}
+	}
+
+	return a.clearPendingPlan(domain.Zone)
+}
@@ -0,0 +1,81 @@
+package dnsregister
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestResumePendingPlan(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		// Created by the interrupted reconcile, but without its marker
+		libdns.Address{Name: "www", IP: netip.MustParseAddr("192.0.2.1"), TTL: 300 * time.Second},
+		// Marker left behind by an interrupted delete
+		libdns.TXT{Name: "_cdr.old", Text: "owner=test-caddy,heritage=caddy-dns-register", TTL: 300 * time.Second},
+		// Unrelated record with the same name as a planned create
+		libdns.Address{Name: "manual", IP: netip.MustParseAddr("192.0.2.50"), TTL: 300 * time.Second},
+	}}
+	app := newTestApp(t, provider)
+	app.ResumeOnCrash = true
+
+	plan := &reconcilePlan{
+		owned: map[string][]*Record{
+			"old:A": {{Name: "old", Type: "A", Value: "192.0.2.9"}},
+		},
+		desired: map[string][]*Record{
+			"www:A":    {{Name: "www", Type: "A", Value: "192.0.2.1"}},
+			"api:A":    {{Name: "api", Type: "A", Value: "192.0.2.2"}},
+			"manual:A": {{Name: "manual", Type: "A", Value: "192.0.2.51"}},
+		},
+		toCreate: []string{"api:A", "manual:A", "www:A"},
+		toDelete: []string{"old:A"},
+	}
+	if err := app.savePendingPlan("example.com", plan); err != nil {
+		t.Fatalf("savePendingPlan failed: %v", err)
+	}
+
+	if err := app.resumePendingPlan(app.Domains[0]); err != nil {
+		t.Fatalf("resumePendingPlan failed: %v", err)
+	}
+
+	if !provider.has("_cdr.www", "TXT") {
+		t.Error("expected www to be claimed with a marker")
+	}
+	if provider.has("_cdr.manual", "TXT") {
+		t.Error("manual record with a different value should not be claimed")
+	}
+	if provider.has("api", "A") || provider.has("_cdr.api", "TXT") {
+		t.Error("records that were never created should be left to the regular reconcile")
+	}
+	if provider.has("_cdr.old", "TXT") {
+		t.Error("expected orphaned marker to be removed")
+	}
+
+	pending, err := app.loadPendingPlan("example.com")
+	if err != nil {
+		t.Fatalf("loadPendingPlan failed: %v", err)
+	}
+	if pending != nil {
+		t.Error("expected pending plan to be cleared")
+	}
+}
+
+func TestReconcileClearsPendingPlan(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.ResumeOnCrash = true
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	pending, err := app.loadPendingPlan("example.com")
+	if err != nil {
+		t.Fatalf("loadPendingPlan failed: %v", err)
+	}
+	if pending != nil {
+		t.Error("expected pending plan to be cleared after a completed reconcile")
+	}
+}
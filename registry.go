@@ -0,0 +1,123 @@
+package dnsregister
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// Registry formats, the format of ownership marker data.
+const (
+	registryFormatDefault     = "caddy-dns-register"
+	registryFormatExternalDNS = "external-dns"
+)
+
+// Registry layouts, where markers are written.
+const (
+	registryLayoutMarkerPrefix = "marker-prefix"
+	registryLayoutTypePrefix   = "type-prefix"
+)
+
+const (
+	externalDNSHeritage   = "external-dns"
+	externalDNSOwnerField = "external-dns/owner"
+)
+
+// externalDNS reports whether markers use the external-dns TXT registry
+// format.
+func (a *App) externalDNS() bool {
+	return a.RegistryFormat == registryFormatExternalDNS
+}
+
+// typePrefixedMarkers reports whether markers are written per record
+// set at "<type>-<name>", as newer external-dns versions do.
+func (a *App) typePrefixedMarkers() bool {
+	return a.RegistryLayout == registryLayoutTypePrefix
+}
+
+// markerName returns the name of the marker of the record set of type
+// typ at name. With the type-prefix layout, the lowercase type and a
+// hyphen are prepended to the name's first label; names where that
+// doesn't give a valid label (the apex, wildcards and names with a long
+// first label) keep their marker at "_cdr.<name>".
+func (a *App) markerName(name, typ string) string {
+	if !a.typePrefixedMarkers() || name == "@" {
+		return markerPrefix + name
+	}
+	first, _, _ := strings.Cut(name, ".")
+	if first == "*" || len(typ)+1+len(first) > maxLabelLength {
+		return markerPrefix + name
+	}
+	return strings.ToLower(typ) + "-" + name
+}
+
+// externalDNSMarkerText returns the data of this instance's markers in
+// the external-dns TXT registry format.
+func (a *App) externalDNSMarkerText() string {
+	return fmt.Sprintf("heritage=%s,%s=%s", externalDNSHeritage, externalDNSOwnerField, a.OwnerID)
+}
+
+// externalDNSFields returns the fields of an external-dns registry
+// entry translated to marker fields, so that an entry is treated as a
+// marker of its external-dns owner. It reports false if data is not an
+// external-dns registry entry.
+func externalDNSFields(data string) (map[string]string, bool) {
+	fields := parseMarker(data)
+	if fields["heritage"] != externalDNSHeritage {
+		return nil, false
+	}
+	return map[string]string{
+		"owner":    fields[externalDNSOwnerField],
+		"heritage": markerHeritage,
+	}, true
+}
+
+// externalDNSOwned finds the registry entries external-dns writes
+// outside "_cdr." names that name this instance as the owner: TXT
+// records at "<type>-<name>", owning the set of that type at the name,
+// and, as written by older external-dns versions, TXT records at the
+// record's own name, owning the name's other records. It returns the
+// owned set keys and names, and the keys of the entries themselves.
+func (a *App) externalDNSOwned(records []libdns.Record) (keys, names, entries map[string]bool) {
+	return a.externalDNSEntries(records, a.OwnerID)
+}
+
+// externalDNSEntries is like externalDNSOwned for the entries of owner,
+// or of any owner if owner is empty.
+func (a *App) externalDNSEntries(records []libdns.Record, owner string) (keys, names, entries map[string]bool) {
+	keys, names, entries = make(map[string]bool), make(map[string]bool), make(map[string]bool)
+	if !a.externalDNS() {
+		return keys, names, entries
+	}
+
+	for _, rec := range records {
+		rr := rec.RR()
+		if rr.Type != "TXT" || strings.HasPrefix(rr.Name, markerPrefix) {
+			continue
+		}
+		fields, ok := externalDNSFields(rr.Data)
+		if !ok || (owner != "" && fields["owner"] != owner) {
+			continue
+		}
+		entries[rr.Name+":"+rr.Type] = true
+
+		if prefix, name, ok := strings.Cut(rr.Name, "-"); ok && name != "" && knownRRType(strings.ToUpper(prefix)) {
+			keys[name+":"+strings.ToUpper(prefix)] = true
+			continue
+		}
+		names[rr.Name] = true
+	}
+	return keys, names, entries
+}
+
+// knownRRType reports whether typ is the mnemonic of a known record
+// type.
+func knownRRType(typ string) bool {
+	for _, name := range rrTypeNames {
+		if name == typ {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/libdns/libdns"
+)
+
+func TestReconcileExternalDNSRegistry(t *testing.T) {
+	const entry = "heritage=external-dns,external-dns/owner=test-caddy"
+	provider := &fakeProvider{records: []libdns.Record{
+		// Registry entry at <type>-<name>, as written by external-dns
+		libdns.RR{Name: "api", Type: "A", Data: "192.0.2.3"},
+		libdns.RR{Name: "a-api", Type: "TXT", Data: entry},
+		// Registry entry at the record's name, as written by older versions
+		libdns.RR{Name: "legacy", Type: "A", Data: "192.0.2.4"},
+		libdns.RR{Name: "legacy", Type: "TXT", Data: entry},
+		// Registry entry at a _cdr. name
+		libdns.RR{Name: "mail", Type: "A", Data: "192.0.2.5"},
+		libdns.RR{Name: "_cdr.mail", Type: "TXT", Data: entry},
+		// Another external-dns owner's record
+		libdns.RR{Name: "other", Type: "A", Data: "192.0.2.6"},
+		libdns.RR{Name: "a-other", Type: "TXT", Data: "heritage=external-dns,external-dns/owner=someone"},
+	}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.RegistryFormat = registryFormatExternalDNS
+
+	owned := app.parseOwnedRecords("example.com", provider.records)
+	for _, key := range []string{"api:A", "legacy:A", "mail:A"} {
+		if _, ok := owned[key]; !ok {
+			t.Errorf("expected %s to be owned, got %v", key, owned)
+		}
+	}
+	for _, key := range []string{"other:A", "a-api:TXT", "legacy:TXT"} {
+		if _, ok := owned[key]; ok {
+			t.Errorf("expected %s not to be owned, got %v", key, owned)
+		}
+	}
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !hasRecord(provider.records, "_cdr.www", "TXT", entry) {
+		t.Errorf("expected marker in the external-dns format, got %v", provider.records)
+	}
+	for _, name := range []string{"api", "legacy", "mail"} {
+		if provider.has(name, "A") {
+			t.Errorf("expected owned record %s to be deleted, got %v", name, provider.records)
+		}
+	}
+	if !provider.has("other", "A") {
+		t.Errorf("expected other owner's record to be kept, got %v", provider.records)
+	}
+}
+
+func TestRegistryFormatValidation(t *testing.T) {
+	for _, tt := range []struct {
+		registry, marker   string
+		layout, markerType string
+		wantErr            string
+	}{
+		{registry: "", wantErr: ""},
+		{registry: registryFormatDefault, wantErr: ""},
+		{registry: registryFormatExternalDNS, wantErr: ""},
+		{registry: "txt-registry", wantErr: "invalid registry_format"},
+		{registry: registryFormatExternalDNS, marker: "owner={owner};heritage={heritage}", wantErr: "can't be combined"},
+		{registry: registryFormatExternalDNS, layout: registryLayoutTypePrefix, wantErr: ""},
+		{registry: "", layout: registryLayoutTypePrefix, wantErr: "requires registry_format"},
+		{registry: registryFormatExternalDNS, layout: registryLayoutTypePrefix, markerType: "CNAME", wantErr: "requires TXT markers"},
+		{registry: registryFormatExternalDNS, layout: "per-type", wantErr: "invalid registry_layout"},
+	} {
+		ctx, cancel := caddy.NewContext(caddy.Context{Context: t.Context()})
+		app := &App{
+			LazyProviders:  true,
+			RegistryFormat: tt.registry,
+			MarkerFormat:   tt.marker,
+			RegistryLayout: tt.layout,
+			MarkerType:     tt.markerType,
+			Domains:        []*Domain{{Zone: "example.com", DNSProviderRaw: json.RawMessage(`{"name": "fake"}`)}},
+		}
+		err := app.Provision(ctx)
+		cancel()
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%q/%q: unexpected error: %v", tt.registry, tt.layout, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%q/%q/%q: expected error containing %q, got %v", tt.registry, tt.marker, tt.layout, tt.wantErr, err)
+		}
+	}
+}
+
+func TestTypePrefixedMarkerName(t *testing.T) {
+	app := &App{RegistryFormat: registryFormatExternalDNS, RegistryLayout: registryLayoutTypePrefix}
+	for _, tt := range []struct {
+		name, typ, want string
+	}{
+		{"www", "A", "a-www"},
+		{"www.sub", "AAAA", "aaaa-www.sub"},
+		{"_sip._tcp", "SRV", "srv-_sip._tcp"},
+		{"@", "A", "_cdr.@"},
+		{"*.apps", "A", "_cdr.*.apps"},
+		{strings.Repeat("x", 62), "A", "_cdr." + strings.Repeat("x", 62)},
+	} {
+		if got := app.markerName(tt.name, tt.typ); got != tt.want {
+			t.Errorf("markerName(%q, %q) = %q, want %q", tt.name, tt.typ, got, tt.want)
+		}
+	}
+
+	app.RegistryLayout = ""
+	if got := app.markerName("www", "A"); got != "_cdr.www" {
+		t.Errorf("markerName with default layout = %q, want _cdr.www", got)
+	}
+}
+
+func TestReconcileTypePrefixedRegistry(t *testing.T) {
+	const entry = "heritage=external-dns,external-dns/owner=test-caddy"
+	provider := &fakeProvider{records: []libdns.Record{
+		// Owned through a type-prefixed entry
+		libdns.RR{Name: "api", Type: "A", Data: "192.0.2.3"},
+		libdns.RR{Name: "a-api", Type: "TXT", Data: entry},
+		// Owned through a marker of the marker-prefix layout
+		libdns.RR{Name: "mail", Type: "A", Data: "192.0.2.5"},
+		libdns.RR{Name: "_cdr.mail", Type: "TXT", Data: entry},
+	}}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "www", Type: "AAAA", Value: "2001:db8::1"})
+	app.RegistryFormat = registryFormatExternalDNS
+	app.RegistryLayout = registryLayoutTypePrefix
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	for _, name := range []string{"a-www", "aaaa-www"} {
+		if !hasRecord(provider.records, name, "TXT", entry) {
+			t.Errorf("expected marker %s, got %v", name, provider.records)
+		}
+	}
+	if provider.has("_cdr.www", "TXT") {
+		t.Errorf("expected no _cdr. marker with the type-prefix layout, got %v", provider.records)
+	}
+	if provider.has("api", "A") || provider.has("a-api", "TXT") {
+		t.Errorf("expected owned record api and its marker to be deleted, got %v", provider.records)
+	}
+	if provider.has("mail", "A") {
+		t.Errorf("expected record owned through a _cdr. marker to be deleted, got %v", provider.records)
+	}
+
+	// Removing one of the sets at a name only removes its own marker
+	app.Domains[0].Records = app.Domains[0].Records[:1]
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.has("www", "AAAA") || provider.has("aaaa-www", "TXT") {
+		t.Errorf("expected AAAA set and its marker to be deleted, got %v", provider.records)
+	}
+	if !provider.has("www", "A") || !provider.has("a-www", "TXT") {
+		t.Errorf("expected A set and its marker to be kept, got %v", provider.records)
+	}
+}
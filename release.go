@@ -0,0 +1,101 @@
+package dnsregister
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// releasedKeys returns the keys of the domain's record sets configured
+// to be released.
+func (a *App) releasedKeys(domain *Domain) map[string]bool {
+	released := make(map[string]bool)
+	for _, rec := range a.patchedRecords(domain) {
+		if rec.Release {
+			released[recordKey(rec)] = true
+		}
+	}
+	return released
+}
+
+// releaseSets stops managing the owned sets the plan releases, leaving
+// their records in place: their ownership markers are deleted, and the
+// sets are recorded in result so that markerless ones are dropped from
+// the state file. A set whose marker is shared with a set that stays
+// managed is not released.
+func (a *App) releaseSets(domain *Domain, plan *reconcilePlan, result *ReconcileResult) {
+	var released, marked []string
+	var markers []libdns.Record
+	seen := make(map[string]bool)
+	for _, key := range plan.toRelease {
+		recs := plan.owned[key]
+		if a.isMarkerless(recs[0].Type) {
+			released = append(released, key)
+			continue
+		}
+		marker := a.markerName(recs[0].Name, recs[0].Type)
+		if other := a.markerSharer(plan, key, marker); other != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("release %s: not released, it shares its ownership marker with %s, which stays managed", key, other))
+			continue
+		}
+		marked = append(marked, key)
+		if !seen[marker] {
+			seen[marker] = true
+			markers = append(markers, a.markerAt(marker))
+		}
+	}
+
+	if len(markers) > 0 {
+		var err error
+		if deleter, ok := domain.provider.(libdns.RecordDeleter); ok {
+			ctx, cancel := a.writeContext(domain)
+			_, err = providerDeleteRecords(ctx, domain, deleter, markers)
+			cancel()
+		} else {
+			err = fmt.Errorf("provider does not implement RecordDeleter")
+		}
+		if err != nil {
+			a.logger.Error("failed to release records",
+				zap.String("zone", domain.Zone),
+				zap.Strings("records", marked),
+				zap.Error(err))
+			for _, key := range marked {
+				result.Errors = append(result.Errors, fmt.Sprintf("release %s: %v", key, err))
+				result.failed = append(result.failed, key)
+			}
+			marked = nil
+		}
+	}
+
+	for _, key := range append(released, marked...) {
+		a.logger.Info("released record",
+			zap.String("name", plan.owned[key][0].Name),
+			zap.String("type", plan.owned[key][0].Type))
+		result.Released = append(result.Released, key)
+	}
+}
+
+// markerSharer returns the first key, in key order, of a set of plan
+// other than key that shares the ownership marker named marker and
+// stays managed: owned and neither deleted nor released, or desired. It
+// returns "" if there is none.
+func (a *App) markerSharer(plan *reconcilePlan, key, marker string) string {
+	leaving := make(map[string]bool)
+	for _, k := range slices.Concat(plan.toDelete, plan.toRelease) {
+		leaving[k] = true
+	}
+	sharer := ""
+	for _, sets := range []map[string][]*Record{plan.owned, plan.desired} {
+		for other, recs := range sets {
+			if other == key || leaving[other] || a.isMarkerless(recs[0].Type) {
+				continue
+			}
+			if a.markerName(recs[0].Name, recs[0].Type) == marker && (sharer == "" || other < sharer) {
+				sharer = other
+			}
+		}
+	}
+	return sharer
+}
@@ -0,0 +1,115 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+func TestReconcileRelease(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "legacy", Type: "A", Data: "192.0.2.5"},
+		libdns.RR{Name: "_cdr.legacy", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "www", Type: "AAAA", Data: "2001:db8::1"},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider,
+		&Record{Name: "legacy", Type: "A", Value: "192.0.2.5", Release: true},
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		// Shares its marker with www A, which stays managed
+		&Record{Name: "www", Type: "AAAA", Value: "2001:db8::1", Release: true})
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !provider.has("legacy", "A") || provider.has("_cdr.legacy", "TXT") {
+		t.Errorf("expected legacy to be kept without its marker, got %v", provider.records)
+	}
+	if !provider.has("www", "AAAA") || !provider.has("_cdr.www", "TXT") {
+		t.Errorf("expected www to be kept with its marker, got %v", provider.records)
+	}
+
+	result := app.history.last("example.com")
+	if len(result.Released) != 1 || result.Released[0] != "legacy:A" || len(result.Deleted) != 0 {
+		t.Errorf("expected only legacy:A to be released, got %+v", result)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "release www:AAAA: not released, it shares its ownership marker with www:A") {
+		t.Errorf("expected www:AAAA not to be released, got %v", result.Errors)
+	}
+
+	owned := app.parseOwnedRecords("example.com", provider.records)
+	if _, ok := owned["legacy:A"]; ok {
+		t.Errorf("expected legacy to no longer be owned, got %v", owned)
+	}
+
+	// Released records are not created again
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.has("_cdr.legacy", "TXT") {
+		t.Errorf("expected legacy to stay released, got %v", provider.records)
+	}
+}
+
+func TestAdminRelease(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "www", Type: "AAAA", Data: "2001:db8::1"},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "other", Type: "A", Data: "192.0.2.30"},
+		libdns.RR{Name: "_cdr.other", Type: "TXT", Data: "owner=other-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider)
+	app.history = newReconcileHistory(0)
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"release?zone=example.com", nil)
+	if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); err == nil {
+		t.Error("expected GET on release to be rejected")
+	}
+
+	app.paused = new(atomic.Bool)
+	app.paused.Store(true)
+	req = httptest.NewRequest(http.MethodPost, adminEndpointBase+"release?zone=example.com", nil)
+	var apiErr caddy.APIError
+	if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusConflict {
+		t.Errorf("expected 409 while paused, got %v", err)
+	}
+	if !provider.has("_cdr.www", "TXT") {
+		t.Errorf("expected nothing to be released while paused, got %v", provider.records)
+	}
+	app.paused.Store(false)
+
+	rec := httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, adminEndpointBase+"release?zone=example.com", nil)
+	if err := api.handleAPIEndpoints(rec, req); err != nil {
+		t.Fatalf("POST release failed: %v", err)
+	}
+	var resp releaseResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.Released != 2 {
+		t.Errorf("expected 2 record sets released, got %d: %+v", resp.Released, resp.Result)
+	}
+
+	if !provider.has("www", "A") || !provider.has("www", "AAAA") || provider.has("_cdr.www", "TXT") {
+		t.Errorf("expected released records to be kept without their marker, got %v", provider.records)
+	}
+	if !provider.has("_cdr.other", "TXT") {
+		t.Errorf("expected other owners' markers to be kept, got %v", provider.records)
+	}
+	if owned := app.parseOwnedRecords("example.com", provider.records); len(owned) != 0 {
+		t.Errorf("expected nothing to be owned after release, got %v", owned)
+	}
+}
@@ -0,0 +1,55 @@
+package dnsregister
+
+import (
+	"fmt"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// replaceSet updates an owned record set on a provider that can append
+// and delete records but not set them: the old records are deleted,
+// then the desired ones appended. The set's ownership marker is deleted
+// and appended with them, unless other managed sets share it. If the
+// append fails after the delete succeeded, the set is missing from the
+// zone until it is created again by the retry of failed sets.
+func (a *App) replaceSet(domain *Domain, plan *reconcilePlan, key string, result *ReconcileResult) {
+	deleter := domain.provider.(libdns.RecordDeleter)
+	appender := domain.provider.(libdns.RecordAppender)
+	recs := plan.desired[key]
+	name, typ := recs[0].Name, recs[0].Type
+
+	old := a.toLibdnsRecords(plan.owned[key])
+	replacement := a.toLibdnsRecords(recs)
+	if marker := a.makeMarker(name, typ); !a.isMarkerless(typ) && a.markerSharer(plan, key, marker.RR().Name) == "" {
+		old = append(old, marker)
+		replacement = append(replacement, marker)
+	}
+
+	ctx, cancel := a.writeContext(domain)
+	_, err := providerDeleteRecords(ctx, domain, deleter, old)
+	cancel()
+	if err != nil {
+		a.logger.Warn("failed to update record",
+			zap.String("name", name),
+			zap.String("type", typ),
+			zap.Error(err))
+		result.Errors = append(result.Errors, fmt.Sprintf("update %s: deleting the old records: %v", key, err))
+		result.failed = append(result.failed, key)
+		return
+	}
+
+	if err := a.appendWithRetry(domain, appender, replacement); err != nil {
+		a.logger.Error("record set deleted for an update but appending its new records failed, it is missing from the zone until retried",
+			zap.String("zone", domain.Zone),
+			zap.String("name", name),
+			zap.String("type", typ),
+			zap.Error(err))
+		result.Errors = append(result.Errors, fmt.Sprintf("update %s: old records deleted, but appending the new ones failed: %v", key, err))
+		result.failed = append(result.failed, key)
+		return
+	}
+
+	a.logRecordChange("updated record", recs)
+	result.Updated = append(result.Updated, key)
+}
@@ -0,0 +1,112 @@
+package dnsregister
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeAppendOnlyProvider is a fakeProvider that can append and delete
+// records but not set them.
+type fakeAppendOnlyProvider struct {
+	fake       fakeProvider
+	failAppend bool
+}
+
+func (p *fakeAppendOnlyProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	return p.fake.GetRecords(ctx, zone)
+}
+
+func (p *fakeAppendOnlyProvider) AppendRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	if p.failAppend {
+		return nil, errors.New("rejected")
+	}
+	return p.fake.AppendRecords(ctx, zone, recs)
+}
+
+func (p *fakeAppendOnlyProvider) DeleteRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	return p.fake.DeleteRecords(ctx, zone, recs)
+}
+
+func TestReconcileUpdateWithoutSetter(t *testing.T) {
+	provider := &fakeAppendOnlyProvider{fake: fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "api", Type: "A", Data: "192.0.2.5"},
+		libdns.RR{Name: "api", Type: "AAAA", Data: "2001:db8::5"},
+		libdns.RR{Name: "_cdr.api", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.2"},
+		// Shares its marker with api AAAA, which stays as it is
+		&Record{Name: "api", Type: "A", Value: "192.0.2.6"},
+		&Record{Name: "api", Type: "AAAA", Value: "2001:db8::5"})
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.last("example.com")
+	if !slices.Equal(result.Updated, []string{"api:A", "www:A"}) || len(result.Errors) != 0 {
+		t.Errorf("expected both sets to be updated, got %+v", result)
+	}
+
+	records := provider.fake.records
+	if !hasRecord(records, "www", "A", "192.0.2.2") || hasRecord(records, "www", "A", "192.0.2.1") {
+		t.Errorf("expected www to be replaced, got %v", records)
+	}
+	if !hasRecord(records, "api", "A", "192.0.2.6") || hasRecord(records, "api", "A", "192.0.2.5") {
+		t.Errorf("expected api A to be replaced, got %v", records)
+	}
+	for _, marker := range []string{"_cdr.www", "_cdr.api"} {
+		if n := countRecords(records, marker, "TXT", "owner=test-caddy,heritage=caddy-dns-register"); n != 1 {
+			t.Errorf("expected one %s marker, got %d in %v", marker, n, records)
+		}
+	}
+
+	// Nothing left to update
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if result := app.history.last("example.com"); len(result.Updated) != 0 || len(result.Created) != 0 {
+		t.Errorf("expected the zone to be in sync, got %+v", result)
+	}
+}
+
+func TestReconcileUpdateWithoutSetterAppendFails(t *testing.T) {
+	provider := &fakeAppendOnlyProvider{fake: fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}, failAppend: true}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.2"})
+	appendRetryDelay = 0
+	t.Cleanup(func() { appendRetryDelay = defaultAppendRetryDelay })
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.last("example.com")
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "update www:A: old records deleted, but appending the new ones failed") {
+		t.Errorf("expected the failed append to be reported, got %v", result.Errors)
+	}
+	if !slices.Contains(result.failed, "www:A") {
+		t.Errorf("expected www:A to be retried, got %v", result.failed)
+	}
+	if len(provider.fake.records) != 0 {
+		t.Errorf("expected the old set and its marker to be deleted, got %v", provider.fake.records)
+	}
+
+	// The retry creates the set again
+	provider.failAppend = false
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !hasRecord(provider.fake.records, "www", "A", "192.0.2.2") || !provider.fake.has("_cdr.www", "TXT") {
+		t.Errorf("expected www to be created with its marker, got %v", provider.fake.records)
+	}
+}
@@ -0,0 +1,84 @@
+package dnsregister
+
+import (
+	"time"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// appendAttempts is the number of times an append is attempted before
+// giving up.
+const appendAttempts = 3
+
+// defaultAppendRetryDelay is the delay before the first append retry.
+// It doubles with each further retry.
+const defaultAppendRetryDelay = time.Second
+
+// appendRetryDelay is the delay before the first append retry.
+var appendRetryDelay = defaultAppendRetryDelay
+
+// appendWithRetry appends recs, retrying failed attempts. Appending is
+// not idempotent: an attempt that reported an error (e.g. a timeout) may
+// still have succeeded. Before each retry the zone is re-read and only
+// the records still missing are appended, so retries never create
+// duplicates.
+func (a *App) appendWithRetry(domain *Domain, appender libdns.RecordAppender, recs []libdns.Record) error {
+	delay := appendRetryDelay
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := a.writeContext(domain)
+		_, err := providerAppendRecords(ctx, domain, appender, recs)
+		cancel()
+		if err == nil || attempt == appendAttempts {
+			return err
+		}
+
+		a.logger.Debug("append failed, retrying",
+			zap.String("zone", domain.Zone),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+
+		select {
+		case <-time.After(delay):
+		case <-a.running.context(a.ctx, domain.Zone).Done():
+			return err
+		}
+		delay *= 2
+
+		// Only append what the failed attempt didn't
+		getter, ok := domain.provider.(libdns.RecordGetter)
+		if !ok {
+			continue
+		}
+		readCtx, readCancel := a.readContext(domain)
+		existing, getErr := providerGetRecords(readCtx, domain, getter)
+		readCancel()
+		if getErr != nil {
+			return err
+		}
+		recs = missingRecords(recs, a.normalizeRecordTypes(domain, encodeRecordNames(domain.Zone, existing)))
+		if len(recs) == 0 {
+			return nil
+		}
+	}
+}
+
+// missingRecords returns the records of recs that are not in existing,
+// compared by name, type and data.
+func missingRecords(recs, existing []libdns.Record) []libdns.Record {
+	present := make(map[libdns.RR]bool, len(existing))
+	for _, rec := range existing {
+		rr := rec.RR()
+		present[libdns.RR{Name: rr.Name, Type: rr.Type, Data: rr.Data}] = true
+	}
+
+	var missing []libdns.Record
+	for _, rec := range recs {
+		rr := rec.RR()
+		if !present[libdns.RR{Name: rr.Name, Type: rr.Type, Data: rr.Data}] {
+			missing = append(missing, rec)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,67 @@
+package dnsregister
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeAppendProvider is an append-only provider whose first appends
+// succeed but report an error, like a request that timed out after the
+// provider applied it.
+type fakeAppendProvider struct {
+	inner     fakeProvider
+	failFirst int
+	appends   int
+}
+
+func (p *fakeAppendProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	return p.inner.GetRecords(ctx, zone)
+}
+
+func (p *fakeAppendProvider) AppendRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	p.appends++
+	created, _ := p.inner.AppendRecords(ctx, zone, recs)
+	if p.appends <= p.failFirst {
+		return nil, errors.New("timeout")
+	}
+	return created, nil
+}
+
+func (p *fakeAppendProvider) DeleteRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	return p.inner.DeleteRecords(ctx, zone, recs)
+}
+
+func TestAppendRetryNoDuplicates(t *testing.T) {
+	appendRetryDelay = 0
+	t.Cleanup(func() { appendRetryDelay = defaultAppendRetryDelay })
+
+	provider := &fakeAppendProvider{failFirst: 1}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	if provider.appends != 1 {
+		t.Errorf("expected no second append once the records were found, got %d appends", provider.appends)
+	}
+	if len(provider.inner.records) != 2 {
+		t.Errorf("expected record and marker exactly once, got %d records", len(provider.inner.records))
+	}
+}
+
+func TestMissingRecords(t *testing.T) {
+	recs := []libdns.Record{
+		libdns.TXT{Name: "www", Text: "a"},
+		libdns.TXT{Name: "www", Text: "b"},
+	}
+	existing := []libdns.Record{libdns.TXT{Name: "www", Text: "a"}}
+
+	missing := missingRecords(recs, existing)
+	if len(missing) != 1 || missing[0].RR().Data != "b" {
+		t.Errorf("expected only the b record to be missing, got %v", missing)
+	}
+}
@@ -0,0 +1,86 @@
+package dnsregister
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// rrTypeNames maps numeric RR type codes to their mnemonics, for
+// providers that report record types by number.
+var rrTypeNames = map[uint16]string{
+	1:   "A",
+	2:   "NS",
+	5:   "CNAME",
+	6:   "SOA",
+	12:  "PTR",
+	15:  "MX",
+	16:  "TXT",
+	28:  "AAAA",
+	33:  "SRV",
+	35:  "NAPTR",
+	39:  "DNAME",
+	43:  "DS",
+	44:  "SSHFP",
+	46:  "RRSIG",
+	47:  "NSEC",
+	48:  "DNSKEY",
+	52:  "TLSA",
+	64:  "SVCB",
+	65:  "HTTPS",
+	257: "CAA",
+}
+
+// rrTypeName returns the mnemonic of an RR type as reported by a
+// provider, which may be a mnemonic in any case, a number ("16") or the
+// generic form of RFC 3597 ("TYPE16"). It reports false for an empty
+// type or an unknown number.
+func rrTypeName(typ string) (string, bool) {
+	typ = strings.ToUpper(strings.TrimSpace(typ))
+	if typ == "" {
+		return "", false
+	}
+
+	digits := strings.TrimPrefix(typ, "TYPE")
+	code, err := strconv.ParseUint(digits, 10, 16)
+	if err != nil {
+		// Not a number, so already a mnemonic
+		return typ, true
+	}
+	name, ok := rrTypeNames[uint16(code)]
+	return name, ok
+}
+
+// normalizeRecordTypes returns records with their types in mnemonic
+// form, parsed into the matching libdns type where possible so that
+// their values are compared like those of any other record. Records
+// whose type is empty or unknown are skipped, since they can't be
+// matched against config, and logged.
+func (a *App) normalizeRecordTypes(domain *Domain, records []libdns.Record) []libdns.Record {
+	normalized := make([]libdns.Record, 0, len(records))
+	for _, rec := range records {
+		rr := rec.RR()
+		typ, ok := rrTypeName(rr.Type)
+		if !ok {
+			a.logger.Warn("skipping record of unknown type",
+				zap.String("zone", domain.Zone),
+				zap.String("name", rr.Name),
+				zap.String("type", rr.Type))
+			continue
+		}
+		if typ == rr.Type {
+			normalized = append(normalized, rec)
+			continue
+		}
+
+		rr.Type = typ
+		if parsed, err := rr.Parse(); err == nil {
+			normalized = append(normalized, parsed)
+		} else {
+			normalized = append(normalized, rr)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,53 @@
+package dnsregister
+
+import (
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestRRTypeName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"A", "A", true},
+		{"txt", "TXT", true},
+		{"1", "A", true},
+		{"28", "AAAA", true},
+		{"TYPE16", "TXT", true},
+		{"", "", false},
+		{"65000", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := rrTypeName(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("rrTypeName(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestReconcileNumericRecordTypes(t *testing.T) {
+	// The provider reports types by number; one record has no type
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "1", Data: "192.0.2.1"},
+		libdns.RR{Name: "_cdr.www", Type: "16", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "old", Type: "TYPE1", Data: "192.0.2.2"},
+		libdns.RR{Name: "_cdr.old", Type: "16", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		libdns.RR{Name: "odd", Type: "", Data: "?"},
+	}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.last("example.com")
+	if len(result.Created) != 0 || len(result.Updated) != 0 {
+		t.Errorf("expected www to be recognized as in sync, got %+v", result)
+	}
+	if len(result.Deleted) != 1 || result.Deleted[0] != "old:A" {
+		t.Errorf("expected owned record with numeric type to be deleted, got %+v", result.Deleted)
+	}
+}
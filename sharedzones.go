@@ -0,0 +1,78 @@
+package dnsregister
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// checkSharedZones checks that no two domains manage the same zone at
+// the provider: the same configured zone, or zones their providers
+// report the same canonical name for. Each domain would take the
+// other's records, marked with the same owner, for its own and delete
+// or rewrite them on every reconcile. A configured zone repeated is an
+// error, as is a shared canonical zone with overlapping record names;
+// otherwise a shared canonical zone is only warned about. With
+// lazy_providers, canonical names are not known yet and only the
+// configured zones are compared.
+func (a *App) checkSharedZones() error {
+	byZone := make(map[string]*Domain)
+	for _, domain := range a.Domains {
+		zone := domain.Zone
+		if domain.providerZone != "" {
+			if canonical, err := normalizeZone(domain.providerZone); err == nil {
+				zone = canonical
+			}
+		}
+		other, ok := byZone[zone]
+		if !ok {
+			byZone[zone] = domain
+			continue
+		}
+
+		overlap := overlappingRecords(other, domain)
+		if other.Zone == domain.Zone {
+			if len(overlap) > 0 {
+				return fmt.Errorf("domain %s: zone configured more than once, with overlapping records %s",
+					domain.Zone, strings.Join(overlap, ", "))
+			}
+			return fmt.Errorf("domain %s: zone configured more than once", domain.Zone)
+		}
+		if len(overlap) > 0 {
+			return fmt.Errorf("domain %s: provider zone %s is also managed by domain %s, with overlapping records %s",
+				domain.Zone, zone, other.Zone, strings.Join(overlap, ", "))
+		}
+		a.logger.Warn("domains share a provider zone, and may take each other's records for their own",
+			zap.String("zone", domain.Zone),
+			zap.String("other_zone", other.Zone),
+			zap.String("provider_zone", zone))
+	}
+	return nil
+}
+
+// overlappingRecords returns the fully-qualified names and types, as
+// <name>:<type>, of the record sets configured in both domains.
+func overlappingRecords(a, b *Domain) []string {
+	sets := func(domain *Domain) map[string]bool {
+		keys := make(map[string]bool)
+		for _, rec := range domain.Records {
+			name := domain.Zone
+			if rec.Name != "@" {
+				name = rec.Name + "." + domain.Zone
+			}
+			keys[strings.ToLower(name)+":"+rec.Type] = true
+		}
+		return keys
+	}
+	inA := sets(a)
+	var overlap []string
+	for key := range sets(b) {
+		if inA[key] {
+			overlap = append(overlap, key)
+		}
+	}
+	sort.Strings(overlap)
+	return overlap
+}
@@ -0,0 +1,56 @@
+package dnsregister
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"go.uber.org/zap"
+)
+
+func TestProvisionSharedZone(t *testing.T) {
+	d := caddyfile.NewTestDispenser(`dns_register {
+		lazy_providers
+		domain example.com {
+			dns fake
+			record www A 192.0.2.1
+			record api A 192.0.2.2
+		}
+		domain example.com {
+			dns fake
+			record www A 192.0.2.9
+			record mail A 192.0.2.3
+		}
+	}`)
+	app := &App{}
+	if err := app.UnmarshalCaddyfile(d); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: t.Context()})
+	defer cancel()
+	err := app.Provision(ctx)
+	if err == nil || !strings.Contains(err.Error(), "more than once, with overlapping records www.example.com:A") {
+		t.Errorf("expected duplicate zone error naming www, got %v", err)
+	}
+}
+
+func TestCheckSharedProviderZone(t *testing.T) {
+	app := &App{logger: zap.NewNop(), Domains: []*Domain{
+		{Zone: "example.com", Records: []*Record{{Name: "www.eu", Type: "A", Value: "192.0.2.1"}}},
+		{Zone: "eu.example.com", providerZone: "example.com.", Records: []*Record{{Name: "api", Type: "A", Value: "192.0.2.2"}}},
+	}}
+
+	// Zones the provider maps to the same one are only warned about
+	if err := app.checkSharedZones(); err != nil {
+		t.Errorf("expected no error without overlapping records, got %v", err)
+	}
+
+	// With overlapping records they'd fight over them
+	app.Domains[1].Records = append(app.Domains[1].Records, &Record{Name: "www", Type: "A", Value: "192.0.2.3"})
+	err := app.checkSharedZones()
+	if err == nil || !strings.Contains(err.Error(), "also managed by domain example.com, with overlapping records www.eu.example.com:A") {
+		t.Errorf("expected shared provider zone error naming www.eu, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package dnsregister
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// SOA serial formats for bump_soa_serial.
+const (
+	soaSerialCounter = "counter"
+	soaSerialDate    = "date"
+)
+
+// soaSerialField is the index of the serial among the fields of SOA
+// data: mname, rname, serial, refresh, retry, expire and minimum.
+const soaSerialField = 2
+
+// soaSerial returns the serial of SOA data.
+func soaSerial(data string) (uint32, error) {
+	fields := strings.Fields(data)
+	if len(fields) != 7 {
+		return 0, fmt.Errorf("malformed SOA data: %q", data)
+	}
+	serial, err := strconv.ParseUint(fields[soaSerialField], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("malformed SOA serial: %q", fields[soaSerialField])
+	}
+	return uint32(serial), nil
+}
+
+// withSOASerial returns SOA data with its serial replaced.
+func withSOASerial(data string, serial uint32) string {
+	fields := strings.Fields(data)
+	fields[soaSerialField] = strconv.FormatUint(uint64(serial), 10)
+	return strings.Join(fields, " ")
+}
+
+// nextSOASerial returns the serial following serial in format: one more
+// with counter serials, and with date serials (YYYYMMDDnn) the first of
+// the day of now, or one more if that isn't higher. Serials never
+// decrease; one that can't be raised any further is an error.
+func nextSOASerial(serial uint32, format string, now time.Time) (uint32, error) {
+	next := uint64(serial) + 1
+	if format == soaSerialDate {
+		year, month, day := now.Date()
+		next = max(next, uint64(year*1000000+int(month)*10000+day*100))
+	}
+	if next > math.MaxUint32 {
+		return 0, fmt.Errorf("SOA serial %d can't be raised any further", serial)
+	}
+	return uint32(next), nil
+}
+
+// keepSOASerial keeps the zone's SOA serial from going back when the
+// domain's SOA is configured and its serial bumped: the desired SOA
+// takes the existing serial if that is higher than the configured one,
+// so that only the other values of the configured SOA are applied.
+func (a *App) keepSOASerial(domain *Domain, desired, owned map[string][]*Record) {
+	const key = "@:SOA"
+	if domain.BumpSOASerial == "" || len(desired[key]) != 1 || len(owned[key]) != 1 {
+		return
+	}
+	want, err := soaSerial(desired[key][0].Value)
+	if err != nil {
+		return
+	}
+	have, err := soaSerial(owned[key][0].Value)
+	if err != nil || have <= want {
+		return
+	}
+	rec := *desired[key][0]
+	rec.Value = withSOASerial(rec.Value, have)
+	desired[key] = []*Record{&rec}
+}
+
+// bumpSOASerial raises the serial of the domain's SOA record after a
+// reconcile changed records of the zone, so that secondaries pick up
+// the change. It is done at most once per reconcile. Failures are
+// recorded in result.
+func (a *App) bumpSOASerial(domain *Domain, result *ReconcileResult) {
+	if domain.BumpSOASerial == "" {
+		return
+	}
+	if len(result.Created)+len(result.Updated)+len(result.Deleted)+len(result.Released) == 0 {
+		return
+	}
+	if err := a.writeSOASerial(domain); err != nil {
+		a.logger.Warn("failed to bump SOA serial",
+			zap.String("zone", domain.Zone),
+			zap.Error(err))
+		result.Errors = append(result.Errors, fmt.Sprintf("bump SOA serial: %v", err))
+	}
+}
+
+// writeSOASerial reads the domain's SOA record and writes it back with
+// the next serial.
+func (a *App) writeSOASerial(domain *Domain) error {
+	getter, ok := domain.provider.(libdns.RecordGetter)
+	if !ok {
+		return fmt.Errorf("provider does not implement RecordGetter")
+	}
+	setter, ok := domain.provider.(libdns.RecordSetter)
+	if !ok {
+		return fmt.Errorf("provider does not implement RecordSetter")
+	}
+
+	ctx, cancel := a.readContext(domain)
+	existing, err := providerGetRecords(ctx, domain, getter)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("getting SOA record: %w", err)
+	}
+	var soa *libdns.RR
+	for _, rec := range encodeRecordNames(domain.Zone, existing) {
+		if rr := rec.RR(); rr.Name == "@" && rr.Type == "SOA" {
+			soa = &rr
+			break
+		}
+	}
+	if soa == nil {
+		return fmt.Errorf("zone has no SOA record")
+	}
+
+	serial, err := soaSerial(soa.Data)
+	if err != nil {
+		return err
+	}
+	next, err := nextSOASerial(serial, domain.BumpSOASerial, time.Now())
+	if err != nil {
+		return err
+	}
+	bumped := *soa
+	bumped.Data = withSOASerial(soa.Data, next)
+
+	defer a.cache.invalidate(domain)
+	ctx, cancel = a.writeContext(domain)
+	defer cancel()
+	if _, err := providerSetRecords(ctx, domain, setter, []libdns.Record{bumped}); err != nil {
+		return fmt.Errorf("writing SOA record: %w", err)
+	}
+	a.logger.Info("bumped SOA serial",
+		zap.String("zone", domain.Zone),
+		zap.Uint32("from", serial),
+		zap.Uint32("to", next))
+	return nil
+}
@@ -0,0 +1,92 @@
+package dnsregister
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeSOAProvider counts writes of the zone's SOA record.
+type fakeSOAProvider struct {
+	fakeProvider
+	soaWrites int
+}
+
+func (p *fakeSOAProvider) SetRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	for _, rec := range recs {
+		if rec.RR().Type == "SOA" {
+			p.soaWrites++
+		}
+	}
+	return p.fakeProvider.SetRecords(ctx, zone, recs)
+}
+
+func TestNextSOASerial(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		serial uint32
+		format string
+		want   uint32
+	}{
+		{serial: 41, format: soaSerialCounter, want: 42},
+		{serial: 2026101400, format: soaSerialDate, want: 2026101500},
+		{serial: 2026101503, format: soaSerialDate, want: 2026101504},
+		{serial: 2026101599, format: soaSerialDate, want: 2026101600},
+		// A counter serial lower than the date form moves to it
+		{serial: 7, format: soaSerialDate, want: 2026101500},
+	} {
+		got, err := nextSOASerial(tc.serial, tc.format, now)
+		if err != nil || got != tc.want {
+			t.Errorf("nextSOASerial(%d, %s) = %d, %v; expected %d", tc.serial, tc.format, got, err, tc.want)
+		}
+	}
+	if _, err := nextSOASerial(4294967295, soaSerialCounter, now); err == nil {
+		t.Error("expected error for a serial that can't be raised")
+	}
+}
+
+func TestReconcileBumpSOASerial(t *testing.T) {
+	provider := &fakeSOAProvider{fakeProvider: fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "@", Type: "SOA", Data: "ns1.provider.net. hostmaster.example.com. 5 7200 3600 1209600 300"},
+	}}}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "api", Type: "A", Value: "192.0.2.2"})
+	app.history = newReconcileHistory(0)
+	domain := app.Domains[0]
+	domain.BumpSOASerial = soaSerialCounter
+
+	// Changes bump the serial once
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.soaWrites != 1 || !hasRecord(provider.records, "@", "SOA", "ns1.provider.net. hostmaster.example.com. 6 7200 3600 1209600 300") {
+		t.Errorf("expected one bump to serial 6, got %d writes: %v", provider.soaWrites, provider.records)
+	}
+
+	// Without changes it is left alone
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.soaWrites != 1 {
+		t.Errorf("expected no bump without changes, got %d writes", provider.soaWrites)
+	}
+
+	// A configured SOA doesn't take the serial back, and its other
+	// values are applied along with a single bump
+	domain.Records = append(domain.Records, &Record{Name: "@", Type: "SOA", Value: "ns1.provider.net. hostmaster.example.com. 1 3600 3600 1209600 300"})
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.soaWrites != 3 || !hasRecord(provider.records, "@", "SOA", "ns1.provider.net. hostmaster.example.com. 7 3600 3600 1209600 300") {
+		t.Errorf("expected the SOA updated then bumped to serial 7, got %d writes: %v", provider.soaWrites, provider.records)
+	}
+	if err := app.reconcileDomain(domain); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if result := app.history.last("example.com"); len(result.Updated) != 0 || provider.soaWrites != 3 {
+		t.Errorf("expected the bumped serial to be kept, got %+v", result)
+	}
+}
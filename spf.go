@@ -0,0 +1,62 @@
+package dnsregister
+
+import (
+	"strings"
+)
+
+// spfVersion is the version tag every SPF record starts with.
+const spfVersion = "v=spf1"
+
+// mergeSPF assembles the SPF records of a TXT set into a single
+// "v=spf1" record. The mechanisms of all SPF records are joined in
+// order with duplicates removed; the first "all" mechanism and the
+// first redirect modifier are moved to the end. Records not marked SPF
+// are returned unchanged. The merged record takes the name and type of
+// the first SPF record and the first TTL set among them.
+func mergeSPF(recs []*Record) []*Record {
+	var merged *Record
+	var mechanisms, terminal []string
+	seen := make(map[string]bool)
+	var others []*Record
+
+	for _, rec := range recs {
+		if !rec.SPF {
+			others = append(others, rec)
+			continue
+		}
+		if merged == nil {
+			merged = &Record{Name: rec.Name, Type: rec.Type}
+		}
+		if merged.TTL == 0 {
+			merged.TTL = rec.TTL
+		}
+
+		for _, term := range strings.Fields(strings.Trim(rec.Value, "\"")) {
+			if strings.EqualFold(term, spfVersion) || seen[strings.ToLower(term)] {
+				continue
+			}
+			seen[strings.ToLower(term)] = true
+
+			if isSPFTerminal(term) {
+				if len(terminal) == 0 {
+					terminal = append(terminal, term)
+				}
+				continue
+			}
+			mechanisms = append(mechanisms, term)
+		}
+	}
+	if merged == nil {
+		return recs
+	}
+
+	merged.Value = strings.Join(append(append([]string{spfVersion}, mechanisms...), terminal...), " ")
+	return append([]*Record{merged}, others...)
+}
+
+// isSPFTerminal reports whether an SPF term must come last: an "all"
+// mechanism with any qualifier, or a redirect modifier.
+func isSPFTerminal(term string) bool {
+	term = strings.ToLower(term)
+	return strings.TrimLeft(term, "+-~?") == "all" || strings.HasPrefix(term, "redirect=")
+}
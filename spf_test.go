@@ -0,0 +1,49 @@
+package dnsregister
+
+import "testing"
+
+func TestMergeSPF(t *testing.T) {
+	recs := mergeSPF([]*Record{
+		{Name: "@", Type: "TXT", Value: "v=spf1 include:_spf.google.com ~all", SPF: true},
+		{Name: "@", Type: "TXT", Value: "google-site-verification=abc"},
+		{Name: "@", Type: "TXT", Value: "include:mailgun.org include:_spf.google.com -all", SPF: true, TTL: 600},
+		{Name: "@", Type: "TXT", Value: "ip4:192.0.2.0/24", SPF: true},
+	})
+
+	if len(recs) != 2 {
+		t.Fatalf("expected merged SPF record plus the other TXT record, got %d", len(recs))
+	}
+	want := "v=spf1 include:_spf.google.com include:mailgun.org ip4:192.0.2.0/24 ~all"
+	if recs[0].Value != want {
+		t.Errorf("merged value = %q, want %q", recs[0].Value, want)
+	}
+	if recs[0].TTL != 600 {
+		t.Errorf("expected first set TTL to be used, got %d", recs[0].TTL)
+	}
+	if recs[1].Value != "google-site-verification=abc" {
+		t.Errorf("expected non-SPF record to be kept, got %q", recs[1].Value)
+	}
+}
+
+func TestReconcileSPF(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider,
+		&Record{Name: "@", Type: "TXT", Value: "include:_spf.google.com", SPF: true},
+		&Record{Name: "@", Type: "TXT", Value: "include:mailgun.org ~all", SPF: true},
+	)
+	app.history = newReconcileHistory(0)
+
+	for i := 0; i < 2; i++ {
+		if err := app.reconcileDomain(app.Domains[0]); err != nil {
+			t.Fatalf("reconcileDomain failed: %v", err)
+		}
+	}
+
+	owned := app.parseOwnedRecords("example.com", provider.records)
+	if spf := owned["@:TXT"]; len(spf) != 1 || spf[0].Value != "v=spf1 include:_spf.google.com include:mailgun.org ~all" {
+		t.Errorf("expected one assembled SPF record, got %+v", spf)
+	}
+	if result := app.history.get("example.com")[1]; len(result.Updated) != 0 || len(result.Created) != 0 {
+		t.Errorf("expected the assembled record to compare equal, got %+v", result)
+	}
+}
@@ -0,0 +1,98 @@
+package dnsregister
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// srvLookupTimeout bounds the SRV and address lookups for a single
+// from_srv service.
+const srvLookupTimeout = 5 * time.Second
+
+// srvResolver is the subset of *net.Resolver used to resolve from_srv
+// records.
+type srvResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// srvLookup is the outcome of looking up the addresses of an SRV
+// service's targets, of both address families.
+type srvLookup struct {
+	addrs []netip.Addr
+	err   error
+}
+
+// srvLookups holds the SRV lookups of a single resolution of a domain's
+// records, keyed by service. The A and AAAA records of a dual-stack
+// name resolved from the same service share one lookup, so both are
+// resolved from the same answer and change in the same reconcile.
+type srvLookups map[string]*srvLookup
+
+// lookupSRV looks up the SRV targets of service and their addresses,
+// of both families.
+func (a *App) lookupSRV(service string) *srvLookup {
+	ctx, cancel := context.WithTimeout(a.ctx, srvLookupTimeout)
+	defer cancel()
+
+	_, srvs, err := a.resolver.LookupSRV(ctx, "", "", service)
+	if err != nil {
+		return &srvLookup{err: fmt.Errorf("looking up SRV %s: %w", service, err)}
+	}
+
+	lookup := new(srvLookup)
+	seen := make(map[netip.Addr]bool)
+	for _, srv := range srvs {
+		addrs, err := a.resolver.LookupNetIP(ctx, "ip", srv.Target)
+		if err != nil {
+			return &srvLookup{err: fmt.Errorf("resolving SRV target %s: %w", srv.Target, err)}
+		}
+		for _, addr := range addrs {
+			addr = addr.Unmap()
+			if seen[addr] {
+				continue
+			}
+			seen[addr] = true
+			lookup.addrs = append(lookup.addrs, addr)
+		}
+	}
+	return lookup
+}
+
+// resolveFromSRV looks up the SRV targets of rec.FromSRV and returns one
+// record per target address matching the record's address family.
+// Lookups are reused from and added to lookups, if not nil.
+func (a *App) resolveFromSRV(rec *Record, lookups srvLookups) ([]*Record, error) {
+	lookup := lookups[rec.FromSRV]
+	if lookup == nil {
+		lookup = a.lookupSRV(rec.FromSRV)
+		if lookups != nil {
+			lookups[rec.FromSRV] = lookup
+		}
+	}
+	if lookup.err != nil {
+		return nil, lookup.err
+	}
+
+	var resolved []*Record
+	for _, addr := range lookup.addrs {
+		if addr.Is4() != (rec.Type == "A") {
+			continue
+		}
+		resolved = append(resolved, &Record{
+			Name:  rec.Name,
+			Type:  rec.Type,
+			Value: addr.String(),
+			TTL:   rec.TTL,
+		})
+	}
+
+	if len(resolved) == 0 {
+		return nil, fmt.Errorf("SRV %s has no %s targets", rec.FromSRV, rec.Type)
+	}
+
+	return resolved, nil
+}
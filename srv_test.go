@@ -0,0 +1,118 @@
+package dnsregister
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+type fakeResolver struct {
+	srvs  map[string][]*net.SRV
+	addrs map[string][]netip.Addr
+}
+
+func (f fakeResolver) LookupSRV(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+	srvs, ok := f.srvs[name]
+	if !ok {
+		return "", nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return name, srvs, nil
+}
+
+func (f fakeResolver) LookupNetIP(_ context.Context, network, host string) ([]netip.Addr, error) {
+	var addrs []netip.Addr
+	for _, addr := range f.addrs[host] {
+		if network == "ip" || (network == "ip4") == addr.Is4() {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs, nil
+}
+
+func TestResolveFromSRV(t *testing.T) {
+	app := &App{
+		ctx: context.Background(),
+		resolver: fakeResolver{
+			srvs: map[string][]*net.SRV{
+				"_http._tcp.internal": {
+					{Target: "node1.internal.", Port: 80},
+					{Target: "node2.internal.", Port: 80},
+				},
+			},
+			addrs: map[string][]netip.Addr{
+				"node1.internal.": {netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("2001:db8::1")},
+				"node2.internal.": {netip.MustParseAddr("10.0.0.2")},
+			},
+		},
+	}
+
+	recs, err := app.resolveFromSRV(&Record{Name: "app", Type: "A", FromSRV: "_http._tcp.internal", TTL: 60}, nil)
+	if err != nil {
+		t.Fatalf("resolveFromSRV failed: %v", err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(recs))
+	}
+	if recs[0].Value != "10.0.0.1" || recs[1].Value != "10.0.0.2" {
+		t.Errorf("unexpected values: %q, %q", recs[0].Value, recs[1].Value)
+	}
+	if recs[0].Name != "app" || recs[0].TTL != 60 {
+		t.Errorf("resolved record lost name or TTL: %+v", recs[0])
+	}
+
+	recs, err = app.resolveFromSRV(&Record{Name: "app", Type: "AAAA", FromSRV: "_http._tcp.internal"}, nil)
+	if err != nil {
+		t.Fatalf("resolveFromSRV (AAAA) failed: %v", err)
+	}
+	if len(recs) != 1 || recs[0].Value != "2001:db8::1" {
+		t.Errorf("unexpected AAAA records: %+v", recs)
+	}
+
+	if _, err := app.resolveFromSRV(&Record{Name: "app", Type: "A", FromSRV: "_missing._tcp.internal"}, nil); err == nil {
+		t.Error("expected error for missing SRV")
+	}
+}
+
+// fakeRotatingResolver answers each SRV lookup with the next target
+// in turn, as for a service whose instances change between lookups.
+type fakeRotatingResolver struct {
+	fakeResolver
+	targets []string
+	lookups *int
+}
+
+func (f fakeRotatingResolver) LookupSRV(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+	target := f.targets[*f.lookups%len(f.targets)]
+	*f.lookups++
+	return name, []*net.SRV{{Target: target, Port: 80}}, nil
+}
+
+func TestResolveFromSRVDualStack(t *testing.T) {
+	lookups := 0
+	app := newTestApp(t, nil,
+		&Record{Name: "app", Type: "A", FromSRV: "_http._tcp.internal"},
+		&Record{Name: "app", Type: "AAAA", FromSRV: "_http._tcp.internal"},
+	)
+	app.resolver = fakeRotatingResolver{
+		fakeResolver: fakeResolver{addrs: map[string][]netip.Addr{
+			"old.internal.": {netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("2001:db8::1")},
+			"new.internal.": {netip.MustParseAddr("10.0.0.2"), netip.MustParseAddr("2001:db8::2")},
+		}},
+		targets: []string{"old.internal.", "new.internal."},
+		lookups: &lookups,
+	}
+
+	// Both families are resolved from one lookup, so they agree
+	desired, failed := app.desiredRecords(app.Domains[0])
+	if len(failed) != 0 {
+		t.Fatalf("unexpected failures: %v", failed)
+	}
+	if lookups != 1 {
+		t.Errorf("expected 1 SRV lookup, got %d", lookups)
+	}
+	a, aaaa := desired["app:A"], desired["app:AAAA"]
+	if len(a) != 1 || a[0].Value != "10.0.0.1" || len(aaaa) != 1 || aaaa[0].Value != "2001:db8::1" {
+		t.Errorf("expected both families from the same answer, got %+v and %+v", a, aaaa)
+	}
+}
@@ -0,0 +1,81 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// zoneState is the ownership state persisted for a zone. It tracks
+// records that have no ownership marker in DNS.
+type zoneState struct {
+	Records []string `json:"records"`
+}
+
+// statePath returns the path of the state file for a zone.
+func (a *App) statePath(zone string) string {
+	return filepath.Join(a.dataDir, "state", a.OwnerID, strings.TrimSuffix(zone, ".")+".json")
+}
+
+// loadState returns the set of record keys tracked in the state file
+// for a zone. A missing state file yields an empty set.
+func (a *App) loadState(zone string) (map[string]bool, error) {
+	keys := make(map[string]bool)
+
+	var state zoneState
+	if _, err := readJSONFile(a.statePath(zone), &state); err != nil {
+		return nil, fmt.Errorf("reading state: %w", err)
+	}
+	for _, key := range state.Records {
+		keys[key] = true
+	}
+	return keys, nil
+}
+
+// saveState writes the set of tracked record keys for a zone.
+func (a *App) saveState(zone string, keys map[string]bool) error {
+	state := zoneState{Records: make([]string, 0, len(keys))}
+	for key := range keys {
+		state.Records = append(state.Records, key)
+	}
+	sort.Strings(state.Records)
+
+	if err := writeJSONFile(a.statePath(zone), state); err != nil {
+		return fmt.Errorf("writing state: %w", err)
+	}
+	return nil
+}
+
+// readJSONFile decodes the JSON file at path into v. It reports false
+// without error if the file does not exist.
+func readJSONFile(path string, v any) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// writeJSONFile encodes v as JSON to path, creating parent directories
+// as needed.
+func writeJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,213 @@
+package dnsregister
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Condition types reported for each zone.
+const (
+	conditionReady    = "Ready"
+	conditionDegraded = "Degraded"
+)
+
+// StatusCondition describes one aspect of a zone's reconcile status,
+// shaped like a Kubernetes status condition so that tooling built for
+// those can consume it.
+type StatusCondition struct {
+	// Type is "Ready" or "Degraded".
+	Type string `json:"type"`
+
+	// Status is "True", "False" or "Unknown".
+	Status string `json:"status"`
+
+	// Reason is a CamelCase identifier of why the condition has its
+	// status, and Message a human-readable explanation.
+	Reason  string `json:"reason"`
+	Message string `json:"message,omitempty"`
+
+	// LastTransitionTime is when Status last changed.
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// ZoneConditions are the status conditions of a zone.
+type ZoneConditions struct {
+	Zone       string            `json:"zone"`
+	Conditions []StatusCondition `json:"conditions"`
+}
+
+// resultConditions builds the conditions of a zone from the result of
+// its last reconcile. Ready is true if the zone is in sync: the
+// reconcile had no errors and left no changes pending. Degraded is true
+// if it had errors.
+func resultConditions(result ReconcileResult) []StatusCondition {
+	ready := StatusCondition{Type: conditionReady, Status: "True", Reason: "InSync", Message: "all managed records are in sync"}
+	degraded := StatusCondition{Type: conditionDegraded, Status: "False", Reason: "NoErrors"}
+
+	if n := len(result.Errors); n > 0 {
+		message := result.Errors[0]
+		if n > 1 {
+			message = fmt.Sprintf("%s (and %d more errors)", message, n-1)
+		}
+		ready.Status, ready.Reason, ready.Message = "False", "ReconcileFailed", message
+		degraded.Status, degraded.Reason, degraded.Message = "True", "ReconcileErrors", message
+	} else if n := len(result.Pending); n > 0 {
+		ready.Status, ready.Reason = "False", "ChangesPending"
+		ready.Message = fmt.Sprintf("%d changes awaiting approval in plan mode", n)
+		if result.Frozen {
+			ready.Reason = "ChangesFrozen"
+			ready.Message = fmt.Sprintf("%d changes held back by a change freeze", n)
+		}
+	}
+	return []StatusCondition{ready, degraded}
+}
+
+// unknownConditions are the conditions of a zone not yet reconciled.
+func unknownConditions() []StatusCondition {
+	return []StatusCondition{
+		{Type: conditionReady, Status: "Unknown", Reason: "NotReconciled", Message: "the zone has not been reconciled yet"},
+		{Type: conditionDegraded, Status: "Unknown", Reason: "NotReconciled", Message: "the zone has not been reconciled yet"},
+	}
+}
+
+// zoneConditionStore holds the current status conditions per zone.
+type zoneConditionStore struct {
+	mu    sync.Mutex
+	zones map[string][]StatusCondition
+
+	// pending are the changed conditions waiting to be posted to the
+	// status webhook, oldest first, and sending whether a goroutine is
+	// posting them.
+	pending []ZoneConditions
+	sending bool
+}
+
+func newZoneConditionStore() *zoneConditionStore {
+	return &zoneConditionStore{zones: make(map[string][]StatusCondition)}
+}
+
+// update replaces the zone's conditions with those built from result,
+// keeping the transition time of conditions whose status is unchanged.
+// It returns the new conditions and whether the status or reason of
+// any of them changed. Changed conditions are queued to be posted with
+// post, if it is set, after those changed before them.
+func (s *zoneConditionStore) update(result ReconcileResult, post func(ZoneConditions)) ([]StatusCondition, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := make(map[string]StatusCondition)
+	for _, cond := range s.zones[result.Zone] {
+		prev[cond.Type] = cond
+	}
+	conds := resultConditions(result)
+	changed := false
+	for i := range conds {
+		old, ok := prev[conds[i].Type]
+		if ok && old.Status == conds[i].Status {
+			conds[i].LastTransitionTime = old.LastTransitionTime
+		} else {
+			conds[i].LastTransitionTime = result.Time
+		}
+		if !ok || old.Status != conds[i].Status || old.Reason != conds[i].Reason {
+			changed = true
+		}
+	}
+	s.zones[result.Zone] = conds
+	if changed && post != nil {
+		s.pending = append(s.pending, ZoneConditions{Zone: result.Zone, Conditions: conds})
+		if !s.sending {
+			s.sending = true
+			go s.send(post)
+		}
+	}
+	return conds, changed
+}
+
+// send posts the pending conditions with post one at a time, until none
+// are left, so that a receiver sees each zone's conditions change in
+// the order they did.
+func (s *zoneConditionStore) send(post func(ZoneConditions)) {
+	for {
+		s.mu.Lock()
+		if len(s.pending) == 0 {
+			s.sending = false
+			s.mu.Unlock()
+			return
+		}
+		zc := s.pending[0]
+		s.pending = s.pending[1:]
+		s.mu.Unlock()
+
+		post(zc)
+	}
+}
+
+// get returns the zone's conditions, Unknown if it has not been
+// reconciled yet.
+func (s *zoneConditionStore) get(zone string) []StatusCondition {
+	if s == nil {
+		return unknownConditions()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conds, ok := s.zones[zone]
+	if !ok {
+		return unknownConditions()
+	}
+	return append([]StatusCondition(nil), conds...)
+}
+
+// updateConditions updates the zone's status conditions from the
+// result of a reconcile and, if StatusWebhook is set and a condition's
+// status or reason changed, posts them to the webhook in the
+// background, in order with earlier changes.
+func (a *App) updateConditions(result ReconcileResult) {
+	var post func(ZoneConditions)
+	if a.StatusWebhook != "" {
+		post = a.postConditions
+	}
+	a.conds.update(result, post)
+}
+
+// postConditions posts a zone's conditions to StatusWebhook as JSON,
+// logging any failure.
+func (a *App) postConditions(zc ZoneConditions) {
+	logFailure := func(err error) {
+		a.logger.Warn("failed to post status conditions",
+			zap.String("zone", zc.Zone),
+			zap.String("webhook", a.StatusWebhook),
+			zap.Error(err))
+	}
+
+	body, err := json.Marshal(zc)
+	if err != nil {
+		logFailure(err)
+		return
+	}
+	req, err := http.NewRequestWithContext(a.ctx, http.MethodPost, a.StatusWebhook, bytes.NewReader(body))
+	if err != nil {
+		logFailure(err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		logFailure(err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logFailure(fmt.Errorf("unexpected status %s", resp.Status))
+	}
+}
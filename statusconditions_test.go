@@ -0,0 +1,199 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+func TestResultConditions(t *testing.T) {
+	for _, tc := range []struct {
+		result          ReconcileResult
+		ready, degraded string
+		reason          string
+	}{
+		{ReconcileResult{Created: []string{"www:A"}}, "True", "False", "InSync"},
+		{ReconcileResult{Errors: []string{"create www:A: rejected"}}, "False", "True", "ReconcileFailed"},
+		{ReconcileResult{Frozen: true, Pending: []string{"create www:A"}}, "False", "False", "ChangesFrozen"},
+		{ReconcileResult{Pending: []string{"create www:A"}}, "False", "False", "ChangesPending"},
+	} {
+		conds := resultConditions(tc.result)
+		if conds[0].Type != "Ready" || conds[0].Status != tc.ready || conds[0].Reason != tc.reason {
+			t.Errorf("%+v: unexpected Ready condition %+v", tc.result, conds[0])
+		}
+		if conds[1].Type != "Degraded" || conds[1].Status != tc.degraded {
+			t.Errorf("%+v: unexpected Degraded condition %+v", tc.result, conds[1])
+		}
+	}
+}
+
+func TestZoneConditionStoreTransitions(t *testing.T) {
+	store := newZoneConditionStore()
+	if conds := store.get("example.com"); conds[0].Status != "Unknown" {
+		t.Errorf("expected Unknown before the first reconcile, got %+v", conds)
+	}
+
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, changed := store.update(ReconcileResult{Zone: "example.com", Time: t0}, nil); !changed {
+		t.Error("expected the first result to change the conditions")
+	}
+	if _, changed := store.update(ReconcileResult{Zone: "example.com", Time: t0.Add(time.Minute)}, nil); changed {
+		t.Error("expected an unchanged status not to change the conditions")
+	}
+	conds, changed := store.update(ReconcileResult{Zone: "example.com", Time: t0.Add(2 * time.Minute), Errors: []string{"boom"}}, nil)
+	if !changed {
+		t.Error("expected an error to change the conditions")
+	}
+	if !conds[0].LastTransitionTime.Equal(t0.Add(2 * time.Minute)) {
+		t.Errorf("expected Ready to transition at the failed reconcile, got %v", conds[0].LastTransitionTime)
+	}
+
+	conds, _ = store.update(ReconcileResult{Zone: "example.com", Time: t0.Add(3 * time.Minute), Errors: []string{"boom", "bang"}}, nil)
+	if !conds[1].LastTransitionTime.Equal(t0.Add(2*time.Minute)) || conds[1].Message != "boom (and 1 more errors)" {
+		t.Errorf("expected Degraded to keep its transition time, got %+v", conds[1])
+	}
+}
+
+func TestStatusWebhook(t *testing.T) {
+	posted := make(chan ZoneConditions, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var zc ZoneConditions
+		if err := json.NewDecoder(r.Body).Decode(&zc); err != nil {
+			t.Errorf("decoding webhook body: %v", err)
+		}
+		posted <- zc
+	}))
+	defer server.Close()
+
+	provider := &fakeCountingProvider{fakeProvider: fakeProvider{records: []libdns.Record{}}, failName: "www"}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.conds = newZoneConditionStore()
+	app.client = server.Client()
+	app.StatusWebhook = server.URL
+
+	receive := func() ZoneConditions {
+		t.Helper()
+		select {
+		case zc := <-posted:
+			return zc
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the webhook")
+			return ZoneConditions{}
+		}
+	}
+
+	_ = app.reconcileDomain(app.Domains[0])
+	if zc := receive(); zc.Zone != "example.com" || zc.Conditions[1].Status != "True" {
+		t.Errorf("expected the zone to be posted as degraded, got %+v", zc)
+	}
+
+	provider.failName = ""
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if zc := receive(); zc.Conditions[0].Status != "True" || zc.Conditions[0].Reason != "InSync" {
+		t.Errorf("expected the zone to be posted as ready, got %+v", zc)
+	}
+
+	// Nothing is posted while the status is unchanged
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	select {
+	case zc := <-posted:
+		t.Errorf("expected no post for an unchanged status, got %+v", zc)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestStatusWebhookOrder(t *testing.T) {
+	release := make(chan struct{})
+	posted := make(chan ZoneConditions, 8)
+	var inFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if inFlight.Add(1) > 1 {
+			t.Error("expected one webhook post at a time")
+		}
+		defer inFlight.Add(-1)
+		var zc ZoneConditions
+		if err := json.NewDecoder(r.Body).Decode(&zc); err != nil {
+			t.Errorf("decoding webhook body: %v", err)
+		}
+		<-release
+		posted <- zc
+	}))
+	defer server.Close()
+
+	app := newTestApp(t, &fakeProvider{})
+	app.conds = newZoneConditionStore()
+	app.client = server.Client()
+	app.StatusWebhook = server.URL
+
+	// Changes queued while the first post is held are posted after it,
+	// in the order they happened
+	t0 := time.Now().Truncate(time.Second)
+	var want []string
+	for i := range 6 {
+		result := ReconcileResult{Zone: "example.com", Time: t0.Add(time.Duration(i) * time.Second)}
+		status := "True"
+		if i%2 == 1 {
+			result.Errors = []string{"boom"}
+			status = "False"
+		}
+		app.updateConditions(result)
+		want = append(want, status)
+	}
+	close(release)
+
+	for i, status := range want {
+		select {
+		case zc := <-posted:
+			if zc.Conditions[0].Status != status || !zc.Conditions[0].LastTransitionTime.Equal(t0.Add(time.Duration(i)*time.Second)) {
+				t.Errorf("post %d: expected Ready %s from reconcile %d, got %+v", i, status, i, zc.Conditions[0])
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for post %d", i)
+		}
+	}
+}
+
+func TestAdminConditions(t *testing.T) {
+	app := newTestApp(t, &fakeProvider{}, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.conds = newZoneConditionStore()
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	get := func() []ZoneConditions {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"conditions?zone=example.com", nil)
+		if err := api.handleAPIEndpoints(rec, req); err != nil {
+			t.Fatalf("GET conditions failed: %v", err)
+		}
+		var all []ZoneConditions
+		if err := json.Unmarshal(rec.Body.Bytes(), &all); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		return all
+	}
+
+	if all := get(); len(all) != 1 || all[0].Conditions[0].Reason != "NotReconciled" {
+		t.Errorf("expected unknown conditions before the first reconcile, got %+v", all)
+	}
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if all := get(); len(all) != 1 || all[0].Conditions[0].Status != "True" {
+		t.Errorf("expected the zone to be ready, got %+v", all)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"conditions?zone=example.org", nil)
+	if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); err == nil {
+		t.Error("expected an unknown zone to be rejected")
+	}
+}
@@ -0,0 +1,93 @@
+package dnsregister
+
+import (
+	"context"
+	"sync"
+)
+
+// Zone outcomes in a ReconcileSummary.
+const (
+	zoneOK            = "ok"
+	zoneFailed        = "failed"
+	zoneSkipped       = "skipped"
+	zoneNotReconciled = "not_reconciled"
+)
+
+// ReconcileSummary is the outcome of the last reconcile of every zone,
+// for deciding the exit code of a one-shot run, e.g. in CI.
+type ReconcileSummary struct {
+	// Success is set when every zone's last reconcile succeeded or
+	// the zone was skipped because its manage_when condition is false.
+	Success bool `json:"success"`
+
+	// Zones are the outcomes of the zones, in config order.
+	Zones []ZoneSummary `json:"zones"`
+
+	// Failed lists the zones whose last reconcile failed or that were
+	// not reconciled.
+	Failed []string `json:"failed,omitempty"`
+}
+
+// ZoneSummary is the outcome of a zone's last reconcile.
+type ZoneSummary struct {
+	Zone string `json:"zone"`
+
+	// Status is "ok" if the reconcile succeeded, "failed" if it
+	// recorded any errors, "skipped" if the zone's manage_when
+	// condition is false, or "not_reconciled" if there has been no
+	// reconcile, e.g. because reconciliation is paused. A reconcile
+	// held back by a change freeze or plan_dir is "ok", with its
+	// changes listed as pending.
+	Status string `json:"status"`
+
+	// Result is the result of the reconcile, if there was one.
+	Result *ReconcileResult `json:"result,omitempty"`
+}
+
+// ExitCode returns 0 if the summary is successful and 1 otherwise.
+func (s ReconcileSummary) ExitCode() int {
+	if s.Success {
+		return 0
+	}
+	return 1
+}
+
+// ReconcileAll reconciles every zone once in a reconcile cycle, like
+// the one on start, but waiting for each reconcile to finish
+// regardless of reconcile_debounce, and summarizes the results of that
+// cycle. Failed record sets are still retried in the background, but
+// the summary reflects the reconcile itself.
+func (a *App) ReconcileAll() ReconcileSummary {
+	var mu sync.Mutex
+	results := make(map[*Domain]*ReconcileResult, len(a.Domains))
+	a.cycle(a.Domains, "reconcile_all", func(ctx context.Context, domain *Domain) {
+		result := a.runReconcile(ctx, domain)
+		mu.Lock()
+		results[domain] = result
+		mu.Unlock()
+	})
+	return a.summarize(func(domain *Domain) *ReconcileResult { return results[domain] })
+}
+
+// summarize summarizes the reconcile of every zone whose result is
+// returned by result; zones without one count as not reconciled.
+func (a *App) summarize(result func(domain *Domain) *ReconcileResult) ReconcileSummary {
+	summary := ReconcileSummary{Success: true, Zones: make([]ZoneSummary, 0, len(a.Domains))}
+	for _, domain := range a.Domains {
+		zone := ZoneSummary{Zone: domain.Zone, Status: zoneOK, Result: result(domain)}
+		switch {
+		case !domain.managed():
+			zone.Status = zoneSkipped
+		case zone.Result == nil:
+			zone.Status = zoneNotReconciled
+		case len(zone.Result.Errors) > 0:
+			zone.Status = zoneFailed
+		}
+		if zone.Status == zoneFailed || zone.Status == zoneNotReconciled {
+			summary.Success = false
+			summary.Failed = append(summary.Failed, domain.Zone)
+		}
+		summary.Zones = append(summary.Zones, zone)
+	}
+	return summary
+}
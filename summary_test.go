@@ -0,0 +1,126 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+func TestReconcileAll(t *testing.T) {
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+
+	summary := app.ReconcileAll()
+	if !summary.Success || summary.ExitCode() != 0 || len(summary.Zones) != 1 || summary.Zones[0].Status != zoneOK {
+		t.Errorf("expected a successful summary, got %+v", summary)
+	}
+	if result := summary.Zones[0].Result; result == nil || !slices.Equal(result.Created, []string{"www:A"}) {
+		t.Errorf("expected the result to list www:A as created, got %+v", result)
+	}
+
+	// A provider without RecordGetter fails its zone
+	other := &Domain{Zone: "example.org", provider: struct{}{}}
+	app.Domains = append(app.Domains, other)
+	summary = app.ReconcileAll()
+	if summary.Success || summary.ExitCode() != 1 || !slices.Equal(summary.Failed, []string{"example.org"}) {
+		t.Errorf("expected example.org to fail the summary, got %+v", summary)
+	}
+	if summary.Zones[0].Status != zoneOK || summary.Zones[1].Status != zoneFailed {
+		t.Errorf("expected statuses ok and failed, got %+v", summary.Zones)
+	}
+
+	// Paused zones are not reconciled, which fails the summary too,
+	// while zones whose manage_when condition is false are skipped
+	app.Domains = app.Domains[:1]
+	app.paused = new(atomic.Bool)
+	app.paused.Store(true)
+	summary = app.ReconcileAll()
+	if summary.Success || summary.Zones[0].Status != zoneNotReconciled {
+		t.Errorf("expected the paused zone not to be reconciled, got %+v", summary)
+	}
+	cond := &Condition{Left: "{env.CDR_TEST_ROLE}", Op: "==", Right: "primary"}
+	if err := cond.provision(); err != nil {
+		t.Fatalf("provision failed: %v", err)
+	}
+	t.Setenv("CDR_TEST_ROLE", "secondary")
+	app.Domains[0].ManageWhen = cond
+	summary = app.ReconcileAll()
+	if !summary.Success || summary.Zones[0].Status != zoneSkipped {
+		t.Errorf("expected the unmanaged zone to be skipped, got %+v", summary)
+	}
+}
+
+func TestReconcileAllCycle(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: 300 * time.Second},
+		libdns.TXT{Name: "_cdr.www", Text: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	www := &Record{Name: "www", Type: "A", Value: "192.0.2.1"}
+	app := newTestApp(t, provider, www)
+	app.Domains = append(app.Domains, &Domain{Zone: "example.com", provider: provider, Records: []*Record{www}})
+	app.history = newReconcileHistory(0)
+	app.cache = newRecordsCache(0)
+	app.zoneLocks = newZoneLocks()
+
+	// Domains sharing a provider and zone share the cycle's fetch
+	if summary := app.ReconcileAll(); !summary.Success {
+		t.Fatalf("expected a successful summary, got %+v", summary)
+	}
+	if provider.gets != 1 {
+		t.Errorf("expected one fetch for the cycle, got %d", provider.gets)
+	}
+
+	// The cycle deadline cancels a hung reconcile, which fails the
+	// summary even though the zone's last reconcile before succeeded
+	app.Domains = []*Domain{{Zone: "example.com", provider: &fakeHangingProvider{entered: make(chan struct{})}}}
+	app.running = newRunningReconciles()
+	app.CycleDeadline = caddy.Duration(50 * time.Millisecond)
+	start := time.Now()
+	summary := app.ReconcileAll()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected ReconcileAll to end at the cycle deadline, took %v", elapsed)
+	}
+	if summary.Success || summary.Zones[0].Status != zoneFailed || !strings.Contains(summary.Zones[0].Result.Errors[0], "deadline exceeded") {
+		t.Errorf("expected the zone to fail with the deadline, got %+v", summary)
+	}
+}
+
+func TestAdminSummary(t *testing.T) {
+	app := newTestApp(t, &fakeProvider{}, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	get := func() ReconcileSummary {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, adminEndpointBase+"summary", nil)
+		if err := api.handleAPIEndpoints(rec, req); err != nil {
+			t.Fatalf("GET summary failed: %v", err)
+		}
+		var summary ReconcileSummary
+		if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		return summary
+	}
+
+	if summary := get(); summary.Success || summary.Zones[0].Status != zoneNotReconciled {
+		t.Errorf("expected the zone not to be reconciled yet, got %+v", summary)
+	}
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if summary := get(); !summary.Success || summary.Zones[0].Result == nil {
+		t.Errorf("expected a successful summary with the result, got %+v", summary)
+	}
+}
@@ -0,0 +1,116 @@
+package dnsregister
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// deleteOwned deletes every record set this instance owns in the
+// domain's zone, with their markers, whether configured or not, and
+// returns the result. Records under authoritative prefixes that this
+// instance doesn't own are left alone. Configured records are created
+// again by the next reconcile.
+func (a *App) deleteOwned(domain *Domain) (ReconcileResult, error) {
+	return a.unmanageOwned(domain, false)
+}
+
+// releaseOwned is like deleteOwned, but releases the record sets
+// instead, deleting only their markers.
+func (a *App) releaseOwned(domain *Domain) (ReconcileResult, error) {
+	return a.unmanageOwned(domain, true)
+}
+
+// unmanageOwned deletes or releases every record set this instance owns
+// in the domain's zone.
+func (a *App) unmanageOwned(domain *Domain, release bool) (result ReconcileResult, err error) {
+	defer a.running.start(a.ctx, domain.Zone)()
+
+	result = ReconcileResult{Zone: domain.Zone, Time: time.Now()}
+	var plan *reconcilePlan
+	defer func() {
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		result.Duration = time.Since(result.Time).String()
+		forgetVerified(domain.Zone)
+		a.history.add(result)
+		a.emitRecordsChanged(result)
+		a.updateConditions(result)
+		if plan != nil {
+			a.auditChanges(plan, result)
+			a.printChanges(plan, result)
+		}
+	}()
+
+	unlock, err := a.zoneLocks.lock(a.running.context(a.ctx, domain.Zone), domain.Zone)
+	if err != nil {
+		return result, fmt.Errorf("waiting for another reconcile of the zone: %w", err)
+	}
+	defer unlock()
+
+	if err := a.ensureProvider(domain); err != nil {
+		return result, err
+	}
+	getter, ok := domain.provider.(libdns.RecordGetter)
+	if !ok {
+		return result, fmt.Errorf("provider does not implement RecordGetter")
+	}
+	if _, ok := domain.provider.(libdns.RecordDeleter); !ok && !release {
+		return result, fmt.Errorf("provider does not implement RecordDeleter")
+	}
+
+	// Read the zone afresh rather than from the cache
+	a.cache.invalidate(domain)
+	ctx, cancel := a.readContext(domain)
+	existing, err := providerGetRecords(ctx, domain, getter)
+	cancel()
+	if err != nil {
+		return result, fmt.Errorf("getting existing records: %w", err)
+	}
+	existing = a.normalizeRecordTypes(domain, encodeRecordNames(domain.Zone, existing))
+	result.ZoneRecords = len(existing)
+
+	owned := a.parseOwnedRecords(domain.Zone, existing)
+	var tracked map[string]bool
+	if len(a.MarkerlessTypes) > 0 {
+		if tracked, err = a.loadState(domain.Zone); err != nil {
+			return result, err
+		}
+		for key, recs := range a.trackedRecords(existing, tracked) {
+			owned[key] = recs
+		}
+	}
+
+	plan = &reconcilePlan{zone: domain.Zone, owned: owned, desired: make(map[string][]*Record)}
+	keys := make([]string, 0, len(owned))
+	for key := range owned {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if release {
+		plan.toRelease = keys
+		a.logger.Warn("releasing all owned records",
+			zap.String("zone", domain.Zone),
+			zap.Strings("release_records", keys))
+	} else {
+		plan.toDelete = keys
+		a.logger.Warn("deleting all owned records",
+			zap.String("zone", domain.Zone),
+			zap.Strings("delete_records", keys))
+	}
+
+	if err := a.applyPlan(domain, plan, &result); err != nil {
+		return result, err
+	}
+	if tracked != nil && a.trackChanges(tracked, plan, result) {
+		if err := a.saveState(domain.Zone, tracked); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,88 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+func TestAdminDeleteOwned(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "www", Type: "AAAA", Data: "2001:db8::1"},
+		libdns.RR{Name: "_cdr.www", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		// No longer configured, but still owned
+		libdns.RR{Name: "old", Type: "A", Data: "192.0.2.9"},
+		libdns.RR{Name: "_cdr.old", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+		// Not owned by this instance
+		libdns.RR{Name: "manual", Type: "A", Data: "192.0.2.20"},
+		libdns.RR{Name: "other", Type: "A", Data: "192.0.2.30"},
+		libdns.RR{Name: "_cdr.other", Type: "TXT", Data: "owner=other-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+	api := &adminAPI{log: zap.NewNop(), dnsApp: app}
+
+	// Without confirmation nothing is deleted
+	for _, target := range []string{"owned?zone=example.com", "owned?zone=example.com&confirm=true"} {
+		req := httptest.NewRequest(http.MethodDelete, adminEndpointBase+target, nil)
+		var apiErr caddy.APIError
+		if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusBadRequest {
+			t.Errorf("%s: expected bad request, got %v", target, err)
+		}
+	}
+	req := httptest.NewRequest(http.MethodPost, adminEndpointBase+"owned?zone=example.com&confirm=example.com", nil)
+	if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); err == nil {
+		t.Error("expected POST on owned to be rejected")
+	}
+	if len(provider.records) != 8 {
+		t.Fatalf("expected no changes before confirmation, got %v", provider.records)
+	}
+
+	// Nothing is deleted while reconciliation is paused
+	app.paused = new(atomic.Bool)
+	app.paused.Store(true)
+	req = httptest.NewRequest(http.MethodDelete, adminEndpointBase+"owned?zone=example.com&confirm=example.com", nil)
+	var apiErr caddy.APIError
+	if err := api.handleAPIEndpoints(httptest.NewRecorder(), req); !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusConflict {
+		t.Errorf("expected DELETE owned to conflict while paused, got %v", err)
+	}
+	if len(provider.records) != 8 {
+		t.Fatalf("expected no changes while paused, got %v", provider.records)
+	}
+	app.paused.Store(false)
+
+	rec := httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, adminEndpointBase+"owned?zone=example.com&confirm=example.com", nil)
+	if err := api.handleAPIEndpoints(rec, req); err != nil {
+		t.Fatalf("DELETE owned failed: %v", err)
+	}
+	var resp deleteOwnedResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.Deleted != 3 {
+		t.Errorf("expected 3 record sets deleted, got %d: %+v", resp.Deleted, resp.Result)
+	}
+
+	for _, name := range []string{"www", "_cdr.www", "old", "_cdr.old"} {
+		for _, rr := range provider.records {
+			if rr.RR().Name == name {
+				t.Errorf("expected %s to be deleted, got %v", name, provider.records)
+			}
+		}
+	}
+	if !provider.has("manual", "A") || !provider.has("other", "A") || !provider.has("_cdr.other", "TXT") {
+		t.Errorf("expected records not owned to be kept, got %v", provider.records)
+	}
+	if last := app.history.last("example.com"); last == nil || len(last.Deleted) != 3 {
+		t.Errorf("expected the deletion to be recorded in the history, got %+v", last)
+	}
+}
@@ -0,0 +1,39 @@
+package dnsregister
+
+import (
+	"context"
+	"time"
+)
+
+// readContext returns the context for a read call to the domain's
+// provider, bounded by the domain's read timeout if it has one. It is
+// cancelled along with the reconcile of the domain's zone.
+func (a *App) readContext(domain *Domain) (context.Context, context.CancelFunc) {
+	return a.timeoutContext(domain, time.Duration(domain.ReadTimeout))
+}
+
+// writeContext returns the context for a mutating call to the domain's
+// provider, bounded by the domain's write timeout if it has one. It is
+// cancelled along with the reconcile of the domain's zone. With an
+// inter-call delay, it first waits until the delay has passed since
+// the previous write call, whose end is marked by cancelling its
+// context, and since the start of any write call running concurrently.
+func (a *App) writeContext(domain *Domain) (context.Context, context.CancelFunc) {
+	domain.pacer.wait(a.running.context(a.ctx, domain.Zone))
+	ctx, cancel := a.timeoutContext(domain, time.Duration(domain.WriteTimeout))
+	if domain.pacer == nil {
+		return ctx, cancel
+	}
+	return ctx, func() {
+		cancel()
+		domain.pacer.done()
+	}
+}
+
+func (a *App) timeoutContext(domain *Domain, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx := a.running.context(a.ctx, domain.Zone)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,62 @@
+package dnsregister
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/libdns/libdns"
+)
+
+// fakeSlowProvider is a fakeProvider whose reads or writes block until
+// their context is done.
+type fakeSlowProvider struct {
+	fakeProvider
+	slowReads  bool
+	slowWrites bool
+}
+
+func (p *fakeSlowProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	if p.slowReads {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return p.fakeProvider.GetRecords(ctx, zone)
+}
+
+func (p *fakeSlowProvider) SetRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	if p.slowWrites {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return p.fakeProvider.SetRecords(ctx, zone, recs)
+}
+
+func TestReconcileReadTimeout(t *testing.T) {
+	provider := &fakeSlowProvider{slowReads: true}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.Domains[0].ReadTimeout = caddy.Duration(20 * time.Millisecond)
+
+	err := app.reconcileDomain(app.Domains[0])
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected read to time out, got %v", err)
+	}
+}
+
+func TestReconcileWriteTimeout(t *testing.T) {
+	provider := &fakeSlowProvider{slowWrites: true}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.Domains[0].ReadTimeout = caddy.Duration(time.Hour)
+	app.Domains[0].WriteTimeout = caddy.Duration(20 * time.Millisecond)
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.get("example.com")[0]
+	if len(result.Errors) != 1 || len(result.Created) != 0 {
+		t.Errorf("expected the write to time out, got %+v", result)
+	}
+}
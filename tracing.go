@@ -0,0 +1,92 @@
+package dnsregister
+
+import (
+	"context"
+
+	"github.com/libdns/libdns"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans of reconciles and
+// their provider calls.
+const tracerName = "github.com/jxnix-lab/caddy-dns-register"
+
+// startSpan starts a span named name from the global OpenTelemetry
+// tracer provider, which doesn't record anything unless a tracer
+// provider was registered. It is looked up on each call so that a
+// provider registered after the app started is used.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.GetTracerProvider().Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, marking it failed with err if err is not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// reconcileSpanAttributes returns the record counts of a reconcile's
+// result as span attributes.
+func reconcileSpanAttributes(result ReconcileResult) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int("dns_register.records.created", len(result.Created)),
+		attribute.Int("dns_register.records.updated", len(result.Updated)),
+		attribute.Int("dns_register.records.deleted", len(result.Deleted)),
+		attribute.Int("dns_register.records.released", len(result.Released)),
+		attribute.Int("dns_register.records.pending", len(result.Pending)),
+		attribute.Int("dns_register.errors", len(result.Errors)),
+		attribute.Bool("dns_register.unchanged", result.Unchanged),
+	}
+}
+
+// startProviderSpan starts the span of a call of operation on the
+// domain's provider, passing it records records.
+func startProviderSpan(ctx context.Context, domain *Domain, operation string, records int) (context.Context, trace.Span) {
+	return startSpan(ctx, "dns_register.provider."+operation,
+		attribute.String("dns_register.zone", domain.Zone),
+		attribute.String("dns_register.provider", providerName(domain.provider)),
+		attribute.String("dns_register.operation", operation),
+		attribute.Int("dns_register.records", records))
+}
+
+// providerGetRecords reads the domain's zone from getter within a span.
+func providerGetRecords(ctx context.Context, domain *Domain, getter libdns.RecordGetter) ([]libdns.Record, error) {
+	ctx, span := startProviderSpan(ctx, domain, "GetRecords", 0)
+	records, err := getter.GetRecords(ctx, domain.fqdn())
+	span.SetAttributes(attribute.Int("dns_register.records", len(records)))
+	endSpan(span, err)
+	return records, err
+}
+
+// providerSetRecords sets records in the domain's zone through setter
+// within a span.
+func providerSetRecords(ctx context.Context, domain *Domain, setter libdns.RecordSetter, records []libdns.Record) ([]libdns.Record, error) {
+	ctx, span := startProviderSpan(ctx, domain, "SetRecords", len(records))
+	set, err := setter.SetRecords(ctx, domain.fqdn(), records)
+	endSpan(span, err)
+	return set, err
+}
+
+// providerAppendRecords appends records to the domain's zone through
+// appender within a span.
+func providerAppendRecords(ctx context.Context, domain *Domain, appender libdns.RecordAppender, records []libdns.Record) ([]libdns.Record, error) {
+	ctx, span := startProviderSpan(ctx, domain, "AppendRecords", len(records))
+	appended, err := appender.AppendRecords(ctx, domain.fqdn(), records)
+	endSpan(span, err)
+	return appended, err
+}
+
+// providerDeleteRecords deletes records from the domain's zone through
+// deleter within a span.
+func providerDeleteRecords(ctx context.Context, domain *Domain, deleter libdns.RecordDeleter, records []libdns.Record) ([]libdns.Record, error) {
+	ctx, span := startProviderSpan(ctx, domain, "DeleteRecords", len(records))
+	deleted, err := deleter.DeleteRecords(ctx, domain.fqdn(), records)
+	endSpan(span, err)
+	return deleted, err
+}
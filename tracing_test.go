@@ -0,0 +1,102 @@
+package dnsregister
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordingTracerProvider records the spans started from it.
+type recordingTracerProvider struct {
+	embedded.TracerProvider
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+func (p *recordingTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{provider: p}
+}
+
+type recordingTracer struct {
+	embedded.Tracer
+	provider *recordingTracerProvider
+}
+
+func (t recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	parent, _ := trace.SpanFromContext(ctx).(*recordingSpan)
+	span := &recordingSpan{name: name, parent: parent, attrs: make(map[attribute.Key]attribute.Value)}
+	config := trace.NewSpanStartConfig(opts...)
+	span.SetAttributes(config.Attributes()...)
+	t.provider.mu.Lock()
+	t.provider.spans = append(t.provider.spans, span)
+	t.provider.mu.Unlock()
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type recordingSpan struct {
+	noop.Span
+	name   string
+	parent *recordingSpan
+	attrs  map[attribute.Key]attribute.Value
+	ended  bool
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...attribute.KeyValue) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordingSpan) End(...trace.SpanEndOption) { s.ended = true }
+
+func TestReconcileTracing(t *testing.T) {
+	tp := &recordingTracerProvider{}
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "api", Type: "A", Value: "192.0.2.2"})
+	app.running = newRunningReconciles()
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if len(tp.spans) == 0 || tp.spans[0].name != "dns_register.reconcile" {
+		t.Fatalf("expected a reconcile span first, got %v", tp.spans)
+	}
+	reconcile := tp.spans[0]
+	if !reconcile.ended {
+		t.Error("expected the reconcile span to be ended")
+	}
+	if zone := reconcile.attrs["dns_register.zone"].AsString(); zone != "example.com" {
+		t.Errorf("expected zone attribute example.com, got %q", zone)
+	}
+	if created := reconcile.attrs["dns_register.records.created"].AsInt64(); created != 2 {
+		t.Errorf("expected 2 created records, got %d", created)
+	}
+
+	ops := make(map[string]bool)
+	for _, span := range tp.spans[1:] {
+		if span.parent != reconcile {
+			t.Errorf("expected span %s to be a child of the reconcile span", span.name)
+		}
+		if !span.ended {
+			t.Errorf("expected span %s to be ended", span.name)
+		}
+		ops[span.attrs["dns_register.operation"].AsString()] = true
+	}
+	if !ops["GetRecords"] || !ops["SetRecords"] {
+		t.Errorf("expected spans of GetRecords and SetRecords calls, got %v", ops)
+	}
+}
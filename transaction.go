@@ -0,0 +1,52 @@
+package dnsregister
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// TransactionalProvider is implemented by DNS providers that can apply a
+// batch of changes to a zone atomically. When a domain's provider
+// implements it, all changes of a reconcile are applied in a single
+// ApplyChanges call, so either every change is made or none is.
+type TransactionalProvider interface {
+	// ApplyChanges creates, updates and deletes the given records in the
+	// zone as one all-or-nothing operation. Updates replace all existing
+	// records with the same name and type, like libdns.RecordSetter.
+	ApplyChanges(ctx context.Context, zone string, creates, updates, deletes []libdns.Record) error
+}
+
+// applyTransaction applies a plan with a single ApplyChanges call. On
+// failure nothing is recorded as changed in result.
+func (a *App) applyTransaction(tx TransactionalProvider, domain *Domain, plan *reconcilePlan, result *ReconcileResult) error {
+	var creates, updates, deletes []libdns.Record
+	for _, key := range plan.toCreate {
+		creates = append(creates, a.withMarker(plan.desired[key])...)
+	}
+	for _, key := range plan.toUpdate {
+		updates = append(updates, a.toLibdnsRecords(plan.desired[key])...)
+	}
+	for _, key := range plan.toDelete {
+		deletes = append(deletes, a.deletionRecords(plan, key)...)
+	}
+
+	ctx, cancel := a.writeContext(domain)
+	defer cancel()
+	if err := tx.ApplyChanges(ctx, domain.fqdn(), creates, updates, deletes); err != nil {
+		return fmt.Errorf("applying changes: %w", err)
+	}
+
+	a.logger.Info("applied changes",
+		zap.String("zone", domain.Zone),
+		zap.Strings("created", plan.toCreate),
+		zap.Strings("updated", plan.toUpdate),
+		zap.Strings("deleted", plan.toDelete))
+
+	result.Created = append(result.Created, plan.toCreate...)
+	result.Updated = append(result.Updated, plan.toUpdate...)
+	result.Deleted = append(result.Deleted, plan.toDelete...)
+	return nil
+}
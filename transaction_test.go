@@ -0,0 +1,96 @@
+package dnsregister
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeTxProvider is a fakeProvider that applies changes atomically and
+// fails the per-record methods so tests notice if they are used.
+type fakeTxProvider struct {
+	fakeProvider
+	calls int
+	fail  bool
+}
+
+func (p *fakeTxProvider) ApplyChanges(ctx context.Context, zone string, creates, updates, deletes []libdns.Record) error {
+	p.calls++
+	if p.fail {
+		return errors.New("transaction rejected")
+	}
+	_, _ = p.fakeProvider.DeleteRecords(ctx, zone, deletes)
+	_, _ = p.fakeProvider.SetRecords(ctx, zone, updates)
+	_, _ = p.fakeProvider.AppendRecords(ctx, zone, creates)
+	return nil
+}
+
+func (p *fakeTxProvider) SetRecords(context.Context, string, []libdns.Record) ([]libdns.Record, error) {
+	return nil, errors.New("SetRecords called on transactional provider")
+}
+
+func (p *fakeTxProvider) DeleteRecords(context.Context, string, []libdns.Record) ([]libdns.Record, error) {
+	return nil, errors.New("DeleteRecords called on transactional provider")
+}
+
+func TestReconcileTransactional(t *testing.T) {
+	provider := &fakeTxProvider{fakeProvider: fakeProvider{records: []libdns.Record{
+		libdns.TXT{Name: "_cdr.old", Text: "owner=test-caddy,heritage=caddy-dns-register", TTL: 300 * time.Second},
+		libdns.Address{Name: "old", IP: netip.MustParseAddr("192.0.2.9"), TTL: 300 * time.Second},
+		libdns.TXT{Name: "_cdr.api", Text: "owner=test-caddy,heritage=caddy-dns-register", TTL: 300 * time.Second},
+		libdns.Address{Name: "api", IP: netip.MustParseAddr("192.0.2.2"), TTL: 300 * time.Second},
+	}}}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "api", Type: "A", Value: "192.0.2.3"},
+	)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	if provider.calls != 1 {
+		t.Fatalf("expected 1 ApplyChanges call, got %d", provider.calls)
+	}
+	if !provider.has("www", "A") || !provider.has("_cdr.www", "TXT") {
+		t.Error("expected www A and its marker to be created")
+	}
+	if provider.has("old", "A") || provider.has("_cdr.old", "TXT") {
+		t.Error("expected old A and its marker to be deleted")
+	}
+
+	owned := app.parseOwnedRecords("example.com", provider.records)
+	if api := owned["api:A"]; len(api) != 1 || api[0].Value != "192.0.2.3" {
+		t.Errorf("expected api A to be updated, got %+v", api)
+	}
+
+	// No further changes means no further transaction
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.calls != 1 {
+		t.Errorf("expected no ApplyChanges call for an empty plan, got %d calls", provider.calls)
+	}
+}
+
+func TestReconcileTransactionalFailure(t *testing.T) {
+	provider := &fakeTxProvider{fail: true}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err == nil {
+		t.Fatal("expected reconcileDomain to fail")
+	}
+	if len(provider.records) != 0 {
+		t.Errorf("expected no records after a failed transaction, got %d", len(provider.records))
+	}
+
+	history := app.history.get("example.com")
+	if len(history) != 1 || len(history[0].Created) != 0 || len(history[0].Errors) != 1 {
+		t.Errorf("expected one failed result with no changes, got %+v", history)
+	}
+}
@@ -0,0 +1,111 @@
+package dnsregister
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// typesConflict reports whether record sets of types a and b can't
+// exist at the same name: a CNAME can't coexist with other data.
+func typesConflict(a, b string) bool {
+	return a != b && (a == "CNAME" || b == "CNAME")
+}
+
+// typeConflicts returns, for each set the plan creates, the keys of the
+// existing sets at its name it can't coexist with, sorted. Owned sets
+// the plan deletes, markers and external-dns registry entries are not
+// conflicts.
+func (a *App) typeConflicts(plan *reconcilePlan, existing []libdns.Record) map[string][]string {
+	_, _, entries := a.externalDNSEntries(existing, "")
+	types := make(map[string][]string)
+	for _, rec := range existing {
+		rr := rec.RR()
+		key := rr.Name + ":" + rr.Type
+		if a.isMarkerRecord(rr) || entries[key] || slices.Contains(plan.toDelete, key) {
+			continue
+		}
+		if !slices.Contains(types[rr.Name], rr.Type) {
+			types[rr.Name] = append(types[rr.Name], rr.Type)
+		}
+	}
+
+	conflicts := make(map[string][]string)
+	for _, key := range plan.toCreate {
+		name, typ := plan.desired[key][0].Name, plan.desired[key][0].Type
+		for _, exType := range types[name] {
+			if typesConflict(typ, exType) {
+				conflicts[key] = append(conflicts[key], name+":"+exType)
+			}
+		}
+		sort.Strings(conflicts[key])
+	}
+	return conflicts
+}
+
+// resolveTypeConflicts handles the plan's creates that conflict with
+// existing sets of another type at their name. With
+// ResolveTypeConflicts, conflicting sets that aren't desired are
+// deleted before the create, whoever owns them. Otherwise, or if a
+// conflicting set is desired too, the create is dropped from the plan
+// and reported as failed.
+func (a *App) resolveTypeConflicts(domain *Domain, plan *reconcilePlan, existing []libdns.Record, result *ReconcileResult) {
+	conflicts := a.typeConflicts(plan, existing)
+	if len(conflicts) == 0 {
+		return
+	}
+
+	sets := make(map[string][]*Record)
+	for _, rec := range existing {
+		rr := rec.RR()
+		key := rr.Name + ":" + rr.Type
+		sets[key] = append(sets[key], &Record{
+			Name:  rr.Name,
+			Type:  rr.Type,
+			Value: a.extractValue(rec),
+			TTL:   int(rr.TTL.Seconds()),
+		})
+	}
+
+	var blocked []string
+	for _, key := range plan.toCreate {
+		exKeys := conflicts[key]
+		if len(exKeys) == 0 {
+			continue
+		}
+		resolvable := a.ResolveTypeConflicts && !slices.ContainsFunc(exKeys, func(exKey string) bool {
+			_, desired := plan.desired[exKey]
+			return desired
+		})
+		if !resolvable {
+			a.logger.Error("record type conflicts with an existing record at the same name",
+				zap.String("zone", domain.Zone),
+				zap.String("record", key),
+				zap.Strings("existing", exKeys))
+			result.Errors = append(result.Errors, fmt.Sprintf("create %s: conflicts with existing %v", key, exKeys))
+			result.failed = append(result.failed, key)
+			blocked = append(blocked, key)
+			continue
+		}
+
+		for _, exKey := range exKeys {
+			if slices.Contains(plan.toDelete, exKey) {
+				continue
+			}
+			a.logger.Warn("deleting record of a conflicting type",
+				zap.String("zone", domain.Zone),
+				zap.String("record", exKey),
+				zap.String("for", key))
+			if _, owned := plan.owned[exKey]; !owned {
+				plan.owned[exKey] = sets[exKey]
+			}
+			plan.toDelete = append(plan.toDelete, exKey)
+		}
+	}
+	plan.toCreate = slices.DeleteFunc(plan.toCreate, func(key string) bool {
+		return slices.Contains(blocked, key)
+	})
+}
@@ -0,0 +1,76 @@
+package dnsregister
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestReconcileTypeConflict(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		// Unowned records of types the desired sets can't coexist with
+		libdns.RR{Name: "www", Type: "CNAME", Data: "lb.example.net."},
+		libdns.RR{Name: "api", Type: "A", Data: "192.0.2.9"},
+		libdns.RR{Name: "api", Type: "TXT", Data: "v=verify"},
+	}}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "api", Type: "CNAME", Value: "api.example.net."},
+		&Record{Name: "mail", Type: "A", Value: "192.0.2.25"})
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.has("www", "A") || provider.has("api", "CNAME") {
+		t.Errorf("expected conflicting creates to be skipped, got %v", provider.records)
+	}
+	if !provider.has("www", "CNAME") || !provider.has("api", "A") || !provider.has("api", "TXT") {
+		t.Errorf("expected existing records to be kept, got %v", provider.records)
+	}
+	if !provider.has("mail", "A") {
+		t.Errorf("expected records without conflicts to be created, got %v", provider.records)
+	}
+
+	errs := strings.Join(app.history.last("example.com").Errors, "\n")
+	for _, want := range []string{
+		"create www:A: conflicts with existing [www:CNAME]",
+		"create api:CNAME: conflicts with existing [api:A api:TXT]",
+	} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("expected error %q, got %q", want, errs)
+		}
+	}
+}
+
+func TestReconcileResolveTypeConflicts(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "www", Type: "CNAME", Data: "lb.example.net."},
+		// Desired sets are never deleted to resolve a conflict
+		libdns.RR{Name: "api", Type: "A", Data: "192.0.2.9"},
+		libdns.RR{Name: "_cdr.api", Type: "TXT", Data: "owner=test-caddy,heritage=caddy-dns-register"},
+	}}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "api", Type: "A", Value: "192.0.2.9"},
+		&Record{Name: "api", Type: "CNAME", Value: "api.example.net."})
+	app.ResolveTypeConflicts = true
+	app.history = newReconcileHistory(0)
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if provider.has("www", "CNAME") {
+		t.Errorf("expected conflicting CNAME to be deleted, got %v", provider.records)
+	}
+	if !hasRecord(provider.records, "www", "A", "192.0.2.1") || !provider.has("_cdr.www", "TXT") {
+		t.Errorf("expected www A to be created with its marker, got %v", provider.records)
+	}
+	if !provider.has("api", "A") || provider.has("api", "CNAME") {
+		t.Errorf("expected desired api A to be kept and api CNAME skipped, got %v", provider.records)
+	}
+	if errs := app.history.last("example.com").Errors; len(errs) != 1 || !strings.Contains(errs[0], "create api:CNAME: conflicts") {
+		t.Errorf("expected only the api CNAME conflict to be reported, got %v", errs)
+	}
+}
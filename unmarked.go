@@ -0,0 +1,146 @@
+package dnsregister
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+)
+
+// unmarkedRecords remembers, per zone, the record sets whose records
+// were written but whose ownership marker failed to write, as happens
+// with two-phase markers. Without their marker they look like records
+// of another owner, so they are claimed back by the next reconcile.
+type unmarkedRecords struct {
+	mu    sync.Mutex
+	zones map[string]map[string]bool
+}
+
+func newUnmarkedRecords() *unmarkedRecords {
+	return &unmarkedRecords{zones: make(map[string]map[string]bool)}
+}
+
+// add remembers the set key of zone as written without its marker.
+func (u *unmarkedRecords) add(zone, key string) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.zones[zone] == nil {
+		u.zones[zone] = make(map[string]bool)
+	}
+	u.zones[zone][key] = true
+}
+
+// remove forgets the set key of zone.
+func (u *unmarkedRecords) remove(zone, key string) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.zones[zone], key)
+}
+
+// keys returns the keys of the sets of zone written without their
+// marker.
+func (u *unmarkedRecords) keys(zone string) []string {
+	if u == nil {
+		return nil
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	keys := make([]string, 0, len(u.zones[zone]))
+	for key := range u.zones[zone] {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// claimUnmarked adds to owned the sets of the domain's zone that were
+// written without their marker and are still exactly as written, and
+// returns their keys so that only their marker is written rather than
+// the sets being created again. Sets that have since been marked,
+// changed, removed from config or marked by another owner are
+// forgotten. During a freeze or in plan mode nothing is claimed, so
+// the sets remain pending creates.
+func (a *App) claimUnmarked(domain *Domain, existing []libdns.Record, desired, owned map[string][]*Record, failed map[string]error) []string {
+	keys := a.unmarked.keys(domain.Zone)
+	if len(keys) == 0 {
+		return nil
+	}
+	if _, frozen := a.frozen(); frozen || a.PlanDir != "" {
+		return nil
+	}
+
+	present := make(map[string][]*Record)
+	otherMarkers := make(map[string]bool)
+	for _, rec := range existing {
+		rr := rec.RR()
+		if a.isMarkerRecord(rr) && !a.isOwnMarker(rr.Data) {
+			otherMarkers[rr.Name] = true
+		}
+		key := rr.Name + ":" + rr.Type
+		present[key] = append(present[key], &Record{
+			Name:  rr.Name,
+			Type:  rr.Type,
+			Value: a.extractValue(rec),
+			TTL:   int(rr.TTL.Seconds()),
+		})
+	}
+
+	var claimed []string
+	for _, key := range keys {
+		if _, resolving := failed[key]; resolving {
+			continue
+		}
+		recs, wanted := desired[key]
+		_, marked := owned[key]
+		if marked || !wanted || recordSetChanged(present[key], recs) ||
+			otherMarkers[a.markerName(recs[0].Name, recs[0].Type)] {
+			a.unmarked.remove(domain.Zone, key)
+			continue
+		}
+		owned[key] = present[key]
+		claimed = append(claimed, key)
+	}
+	return claimed
+}
+
+// markUnmarked writes the ownership markers of the sets of plan that
+// were claimed by claimUnmarked. Sets whose marker fails to write
+// again are recorded as failed and claimed again next time.
+func (a *App) markUnmarked(domain *Domain, plan *reconcilePlan, result *ReconcileResult) {
+	if len(plan.unmarked) == 0 {
+		return
+	}
+	defer a.cache.invalidate(domain)
+
+	for _, key := range plan.unmarked {
+		recs := plan.desired[key]
+		if err := a.writeRecords(domain, []libdns.Record{a.makeMarker(recs[0].Name, recs[0].Type)}); err != nil {
+			a.markerWriteFailed(domain, key, err)
+			result.Errors = append(result.Errors, fmt.Sprintf("mark %s: %v", key, err))
+			result.failed = append(result.failed, key)
+			continue
+		}
+		a.unmarked.remove(domain.Zone, key)
+		a.logger.Info("marked record written without its ownership marker",
+			zap.String("zone", domain.Zone),
+			zap.String("record", key))
+	}
+}
+
+// markerWriteFailed reports that the records of the set key were
+// written but its ownership marker was not, and remembers the set so
+// the next reconcile writes just the marker.
+func (a *App) markerWriteFailed(domain *Domain, key string, err error) {
+	a.logger.Warn("failed to write ownership marker of written records",
+		zap.String("zone", domain.Zone),
+		zap.String("record", key),
+		zap.Error(err))
+	countMarkerWriteFailure(domain)
+	a.unmarked.add(domain.Zone, key)
+}
@@ -0,0 +1,76 @@
+package dnsregister
+
+import (
+	"testing"
+
+	"github.com/libdns/libdns"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestReconcileMarksUnmarkedRecords(t *testing.T) {
+	initMetrics(prometheus.NewRegistry())
+	app, provider := newBatchTestApp(3)
+	app.TwoPhaseMarkers = true
+	app.unmarked = newUnmarkedRecords()
+	// Other tests reconcile example.com with the same global counter
+	zone := "unmarked.example"
+	app.Domains[0].Zone = zone
+	counter := dnsRegisterMetrics.markerFails.WithLabelValues(zone)
+
+	provider.failName = "_cdr.host1"
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if got := counterValue(t, counter); got != 1 {
+		t.Errorf("expected one marker write failure, got %v", got)
+	}
+
+	// The next reconcile writes just the missing marker
+	provider.failName = ""
+	provider.calls = 0
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.last(zone)
+	if len(result.Created) != 0 || len(result.Errors) != 0 {
+		t.Errorf("expected host1 to be marked, not created again, got %+v", result)
+	}
+	if provider.calls != 2 {
+		t.Errorf("expected get records and set marker, got %d calls", provider.calls)
+	}
+	if _, owned := app.parseOwnedRecords("example.com", provider.records)["host1:A"]; !owned {
+		t.Errorf("expected host1 to be marked, got %v", provider.records)
+	}
+	if keys := app.unmarked.keys(zone); len(keys) != 0 {
+		t.Errorf("expected no sets left unmarked, got %v", keys)
+	}
+	if got := counterValue(t, counter); got != 1 {
+		t.Errorf("expected no further marker write failures, got %v", got)
+	}
+}
+
+func TestClaimUnmarkedChangedRecords(t *testing.T) {
+	app, provider := newBatchTestApp(1)
+	app.TwoPhaseMarkers = true
+	app.unmarked = newUnmarkedRecords()
+
+	provider.failName = "_cdr.host0"
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+
+	// Records changed since they were written aren't claimed, but
+	// replaced like any unowned records
+	provider.failName = ""
+	provider.records = append(provider.fakeProvider.records, libdns.RR{Name: "host0", Type: "A", Data: "10.0.0.99"})
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	result := app.history.last("example.com")
+	if len(result.Created) != 1 || result.Created[0] != "host0:A" {
+		t.Errorf("expected host0 to be created, got %+v", result)
+	}
+	if keys := app.unmarked.keys("example.com"); len(keys) != 0 {
+		t.Errorf("expected host0 to be forgotten, got %v", keys)
+	}
+}
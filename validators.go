@@ -0,0 +1,152 @@
+package dnsregister
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RecordValidator checks that value is valid data for a record of the
+// type it is registered for, returning a descriptive error if not.
+type RecordValidator func(value string) error
+
+var recordValidators = struct {
+	sync.RWMutex
+	byType map[string]RecordValidator
+}{byType: make(map[string]RecordValidator)}
+
+// RegisterRecordValidator registers validator for the values of records
+// of recordType, so that configured and patched records of the type are
+// checked when they are loaded. It lets packages that embed this module
+// validate record types it doesn't know about. It is meant to be called
+// from init and panics if recordType already has a validator.
+func RegisterRecordValidator(recordType string, validator RecordValidator) {
+	recordType = strings.ToUpper(recordType)
+	if recordType == "" || validator == nil {
+		panic("record validator requires a record type and a func")
+	}
+
+	recordValidators.Lock()
+	defer recordValidators.Unlock()
+	if _, ok := recordValidators.byType[recordType]; ok {
+		panic(fmt.Sprintf("record validator for %s already registered", recordType))
+	}
+	recordValidators.byType[recordType] = validator
+}
+
+// validateRecordValue checks the value of rec with the validator
+// registered for its type, if any. Records whose values are resolved
+// from SRV lookups have no value of their own to check.
+func validateRecordValue(rec *Record) error {
+	if rec.FromSRV != "" {
+		return nil
+	}
+	recordValidators.RLock()
+	validator, ok := recordValidators.byType[strings.ToUpper(rec.Type)]
+	recordValidators.RUnlock()
+	if !ok {
+		return nil
+	}
+	if err := validator(rec.Value); err != nil {
+		return fmt.Errorf("invalid %s value %q: %v", rec.Type, rec.Value, err)
+	}
+	return nil
+}
+
+func init() {
+	RegisterRecordValidator("A", validateIPv4)
+	RegisterRecordValidator("AAAA", validateIPv6)
+	for _, typ := range []string{"CNAME", "NS", "PTR", "DNAME"} {
+		RegisterRecordValidator(typ, validateHostname)
+	}
+	RegisterRecordValidator("MX", validateMX)
+	RegisterRecordValidator("SRV", validateSRV)
+	RegisterRecordValidator("CAA", validateCAA)
+}
+
+func validateIPv4(value string) error {
+	ip, err := netip.ParseAddr(value)
+	if err != nil || !ip.Is4() {
+		return fmt.Errorf("not an IPv4 address")
+	}
+	return nil
+}
+
+func validateIPv6(value string) error {
+	ip, err := netip.ParseAddr(value)
+	if err != nil || !ip.Is6() || ip.Is4In6() {
+		return fmt.Errorf("not an IPv6 address")
+	}
+	return nil
+}
+
+// validateHostname checks that value is a single domain name of valid
+// length. Relative names are allowed; they are resolved by the
+// provider.
+func validateHostname(value string) error {
+	if value == "." {
+		// The root, e.g. the exchange of a null MX record
+		return nil
+	}
+	name := strings.TrimSuffix(value, ".")
+	if name == "" {
+		return fmt.Errorf("empty hostname")
+	}
+	if strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("hostname contains whitespace")
+	}
+	if len(name) > 253 {
+		return fmt.Errorf("hostname longer than 253 characters")
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 {
+			return fmt.Errorf("invalid label %q", label)
+		}
+	}
+	return nil
+}
+
+// validateMX checks a "<preference> <exchange>" value.
+func validateMX(value string) error {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return fmt.Errorf("expected <preference> <exchange>")
+	}
+	if _, err := strconv.ParseUint(fields[0], 10, 16); err != nil {
+		return fmt.Errorf("invalid preference %q", fields[0])
+	}
+	return validateHostname(fields[1])
+}
+
+// validateSRV checks a "<priority> <weight> <port> <target>" value.
+func validateSRV(value string) error {
+	fields := strings.Fields(value)
+	if len(fields) != 4 {
+		return fmt.Errorf("expected <priority> <weight> <port> <target>")
+	}
+	for i, field := range fields[:3] {
+		if _, err := strconv.ParseUint(field, 10, 16); err != nil {
+			return fmt.Errorf("invalid %s %q", []string{"priority", "weight", "port"}[i], field)
+		}
+	}
+	return validateHostname(fields[3])
+}
+
+// validateCAA checks a "<flags> <tag> <value>" value.
+func validateCAA(value string) error {
+	fields := strings.SplitN(value, " ", 3)
+	if len(fields) != 3 {
+		return fmt.Errorf("expected <flags> <tag> <value>")
+	}
+	if _, err := strconv.ParseUint(fields[0], 10, 8); err != nil {
+		return fmt.Errorf("invalid flags %q", fields[0])
+	}
+	for _, r := range fields[1] {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return fmt.Errorf("invalid tag %q", fields[1])
+		}
+	}
+	return nil
+}
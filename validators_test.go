@@ -0,0 +1,79 @@
+package dnsregister
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateRecordValue(t *testing.T) {
+	for _, tc := range []struct {
+		typ, value string
+		valid      bool
+	}{
+		{"A", "192.0.2.1", true},
+		{"A", "2001:db8::1", false},
+		{"A", "www.example.com.", false},
+		{"AAAA", "2001:db8::1", true},
+		{"AAAA", "::ffff:192.0.2.1", false},
+		{"CNAME", "target.example.com.", true},
+		{"CNAME", "target..example.com.", false},
+		{"CNAME", "", false},
+		{"MX", "10 mx.example.com.", true},
+		{"MX", "0 .", true},
+		{"MX", "mx.example.com.", false},
+		{"MX", "65536 mx.example.com.", false},
+		{"MX", "-1 mx.example.com.", false},
+		{"MX", "10 mx..example.com.", false},
+		{"SRV", "10 5 5060 sip.example.com.", true},
+		{"SRV", "10 5 70000 sip.example.com.", false},
+		{"SRV", "10 5 5060", false},
+		{"CAA", `0 issue "letsencrypt.org"`, true},
+		{"CAA", `256 issue "letsencrypt.org"`, false},
+		{"TXT", "anything goes", true},
+	} {
+		err := validateRecordValue(&Record{Name: "www", Type: tc.typ, Value: tc.value})
+		if (err == nil) != tc.valid {
+			t.Errorf("%s %q: expected valid=%v, got %v", tc.typ, tc.value, tc.valid, err)
+		}
+	}
+
+	err := validateRecordValue(&Record{Name: "_sip._tcp", Type: "SRV", Value: "10 5 70000 sip.example.com."})
+	if err == nil || !strings.Contains(err.Error(), `invalid port "70000"`) {
+		t.Errorf("expected error naming the SRV port, got %v", err)
+	}
+
+	// Values resolved from SRV lookups are not checked
+	if err := validateRecordValue(&Record{Name: "www", Type: "A", FromSRV: "_http._tcp.example.com"}); err != nil {
+		t.Errorf("expected from_srv record to validate, got %v", err)
+	}
+}
+
+func TestRegisterRecordValidator(t *testing.T) {
+	RegisterRecordValidator("x-test", func(value string) error {
+		if !strings.HasPrefix(value, "v=") {
+			return errors.New("missing version")
+		}
+		return nil
+	})
+	t.Cleanup(func() {
+		recordValidators.Lock()
+		delete(recordValidators.byType, "X-TEST")
+		recordValidators.Unlock()
+	})
+
+	if err := validateRecord(&Record{Name: "www", Type: "X-TEST", Value: "v=1"}); err != nil {
+		t.Errorf("expected valid value, got %v", err)
+	}
+	err := validateRecord(&Record{Name: "www", Type: "X-TEST", Value: "1"})
+	if err == nil || !strings.Contains(err.Error(), "missing version") {
+		t.Errorf("expected registered validator error, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a second validator for a type to panic")
+		}
+	}()
+	RegisterRecordValidator("X-TEST", func(string) error { return nil })
+}
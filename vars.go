@@ -0,0 +1,125 @@
+package dnsregister
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/httpcaddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+func init() {
+	caddy.RegisterModule(VarsHandler{})
+	httpcaddyfile.RegisterHandlerDirective("dns_register_vars", parseVarsHandler)
+	httpcaddyfile.RegisterDirectiveOrder("dns_register_vars", httpcaddyfile.Before, "map")
+}
+
+// varsPlaceholderPrefix is the prefix of the placeholders set by
+// VarsHandler.
+const varsPlaceholderPrefix = "dns_register."
+
+// VarsHandler is an HTTP handler that makes the reconcile status of the
+// dns_register app's zones available as placeholders to the handlers
+// after it, e.g. for routing or response headers:
+//
+//	{dns_register.<zone>.in_sync}         whether the last reconcile succeeded with nothing left pending
+//	{dns_register.<zone>.last_reconcile}  the time of the last reconcile, in RFC 3339 format
+//	{dns_register.<zone>.errors}          the number of errors of the last reconcile
+//
+// The zone is written with its dots replaced by underscores (e.g.
+// example_com), or as it is. Placeholders of zones not yet reconciled
+// are empty.
+type VarsHandler struct {
+	app *App
+}
+
+// CaddyModule returns the Caddy module information.
+func (VarsHandler) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "http.handlers.dns_register_vars",
+		New: func() caddy.Module { return new(VarsHandler) },
+	}
+}
+
+// Provision looks up the dns_register app.
+func (h *VarsHandler) Provision(ctx caddy.Context) error {
+	app, err := ctx.AppIfConfigured("dns_register")
+	if err != nil {
+		return fmt.Errorf("dns_register_vars requires the dns_register app: %v", err)
+	}
+	h.app = app.(*App)
+	return nil
+}
+
+// ServeHTTP adds the placeholders to the request's replacer.
+func (h VarsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.Handler) error {
+	repl := r.Context().Value(caddy.ReplacerCtxKey).(*caddy.Replacer)
+	repl.Map(h.app.statusPlaceholder)
+	return next.ServeHTTP(w, r)
+}
+
+// statusPlaceholder returns the value of a dns_register status
+// placeholder, for use as a caddy.ReplacerFunc.
+func (a *App) statusPlaceholder(key string) (any, bool) {
+	rest, ok := strings.CutPrefix(key, varsPlaceholderPrefix)
+	if !ok {
+		return nil, false
+	}
+	i := strings.LastIndex(rest, ".")
+	if i < 0 {
+		return nil, false
+	}
+	zone, stat := rest[:i], rest[i+1:]
+
+	var domain *Domain
+	for _, d := range a.Domains {
+		if d.Zone == zone || strings.ReplaceAll(d.Zone, ".", "_") == zone {
+			domain = d
+			break
+		}
+	}
+	if domain == nil {
+		return nil, false
+	}
+
+	last := a.history.last(domain.Zone)
+	switch stat {
+	case "in_sync":
+		if last == nil {
+			return "", true
+		}
+		return len(last.Errors) == 0 && len(last.Pending) == 0, true
+	case "last_reconcile":
+		if last == nil {
+			return "", true
+		}
+		return last.Time.Format(time.RFC3339), true
+	case "errors":
+		if last == nil {
+			return "", true
+		}
+		return strconv.Itoa(len(last.Errors)), true
+	}
+	return nil, false
+}
+
+// parseVarsHandler parses the dns_register_vars directive, which takes
+// no arguments:
+//
+//	dns_register_vars
+func parseVarsHandler(h httpcaddyfile.Helper) (caddyhttp.MiddlewareHandler, error) {
+	if h.NextArg() || h.NextBlock(0) {
+		return nil, h.ArgErr()
+	}
+	return new(VarsHandler), nil
+}
+
+// Interface guards
+var (
+	_ caddy.Provisioner           = (*VarsHandler)(nil)
+	_ caddyhttp.MiddlewareHandler = (*VarsHandler)(nil)
+)
@@ -0,0 +1,49 @@
+package dnsregister
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+func TestVarsHandler(t *testing.T) {
+	app := newTestApp(t, &fakeProvider{})
+	app.Domains = append(app.Domains, &Domain{Zone: "broken.example"})
+	app.history = newReconcileHistory(0)
+	reconciled := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	app.history.add(ReconcileResult{Zone: "example.com", Time: reconciled})
+
+	repl := caddy.NewReplacer()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), caddy.ReplacerCtxKey, repl))
+
+	handler := VarsHandler{app: app}
+	next := caddyhttp.HandlerFunc(func(http.ResponseWriter, *http.Request) error { return nil })
+	if err := handler.ServeHTTP(httptest.NewRecorder(), req, next); err != nil {
+		t.Fatalf("ServeHTTP failed: %v", err)
+	}
+
+	for placeholder, want := range map[string]string{
+		"{dns_register.example_com.in_sync}":        "true",
+		"{dns_register.example.com.in_sync}":        "true",
+		"{dns_register.example_com.last_reconcile}": "2026-01-02T03:04:05Z",
+		"{dns_register.example_com.errors}":         "0",
+		"{dns_register.broken_example.in_sync}":     "-", // empty until reconciled
+		"{dns_register.example_com.unknown}":        "-",
+		"{dns_register.unknown_example.in_sync}":    "-",
+	} {
+		if got := repl.ReplaceAll(placeholder, "-"); got != want {
+			t.Errorf("%s = %q, want %q", placeholder, got, want)
+		}
+	}
+
+	app.history.add(ReconcileResult{Zone: "example.com", Time: reconciled, Errors: []string{"create www:A: rejected"}})
+	if got := repl.ReplaceAll("{dns_register.example_com.in_sync} {dns_register.example_com.errors}", ""); got != "false 1" {
+		t.Errorf("expected out of sync with 1 error, got %q", got)
+	}
+}
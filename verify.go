@@ -0,0 +1,90 @@
+package dnsregister
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// verifiedZones holds, per zone, the desired records as of the last
+// reconcile that verified the zone in sync. It is kept across config
+// reloads, each of which creates a new App, as those are what trigger
+// most reconciles.
+var verifiedZones = &verifiedStates{zones: make(map[string]verifiedState)}
+
+// verifiedState is a zone's desired records, as a fingerprint, when it
+// was last verified in sync, and its record count then.
+type verifiedState struct {
+	fingerprint string
+	at          time.Time
+	records     int
+}
+
+type verifiedStates struct {
+	mu    sync.Mutex
+	zones map[string]verifiedState
+}
+
+// desiredFingerprint returns a fingerprint of the domain's desired
+// records and of the settings that decide how they are published, so
+// that changing either forces a full reconcile.
+func (a *App) desiredFingerprint(domain *Domain, desired map[string][]*Record) string {
+	data, err := json.Marshal(struct {
+		Owner      string
+		Marker     string
+		MarkerType string
+		Layout     string
+		Markerless []string
+		Provider   string
+		Desired    map[string][]*Record
+	}{a.OwnerID, a.markerText(), a.markerType(), a.RegistryLayout, a.MarkerlessTypes, domain.providerHash, desired})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// unchangedSinceVerified reports whether the zone was verified in sync
+// with the desired records of fingerprint less than VerifyInterval ago,
+// and its record count then.
+func (a *App) unchangedSinceVerified(zone, fingerprint string) (bool, int) {
+	if a.VerifyInterval <= 0 || fingerprint == "" {
+		return false, 0
+	}
+	verifiedZones.mu.Lock()
+	defer verifiedZones.mu.Unlock()
+	state, ok := verifiedZones.zones[zone]
+	if !ok || state.fingerprint != fingerprint || time.Since(state.at) >= time.Duration(a.VerifyInterval) {
+		return false, 0
+	}
+	return true, state.records
+}
+
+// recordVerified records the outcome of a reconcile of all of a zone's
+// records with the desired records of fingerprint: the zone is
+// verified in sync if the reconcile found nothing to change and had no
+// errors, and must be verified again otherwise.
+func (a *App) recordVerified(zone, fingerprint string, result ReconcileResult, err error) {
+	if a.VerifyInterval <= 0 || result.Unchanged {
+		return
+	}
+	changed := len(result.Created) > 0 || len(result.Updated) > 0 || len(result.Deleted) > 0 || len(result.Released) > 0
+	if err != nil || fingerprint == "" || changed || len(result.Errors) > 0 || len(result.Pending) > 0 {
+		forgetVerified(zone)
+		return
+	}
+	verifiedZones.mu.Lock()
+	defer verifiedZones.mu.Unlock()
+	verifiedZones.zones[zone] = verifiedState{fingerprint: fingerprint, at: result.Time, records: result.ZoneRecords}
+}
+
+// forgetVerified makes the next reconcile of zone verify it in full,
+// e.g. after records were changed outside a reconcile.
+func forgetVerified(zone string) {
+	verifiedZones.mu.Lock()
+	defer verifiedZones.mu.Unlock()
+	delete(verifiedZones.zones, zone)
+}
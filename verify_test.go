@@ -0,0 +1,133 @@
+package dnsregister
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/libdns/libdns"
+)
+
+func TestReconcileVerifyInterval(t *testing.T) {
+	t.Cleanup(func() { forgetVerified("example.com") })
+
+	provider := &fakeProvider{}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.VerifyInterval = caddy.Duration(time.Hour)
+	app.history = newReconcileHistory(0)
+
+	reconcile := func() *ReconcileResult {
+		t.Helper()
+		if err := app.reconcileDomain(app.Domains[0]); err != nil {
+			t.Fatalf("reconcileDomain failed: %v", err)
+		}
+		return app.history.last("example.com")
+	}
+
+	// Creating the record doesn't verify the zone yet, the next
+	// reconcile finds it in sync
+	reconcile()
+	reconcile()
+	gets := provider.gets
+	if result := reconcile(); !result.Unchanged || result.ZoneRecords != 2 || provider.gets != gets {
+		t.Errorf("expected an unchanged zone not to be read, got %+v after %d reads", result, provider.gets-gets)
+	}
+
+	// A new desired value is applied at once
+	app.Domains[0].Records[0].Value = "192.0.2.2"
+	if result := reconcile(); result.Unchanged || len(result.Updated) != 1 {
+		t.Errorf("expected the changed record to be updated, got %+v", result)
+	}
+
+	// Drift is caught once the interval is over
+	if result := reconcile(); result.Unchanged {
+		t.Errorf("expected the zone to be verified again after the update, got %+v", result)
+	}
+	provider.mu.Lock()
+	provider.records = nil
+	provider.mu.Unlock()
+	if result := reconcile(); !result.Unchanged {
+		t.Errorf("expected drift to go unnoticed within the interval, got %+v", result)
+	}
+	verifiedZones.mu.Lock()
+	state := verifiedZones.zones["example.com"]
+	state.at = state.at.Add(-time.Hour)
+	verifiedZones.zones["example.com"] = state
+	verifiedZones.mu.Unlock()
+	if result := reconcile(); result.Unchanged || len(result.Created) != 1 {
+		t.Errorf("expected the record to be created again after the interval, got %+v", result)
+	}
+	if !hasRecord(provider.records, "www", "A", "192.0.2.2") {
+		t.Errorf("expected www to be restored, got %v", provider.records)
+	}
+}
+
+func TestReconcileVerifyIntervalAfterErrors(t *testing.T) {
+	t.Cleanup(func() { forgetVerified("example.com") })
+
+	provider := &fakeCountingProvider{fakeProvider: fakeProvider{records: []libdns.Record{}}, failName: "www"}
+	app := newTestApp(t, provider, &Record{Name: "www", Type: "A", Value: "192.0.2.1"})
+	app.VerifyInterval = caddy.Duration(time.Hour)
+	app.history = newReconcileHistory(0)
+
+	for i := 0; i < 2; i++ {
+		_ = app.reconcileDomain(app.Domains[0])
+		if result := app.history.last("example.com"); result.Unchanged {
+			t.Fatalf("expected a zone with errors to be read on every reconcile, got %+v", result)
+		}
+	}
+}
+
+func TestReconcileVerifyIntervalProviderReload(t *testing.T) {
+	t.Cleanup(func() { forgetVerified("example.com") })
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	// Providers by account, their records kept across reloads
+	accounts := map[string]*fakeProvider{"first": {}, "second": {}}
+	load := func(account string) *App {
+		t.Helper()
+		app := &App{
+			OwnerID:        "test-caddy",
+			VerifyInterval: caddy.Duration(time.Hour),
+			LazyProviders:  true,
+			Domains: []*Domain{{
+				Zone:           "example.com",
+				DNSProviderRaw: json.RawMessage(`{"name":"fake","account":"` + account + `"}`),
+				Records:        []*Record{{Name: "www", Type: "A", Value: "192.0.2.1"}},
+			}},
+		}
+		ctx, cancel := caddy.NewContext(caddy.Context{Context: t.Context()})
+		t.Cleanup(cancel)
+		if err := app.Provision(ctx); err != nil {
+			t.Fatalf("Provision failed: %v", err)
+		}
+		// Loading the module clears its raw config, as caddy does
+		domain := app.Domains[0]
+		domain.lazy.load = func() (any, error) {
+			domain.DNSProviderRaw = nil
+			return accounts[account], nil
+		}
+		return app
+	}
+	reconcile := func(app *App) *ReconcileResult {
+		t.Helper()
+		if err := app.reconcileDomain(app.Domains[0]); err != nil {
+			t.Fatalf("reconcileDomain failed: %v", err)
+		}
+		return app.history.last("example.com")
+	}
+
+	reconcile(load("first"))
+	if result := reconcile(load("first")); result.Unchanged {
+		t.Fatalf("expected the first reconcile after creating to verify the zone, got %+v", result)
+	}
+	if result := reconcile(load("first")); !result.Unchanged {
+		t.Fatalf("expected a reload without changes to skip the verified zone, got %+v", result)
+	}
+
+	// A reload changing only the provider account reads the zone again
+	if result := reconcile(load("second")); result.Unchanged || len(result.Created) != 1 {
+		t.Errorf("expected www to be created in the new account, got %+v", result)
+	}
+}
@@ -0,0 +1,79 @@
+package dnsregister
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeEscapingProvider returns the wildcard label escaped as "\052", as
+// some providers do.
+type fakeEscapingProvider struct {
+	fakeProvider
+}
+
+func (p *fakeEscapingProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	recs, err := p.fakeProvider.GetRecords(ctx, zone)
+	escaped := make([]libdns.Record, len(recs))
+	for i, rec := range recs {
+		rr := rec.RR()
+		rr.Name = strings.ReplaceAll(rr.Name, "*", `\052`)
+		escaped[i] = rr
+	}
+	return escaped, err
+}
+
+func TestReconcileWildcardAndSpecific(t *testing.T) {
+	records := func() []*Record {
+		return []*Record{
+			{Name: "*", Type: "A", Value: "192.0.2.1"},
+			{Name: "www", Type: "A", Value: "192.0.2.2"},
+			{Name: "*.apps", Type: "A", Value: "192.0.2.3"},
+			{Name: "apps", Type: "A", Value: "192.0.2.4"},
+		}
+	}
+	for _, escaping := range []bool{false, true} {
+		var provider interface {
+			libdns.RecordGetter
+			libdns.RecordSetter
+		}
+		fake := &fakeProvider{}
+		provider = fake
+		if escaping {
+			escaper := &fakeEscapingProvider{}
+			fake, provider = &escaper.fakeProvider, escaper
+		}
+		app := newTestApp(t, provider, records()...)
+		app.history = newReconcileHistory(0)
+		domain := app.Domains[0]
+
+		for i := 0; i < 2; i++ {
+			if err := app.reconcileDomain(domain); err != nil {
+				t.Fatalf("escaping=%v: reconcileDomain failed: %v", escaping, err)
+			}
+		}
+		result := app.history.last("example.com")
+		if len(result.Created) != 0 || len(result.Updated) != 0 || len(result.Deleted) != 0 || len(result.Errors) != 0 {
+			t.Errorf("escaping=%v: expected no churn, got %+v", escaping, result)
+		}
+		if len(fake.records) != 8 {
+			t.Errorf("escaping=%v: expected 4 records with their markers, got %v", escaping, fake.records)
+		}
+
+		// Each is owned and reconciled on its own
+		domain.Records = records()[1:]
+		domain.Records[0].Value = "192.0.2.20"
+		if err := app.reconcileDomain(domain); err != nil {
+			t.Fatalf("escaping=%v: reconcileDomain failed: %v", escaping, err)
+		}
+		result = app.history.last("example.com")
+		if len(result.Deleted) != 1 || result.Deleted[0] != "*:A" || len(result.Updated) != 1 || result.Updated[0] != "www:A" {
+			t.Errorf("escaping=%v: expected *:A deleted and www:A updated, got %+v", escaping, result)
+		}
+		if fake.has("*", "A") || fake.has("_cdr.*", "TXT") || !fake.has("*.apps", "A") || !hasRecord(fake.records, "www", "A", "192.0.2.20") {
+			t.Errorf("escaping=%v: expected only the apex wildcard to be removed, got %v", escaping, fake.records)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package dnsregister
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// saturationWarnAfter is how long all reconcile workers must be busy,
+// with reconciles waiting for one, before a warning is logged.
+const saturationWarnAfter = time.Minute
+
+// reconcileWorkers bounds the number of reconciles that run at once.
+// Reconciles beyond the bound wait for a worker. The pool is saturated
+// while any reconcile is waiting.
+type reconcileWorkers struct {
+	slots   chan struct{}
+	active  atomic.Int64
+	waiting atomic.Int64
+
+	// saturatedSince is the time, in Unix nanoseconds, since which the
+	// pool has been saturated, or zero if it isn't.
+	saturatedSince atomic.Int64
+	warned         atomic.Bool
+}
+
+// newReconcileWorkers returns a pool of max workers, or an unbounded
+// pool if max is zero or less.
+func newReconcileWorkers(max int) *reconcileWorkers {
+	w := new(reconcileWorkers)
+	if max > 0 {
+		w.slots = make(chan struct{}, max)
+	}
+	return w
+}
+
+// acquire waits for a worker to run a reconcile on, or until ctx is
+// done. The returned func releases the worker.
+func (w *reconcileWorkers) acquire(ctx context.Context, logger *zap.Logger) (release func(), err error) {
+	if w == nil {
+		return func() {}, nil
+	}
+	if w.slots != nil {
+		select {
+		case w.slots <- struct{}{}:
+		default:
+			if err := w.wait(ctx, logger); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.active.Add(1)
+	updateActiveWorkersMetric(1)
+	return func() {
+		w.active.Add(-1)
+		updateActiveWorkersMetric(-1)
+		if w.slots != nil {
+			<-w.slots
+		}
+	}, nil
+}
+
+// wait waits for a worker of the saturated pool, warning if the pool
+// has been saturated for longer than saturationWarnAfter.
+func (w *reconcileWorkers) wait(ctx context.Context, logger *zap.Logger) error {
+	w.waiting.Add(1)
+	w.saturatedSince.CompareAndSwap(0, time.Now().UnixNano())
+	w.checkSaturation(logger)
+
+	defer func() {
+		if w.waiting.Add(-1) == 0 {
+			w.checkSaturation(logger)
+			w.saturatedSince.Store(0)
+			w.warned.Store(false)
+		}
+	}()
+
+	select {
+	case w.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// checkSaturation logs a warning, once per period of saturation, if
+// the pool has been saturated for longer than saturationWarnAfter.
+func (w *reconcileWorkers) checkSaturation(logger *zap.Logger) {
+	since := w.saturatedSince.Load()
+	if since == 0 {
+		return
+	}
+	saturated := time.Since(time.Unix(0, since))
+	if saturated < saturationWarnAfter || !w.warned.CompareAndSwap(false, true) {
+		return
+	}
+	logger.Warn("all reconcile workers busy; reconciles are triggered faster than the provider applies them, consider raising max_concurrency",
+		zap.Int("max_concurrency", cap(w.slots)),
+		zap.Int64("waiting", w.waiting.Load()),
+		zap.Duration("saturated_for", saturated.Round(time.Second)))
+}
@@ -0,0 +1,81 @@
+package dnsregister
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestReconcileWorkers(t *testing.T) {
+	initMetrics(prometheus.NewRegistry())
+	base := gaugeValue(t, dnsRegisterMetrics.workers)
+
+	core, logs := observer.New(zap.WarnLevel)
+	logger := zap.New(core)
+	w := newReconcileWorkers(1)
+
+	release, err := w.acquire(context.Background(), logger)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	if got := gaugeValue(t, dnsRegisterMetrics.workers) - base; got != 1 {
+		t.Errorf("expected 1 active worker, got %v", got)
+	}
+
+	// The pool is saturated: a second reconcile waits for the worker
+	acquired := make(chan func())
+	go func() {
+		release, err := w.acquire(context.Background(), logger)
+		if err != nil {
+			t.Errorf("acquire failed: %v", err)
+		}
+		acquired <- release
+	}()
+	for w.waiting.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Pretend the pool has been saturated for long enough to warn
+	w.saturatedSince.Store(time.Now().Add(-2 * saturationWarnAfter).UnixNano())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := w.acquire(ctx, logger); err == nil {
+		t.Error("expected acquire to fail once its context is done")
+	}
+	if got := logs.FilterMessageSnippet("reconcile workers busy").Len(); got != 1 {
+		t.Errorf("expected 1 saturation warning, got %d", got)
+	}
+
+	release()
+	select {
+	case release := <-acquired:
+		release()
+	case <-time.After(time.Second):
+		t.Fatal("waiting reconcile did not get the worker")
+	}
+	if got := gaugeValue(t, dnsRegisterMetrics.workers) - base; got != 0 {
+		t.Errorf("expected no active workers, got %v", got)
+	}
+	if w.saturatedSince.Load() != 0 || w.warned.Load() {
+		t.Error("expected saturation to be reset once no reconcile waits")
+	}
+	if got := logs.Len(); got != 1 {
+		t.Errorf("expected warning once per saturation, got %d", got)
+	}
+}
+
+func TestReconcileWorkersUnbounded(t *testing.T) {
+	w := newReconcileWorkers(0)
+	for i := 0; i < 3; i++ {
+		if _, err := w.acquire(context.Background(), zap.NewNop()); err != nil {
+			t.Fatalf("acquire failed: %v", err)
+		}
+	}
+	if got := w.active.Load(); got != 3 {
+		t.Errorf("expected 3 active workers, got %d", got)
+	}
+}
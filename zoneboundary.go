@@ -0,0 +1,84 @@
+package dnsregister
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// Zone boundary check modes, for records that appear to belong to a
+// subzone of the domain's zone.
+const (
+	zoneBoundaryWarn = "warn"
+	zoneBoundarySkip = "skip"
+	zoneBoundaryOff  = "off"
+)
+
+// zoneCuts returns the names, relative to the domain's zone, at which
+// parts of the zone are delegated or managed as zones of their own:
+// names with NS records below the apex, and the zones of other
+// configured domains that lie within this one.
+func (a *App) zoneCuts(domain *Domain, existing []libdns.Record) []string {
+	zone := strings.TrimSuffix(domain.Zone, ".")
+	seen := make(map[string]bool)
+
+	for _, rec := range existing {
+		rr := rec.RR()
+		if rr.Type == "NS" && rr.Name != "" && rr.Name != "@" {
+			seen[rr.Name] = true
+		}
+	}
+	for _, other := range a.Domains {
+		sub := strings.TrimSuffix(other.Zone, ".")
+		if rel, ok := strings.CutSuffix(sub, "."+zone); ok {
+			seen[rel] = true
+		}
+	}
+
+	cuts := make([]string, 0, len(seen))
+	for cut := range seen {
+		cuts = append(cuts, cut)
+	}
+	sort.Strings(cuts)
+	return cuts
+}
+
+// crossZoneRecords returns the desired record sets whose names lie at
+// or below a zone cut, keyed by set key, with the cut they fall under.
+// Such records would be published in the parent zone where resolvers
+// never look for them. NS and DS records at the cut itself are part of
+// the delegation and belong in the parent zone, as do glue records for
+// the delegation's nameservers.
+func (a *App) crossZoneRecords(domain *Domain, desired map[string][]*Record, existing []libdns.Record) map[string]string {
+	crossing := make(map[string]string)
+	if a.ZoneBoundary == zoneBoundaryOff {
+		return crossing
+	}
+
+	glue := make(map[string]bool)
+	nsGlue, _ := glueSets(domain.Zone, desired)
+	for _, keys := range nsGlue {
+		for _, key := range keys {
+			glue[key] = true
+		}
+	}
+
+	cuts := a.zoneCuts(domain, existing)
+	for key, recs := range desired {
+		if glue[key] {
+			continue
+		}
+		name, typ := recs[0].Name, recs[0].Type
+		for _, cut := range cuts {
+			if name == cut && (typ == "NS" || typ == "DS") {
+				continue
+			}
+			if name == cut || strings.HasSuffix(name, "."+cut) {
+				crossing[key] = cut
+				break
+			}
+		}
+	}
+	return crossing
+}
@@ -0,0 +1,66 @@
+package dnsregister
+
+import (
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestCrossZoneRecords(t *testing.T) {
+	app := newTestApp(t, &fakeProvider{})
+	app.Domains = append(app.Domains, &Domain{Zone: "lab.example.com"})
+	existing := []libdns.Record{
+		libdns.RR{Name: "@", Type: "NS", Data: "ns1.example.net."},
+		libdns.RR{Name: "dev", Type: "NS", Data: "ns1.dev-dns.example.net."},
+	}
+	desired := map[string][]*Record{
+		"www:A":          {{Name: "www", Type: "A"}},
+		"_acme.www:TXT":  {{Name: "_acme.www", Type: "TXT"}},
+		"api.dev:A":      {{Name: "api.dev", Type: "A"}},
+		"dev:NS":         {{Name: "dev", Type: "NS"}},
+		"lab:A":          {{Name: "lab", Type: "A"}},
+		"host.lab:AAAA":  {{Name: "host.lab", Type: "AAAA"}},
+		"devices.lab2:A": {{Name: "devices.lab2", Type: "A"}},
+	}
+
+	got := app.crossZoneRecords(app.Domains[0], desired, existing)
+	want := map[string]string{
+		"api.dev:A":     "dev",
+		"lab:A":         "lab",
+		"host.lab:AAAA": "lab",
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	for key, cut := range want {
+		if got[key] != cut {
+			t.Errorf("expected %s to cross into %s, got %q", key, cut, got[key])
+		}
+	}
+
+	app.ZoneBoundary = zoneBoundaryOff
+	if got := app.crossZoneRecords(app.Domains[0], desired, existing); len(got) != 0 {
+		t.Errorf("expected no check when off, got %v", got)
+	}
+}
+
+func TestReconcileZoneBoundarySkip(t *testing.T) {
+	provider := &fakeProvider{records: []libdns.Record{
+		libdns.RR{Name: "dev", Type: "NS", Data: "ns1.dev-dns.example.net."},
+	}}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "api.dev", Type: "A", Value: "192.0.2.2"},
+	)
+	app.ZoneBoundary = zoneBoundarySkip
+
+	if err := app.reconcileDomain(app.Domains[0]); err != nil {
+		t.Fatalf("reconcileDomain failed: %v", err)
+	}
+	if !hasRecord(provider.records, "www", "A", "192.0.2.1") {
+		t.Errorf("expected www to be created, got %v", provider.records)
+	}
+	if hasRecord(provider.records, "api.dev", "A", "192.0.2.2") {
+		t.Errorf("expected record below delegation to be skipped, got %v", provider.records)
+	}
+}
@@ -0,0 +1,41 @@
+package dnsregister
+
+import (
+	"context"
+	"sync"
+)
+
+// zoneLocks serializes the operations that read a zone and then change
+// it based on what they read, so that two of them can't both act on
+// the same state, e.g. both create a record set and its marker, or
+// both rewrite a marker shared by several record sets at a name.
+type zoneLocks struct {
+	mu    sync.Mutex
+	zones map[string]chan struct{}
+}
+
+func newZoneLocks() *zoneLocks {
+	return &zoneLocks{zones: make(map[string]chan struct{})}
+}
+
+// lock waits until zone's lock is free and takes it, or returns ctx's
+// error if ctx is done first. The returned func releases the lock.
+func (l *zoneLocks) lock(ctx context.Context, zone string) (unlock func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	l.mu.Lock()
+	ch, ok := l.zones[zone]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		l.zones[zone] = ch
+	}
+	l.mu.Unlock()
+
+	select {
+	case ch <- struct{}{}:
+		return func() { <-ch }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,117 @@
+package dnsregister
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeLaggingProvider is an append-only fakeProvider whose reads take
+// a while, widening the window between a reconcile's read and its
+// writes.
+type fakeLaggingProvider struct {
+	fake fakeProvider
+}
+
+func (p *fakeLaggingProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	recs, err := p.fake.GetRecords(ctx, zone)
+	time.Sleep(10 * time.Millisecond)
+	return recs, err
+}
+
+func (p *fakeLaggingProvider) AppendRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	return p.fake.AppendRecords(ctx, zone, recs)
+}
+
+func (p *fakeLaggingProvider) DeleteRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	return p.fake.DeleteRecords(ctx, zone, recs)
+}
+
+func TestConcurrentReconcilesOfZone(t *testing.T) {
+	provider := &fakeLaggingProvider{}
+	app := newTestApp(t, provider,
+		&Record{Name: "www", Type: "A", Value: "192.0.2.1"},
+		&Record{Name: "www", Type: "AAAA", Value: "2001:db8::1"})
+	app.zoneLocks = newZoneLocks()
+
+	reconcileConcurrently := func() {
+		t.Helper()
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := app.reconcileDomain(app.Domains[0]); err != nil {
+					t.Errorf("reconcileDomain failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	count := func(name, typ string) int {
+		provider.fake.mu.Lock()
+		defer provider.fake.mu.Unlock()
+		n := 0
+		for _, rec := range provider.fake.records {
+			if rr := rec.RR(); rr.Name == name && rr.Type == typ {
+				n++
+			}
+		}
+		return n
+	}
+
+	reconcileConcurrently()
+	for _, key := range [][2]string{{"www", "A"}, {"www", "AAAA"}, {"_cdr.www", "TXT"}} {
+		if n := count(key[0], key[1]); n != 1 {
+			t.Errorf("expected one %s %s record, got %d", key[0], key[1], n)
+		}
+	}
+
+	// Removing one of the name's sets keeps its shared marker
+	app.Domains[0].Records = app.Domains[0].Records[:1]
+	reconcileConcurrently()
+	if n := count("www", "AAAA"); n != 0 {
+		t.Errorf("expected www AAAA to be deleted, got %d", n)
+	}
+	if count("www", "A") != 1 || count("_cdr.www", "TXT") != 1 {
+		t.Errorf("expected www A and one marker to be kept, got %v", provider.fake.records)
+	}
+
+	// Removing the last one deletes it
+	app.Domains[0].Records = nil
+	reconcileConcurrently()
+	if n := len(provider.fake.records); n != 0 {
+		t.Errorf("expected all records to be deleted, got %v", provider.fake.records)
+	}
+}
+
+func TestZoneLocksCancel(t *testing.T) {
+	locks := newZoneLocks()
+	unlock, err := locks.lock(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("lock failed: %v", err)
+	}
+
+	// Other zones aren't held up
+	unlockOther, err := locks.lock(context.Background(), "example.org")
+	if err != nil {
+		t.Fatalf("lock of another zone failed: %v", err)
+	}
+	unlockOther()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := locks.lock(ctx, "example.com"); err == nil {
+		t.Error("expected waiting for a held lock to end with the context")
+	}
+
+	unlock()
+	unlock, err = locks.lock(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("lock after unlock failed: %v", err)
+	}
+	unlock()
+}